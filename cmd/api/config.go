@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"ecom-go/internal/config"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = `usage: api config <command>
+
+commands:
+  print   print the effective configuration, with secrets redacted`
+
+// runConfig runs the "config" subcommand
+func runConfig(args []string) error {
+	if len(args) != 1 || args[0] != "print" {
+		return errors.New(configUsage)
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(config.RedactedSettings()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -9,7 +9,9 @@ import (
 	"syscall"
 	"time"
 
+	"ecom-go/internal/auth"
 	"ecom-go/internal/config"
+	"ecom-go/internal/featureflags"
 	"ecom-go/internal/handler"
 	"ecom-go/internal/middleware"
 	"ecom-go/internal/repository"
@@ -21,11 +23,42 @@ import (
 
 func main() {
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, args, err := config.LoadConfig(os.Args[1:])
 	if err != nil {
 		logger.Fatal("Failed to load config", "error", err)
 	}
 
+	// Run a subcommand instead of the server if one was given
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			if err := runMigrate(cfg, args[1:]); err != nil {
+				logger.Fatal("Migration failed", "error", err)
+			}
+			return
+		case "users":
+			if err := runUsers(cfg, args[1:]); err != nil {
+				logger.Fatal("Users command failed", "error", err)
+			}
+			return
+		case "config":
+			if err := runConfig(args[1:]); err != nil {
+				logger.Fatal("Config command failed", "error", err)
+			}
+			return
+		default:
+			logger.Fatal("Unknown command", "command", args[0])
+		}
+	}
+
+	logger.Configure(cfg.Log.LoggerOptions())
+
+	// Access tokens identify the users, the API doesn't start without their secret
+	tokens, err := auth.NewTokens(cfg.Auth)
+	if err != nil {
+		logger.Fatal("Failed to set up authentication", "error", err)
+	}
+
 	// Set up repository
 	repoFactory, err := repository.NewFactory(cfg)
 	if err != nil {
@@ -37,23 +70,75 @@ func main() {
 		}
 	}()
 
+	// Set up feature flags, those toggled through the admin API are stored in the database
+	flags := featureflags.NewEvaluator(cfg.FeatureFlags, repoFactory.FeatureFlag)
+	if err := flags.Refresh(context.Background()); err != nil {
+		logger.Error("Failed to read feature flags, using the configured ones", "error", err)
+	}
+	flagsCtx, stopFlags := context.WithCancel(context.Background())
+	defer stopFlags()
+	go flags.Run(flagsCtx)
+
 	// Set up services
 	userService := service.NewUserService(repoFactory.User)
 	// TODO: Add other services here
-	productService := service.NewProductService(repoFactory.Product)
-	orderService := service.NewOrderService(repoFactory.Order)
+	productService := service.NewProductService(repoFactory.Product, repoFactory.Reservation)
+	orderService := service.NewOrderService(repoFactory.Order, repoFactory.Stock, repoFactory.Reservation, repoFactory.Warehouse, repoFactory.TxManager, cfg)
+	reservationService := service.NewReservationService(repoFactory.Reservation, cfg.Reservations)
+	returnService := service.NewReturnService(repoFactory.Return, repoFactory.Stock, orderService, repoFactory.TxManager, cfg.Returns)
+	inventoryService := service.NewInventoryService(repoFactory.Stock, repoFactory.Product, repoFactory.Warehouse, repoFactory.Order, cfg.Inventory)
+	warehouseService := service.NewWarehouseService(repoFactory.Warehouse)
+	deadLetterService := service.NewDeadLetterService(repoFactory.DeadLetter)
+	jobService := service.NewJobService(repoFactory.JobRun)
+	inboxService := service.NewInboxService(repoFactory.Notification, repoFactory.NotificationPreference)
+	webhookService := service.NewWebhookService(repoFactory.Webhook)
+	featureFlagService := service.NewFeatureFlagService(repoFactory.FeatureFlag, flags)
+	// Set up rate limiting
+	limiter, err := middleware.NewRateLimiter(cfg)
+	if err != nil {
+		logger.Fatal("Failed to create rate limiter", "error", err)
+	}
+
+	apiRateLimit := middleware.NewReloadableRateLimitPolicy(middleware.DefaultRateLimitPolicy(cfg.RateLimit))
+
+	// Apply the settings that can change without a restart
+	watchCtx, stopWatching := context.WithCancel(context.Background())
+	defer stopWatching()
+	watchConfig(watchCtx, cfg, apiRateLimit, repoFactory.ProductCache, flags)
+
 	// Set up HTTP server with Gin
-	router := setupRouter()
+	router := setupRouter(cfg)
 
 	// Register handlers
 	api := router.Group("/api/v1")
-	userHandler := handler.NewUserHandler(userService)
+	api.Use(middleware.Authenticate(tokens, auth.NewAPIKeys(cfg.Auth.APIKeys)))
+	api.Use(middleware.RateLimit(limiter, "api", apiRateLimit))
+	api.Use(middleware.FeatureFlags(flags))
+	userHandler := handler.NewUserHandler(userService, tokens, limiter)
 	userHandler.Register(api)
 	// TODO: Add other handlers here
 	productHandler := handler.NewProductHandler((productService))
 	productHandler.Register(api)
 	orderHandler := handler.NewOrderHandler((orderService))
 	orderHandler.Register(api)
+	returnHandler := handler.NewReturnHandler(returnService)
+	returnHandler.Register(api)
+	inventoryHandler := handler.NewInventoryHandler(inventoryService)
+	inventoryHandler.Register(api)
+	reservationHandler := handler.NewReservationHandler(reservationService)
+	reservationHandler.Register(api)
+	warehouseHandler := handler.NewWarehouseHandler(warehouseService)
+	warehouseHandler.Register(api)
+	deadLetterHandler := handler.NewDeadLetterHandler(deadLetterService)
+	deadLetterHandler.Register(api)
+	jobHandler := handler.NewJobHandler(jobService)
+	jobHandler.Register(api)
+	inboxHandler := handler.NewInboxHandler(inboxService)
+	inboxHandler.Register(api)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	webhookHandler.Register(api)
+	featureFlagHandler := handler.NewFeatureFlagHandler(featureFlagService)
+	featureFlagHandler.Register(api)
 	// Create HTTP server
 	server := &http.Server{
 		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
@@ -89,17 +174,49 @@ func main() {
 	logger.Info("Server exited properly")
 }
 
-func setupRouter() *gin.Engine {
+// watchConfig reloads the configuration when its files change, applying the
+// new log settings, rate limits, cache TTL and feature flags
+func watchConfig(ctx context.Context, cfg *config.Config, apiRateLimit *middleware.ReloadableRateLimitPolicy, productCache *repository.CachedProductRepo, flags *featureflags.Evaluator) {
+	watcher, err := config.NewWatcher(cfg)
+	if err != nil {
+		logger.Error("Config changes won't be reloaded", "error", err)
+		return
+	}
+
+	config.Subscribe(watcher, func(c *config.Config) config.LogConfig { return c.Log }, func(event config.Event[config.LogConfig]) {
+		logger.Configure(event.New.LoggerOptions())
+	})
+	config.Subscribe(watcher, func(c *config.Config) config.RateLimitConfig { return c.RateLimit }, func(event config.Event[config.RateLimitConfig]) {
+		apiRateLimit.Store(middleware.DefaultRateLimitPolicy(event.New))
+	})
+	if productCache != nil {
+		config.Subscribe(watcher, func(c *config.Config) config.CacheConfig { return c.Cache }, func(event config.Event[config.CacheConfig]) {
+			productCache.SetTTL(event.New.TTL)
+		})
+	}
+	config.Subscribe(watcher, func(c *config.Config) config.FeatureFlagsConfig { return c.FeatureFlags }, func(event config.Event[config.FeatureFlagsConfig]) {
+		flags.SetConfig(event.New)
+	})
+	go watcher.Run(ctx)
+}
+
+func setupRouter(cfg *config.Config) *gin.Engine {
 	// Set Gin mode
-	if os.Getenv("APP_ENV") == "production" {
+	if cfg.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
 	router := gin.New()
+	// Only the configured proxies are believed about the client's IP, which requests are rate limited by
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Fatal("Invalid trusted proxies", "error", err)
+	}
 
 	// Add middlewares
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger())
 	router.Use(gin.Recovery())
+	router.Use(middleware.ReadYourWrites(cfg.Database.ReadYourWritesWindow))
 
 	// Health check route
 	router.GET("/health", func(c *gin.Context) {
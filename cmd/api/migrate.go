@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/migrate"
+	"ecom-go/internal/repository"
+	"ecom-go/pkg/logger"
+)
+
+const migrateUsage = `usage: api migrate <command>
+
+commands:
+  up             apply all pending migrations
+  down [steps]   revert the last applied migrations, 1 by default
+  status         list migrations and when they were applied
+  to <version>   migrate up or down to the version, 0 reverts everything`
+
+// runMigrate runs the "migrate" subcommand
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	db, err := repository.NewDatabase(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := migrate.New(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	switch command, params := args[0], args[1:]; {
+	case command == "up" && len(params) == 0:
+		count, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("Applied migrations", "count", count)
+
+	case command == "down" && len(params) <= 1:
+		steps := 1
+		if len(params) == 1 {
+			if steps, err = strconv.Atoi(params[0]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", params[0])
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		logger.Info("Reverted migrations", "count", count)
+
+	case command == "to" && len(params) == 1:
+		version, err := strconv.ParseInt(params[0], 10, 64)
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version %q", params[0])
+		}
+		count, err := migrator.To(ctx, version)
+		if err != nil {
+			return err
+		}
+		logger.Info("Migrated", "version", version, "count", count)
+
+	case command == "status" && len(params) == 0:
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		printMigrationStatus(statuses)
+
+	default:
+		return errors.New(migrateUsage)
+	}
+	return nil
+}
+
+// printMigrationStatus prints the migrations as a table
+func printMigrationStatus(statuses []migrate.MigrationStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		name, appliedAt := status.Name, "pending"
+		if status.Unknown {
+			name = "(unknown to this build)"
+		}
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", status.Version, name, appliedAt)
+	}
+	w.Flush()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	"ecom-go/pkg/logger"
+)
+
+const usersUsage = `usage: api users <command>
+
+commands:
+  set-role <email> <role>   give a user the "user" or "admin" role`
+
+// runUsers runs the "users" subcommand, which makes the first admins as only
+// admins can grant the role through the API
+func runUsers(cfg *config.Config, args []string) error {
+	if len(args) != 3 || args[0] != "set-role" {
+		return errors.New(usersUsage)
+	}
+	email, role := args[1], args[2]
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return fmt.Errorf("unknown role %q", role)
+	}
+
+	db, err := repository.NewDatabase(&cfg.Database)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	ctx := context.Background()
+	users := repository.NewUserRepo(db)
+	user, err := users.GetByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("failed to get user %s: %w", email, err)
+	}
+	user.Role = role
+	if err := users.Update(ctx, user); err != nil {
+		return err
+	}
+	logger.Info("Set user role", "user_id", user.ID, "role", role)
+	return nil
+}
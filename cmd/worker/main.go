@@ -2,12 +2,24 @@ package main
 
 import (
 	"context"
+	"ecom-go/internal/broker"
+	"ecom-go/internal/events"
+	"ecom-go/internal/notification"
+	"ecom-go/internal/outbox"
 	"ecom-go/internal/repository"
+	"ecom-go/internal/scheduler"
+	"ecom-go/internal/service"
+	"ecom-go/internal/webhook"
+	"ecom-go/internal/worker"
+	"errors"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	"ecom-go/internal/config"
+	appError "ecom-go/pkg/errors"
 	"ecom-go/pkg/logger"
 )
 
@@ -15,10 +27,11 @@ func main() {
 	logger.Info("Starting worker service")
 
 	// Load configuration
-	cfg, err := config.LoadConfig()
+	cfg, _, err := config.LoadConfig(os.Args[1:])
 	if err != nil {
 		logger.Fatal("Failed to load config", "error", err)
 	}
+	logger.Configure(cfg.Log.LoggerOptions())
 
 	// Set up repository
 	repoFactory, err := repository.NewFactory(cfg)
@@ -32,27 +45,223 @@ func main() {
 	}()
 
 	// Set up services
+	reservationService := service.NewReservationService(repoFactory.Reservation, cfg.Reservations)
+	inventoryService := service.NewInventoryService(repoFactory.Stock, repoFactory.Product, repoFactory.Warehouse, repoFactory.Order, cfg.Inventory)
 	// TODO: Add other services here
 
+	emailTemplates, err := notification.NewTemplates(cfg.Notifications.TemplateVersions)
+	if err != nil {
+		logger.Fatal("Failed to load email templates", "error", err)
+	}
+	emailSender, err := notification.NewEmailSender(cfg)
+	if err != nil {
+		logger.Fatal("Failed to create email sender", "error", err)
+	}
+	notificationService := service.NewNotificationService(emailTemplates, emailSender, repoFactory.EmailLog, repoFactory.Notification, repoFactory.NotificationPreference, repoFactory.User, repoFactory.Order, repoFactory.Product, repoFactory.Return)
+
+	alerts, err := notification.NewNotifier(cfg, emailSender)
+	if err != nil {
+		logger.Fatal("Failed to create alert notifier", "error", err)
+	}
+
+	// Connect to the message broker
+	msgBroker, err := broker.New(cfg, repoFactory.DB())
+	if err != nil {
+		logger.Fatal("Failed to connect to message broker", "error", err)
+	}
+	defer func() {
+		if err := msgBroker.Close(); err != nil {
+			logger.Error("Error closing message broker", "error", err)
+		}
+	}()
+
+	// Register queue handlers
+	runtime := worker.NewRuntime(msgBroker, repoFactory.DeadLetter, cfg.Worker)
+	worker.Register(runtime, worker.QueueConfig{
+		Name:     "worker.low-stock",
+		Bindings: []string{events.StockChanged},
+	}, func(ctx context.Context, event events.StockChangedEvent) error {
+		if event.Quantity >= 0 {
+			return nil
+		}
+		return inventoryService.CheckLowStock(ctx, alerts)
+	})
+
+	registerNotifications(runtime, notificationService)
+
+	// Drop cached products whose stock changed. A memory cache is private to
+	// each process, so the API's entries only expire with the TTL
+	if repoFactory.ProductCache != nil && cfg.Cache.Backend == "memory" {
+		logger.Warn("Product cache is in memory, the API serves stock changes made here once its entries expire", "ttl", cfg.Cache.TTL)
+	} else if repoFactory.ProductCache != nil {
+		worker.Register(runtime, worker.QueueConfig{
+			Name:     "worker.cache.products",
+			Bindings: []string{events.StockChanged},
+		}, func(ctx context.Context, event events.StockChangedEvent) error {
+			repoFactory.ProductCache.Invalidate(ctx, event.ProductID)
+			return nil
+		})
+	}
+
+	// Send events to partners' webhook endpoints
+	webhooks := webhook.NewDispatcher(repoFactory.Webhook, msgBroker, cfg.Webhooks)
+	runtime.Handle(worker.QueueConfig{
+		Name:     webhook.FanoutQueue,
+		Bindings: webhook.Bindings,
+	}, webhooks.Fanout)
+	worker.Register(runtime, worker.QueueConfig{
+		Name:  webhook.DeliveryQueue,
+		Retry: cfg.Webhooks.Retry,
+	}, webhooks.Deliver)
+
+	// Relay events written to the outbox to the broker
+	relay := outbox.NewRelay(repoFactory.Outbox, msgBroker, cfg.Outbox)
+
+	// Schedule recurring jobs
+	jobs := scheduler.New(repoFactory.JobRun)
+	if err := scheduleJobs(jobs, cfg.Scheduler, reservationService, inventoryService, alerts); err != nil {
+		logger.Fatal("Failed to schedule jobs", "error", err)
+	}
+
 	// Create a context that is canceled when a signal is received
-	_, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Apply the log settings when the configuration changes
+	if watcher, err := config.NewWatcher(cfg); err != nil {
+		logger.Error("Config changes won't be reloaded", "error", err)
+	} else {
+		config.Subscribe(watcher, func(c *config.Config) config.LogConfig { return c.Log }, func(event config.Event[config.LogConfig]) {
+			logger.Configure(event.New.LoggerOptions())
+		})
+		go watcher.Run(ctx)
+	}
+
 	// Listen for OS signals
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-	// Start workers (to be implemented)
-	// go startOrderProcessor(ctx, log, cfg)
-	// go startNotificationProcessor(ctx, log, cfg)
+	// Start workers
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		relay.Run(ctx)
+	}()
+	runtimeErr := make(chan error, 1)
+	go func() {
+		runtimeErr <- runtime.Run(ctx)
+	}()
+	jobs.Start(ctx)
 
 	logger.Info("Worker service started")
 
-	// Wait for signal
-	sig := <-sigCh
-	logger.Info("Received signal, shutting down", "signal", sig)
+	// Wait for a signal, or for the runtime to stop consuming on its own
+	select {
+	case sig := <-sigCh:
+		logger.Info("Received signal, shutting down", "signal", sig)
+		// Stop consuming and let in-flight messages finish
+		cancel()
+		err = <-runtimeErr
+	case err = <-runtimeErr:
+		logger.Error("Worker runtime stopped, shutting down", "error", err)
+		cancel()
+	}
+	jobs.Stop()
+	wg.Wait()
 
-	// Give workers a chance to finish gracefully
-	cancel()
+	if err != nil {
+		// Exit non-zero so that the process is restarted
+		logger.Fatal("Worker service stopped", "error", err)
+	}
 	logger.Info("Worker service stopped")
 }
+
+// scheduleJobs registers the worker's recurring jobs with the scheduler
+func scheduleJobs(jobs *scheduler.Scheduler, cfg config.SchedulerConfig, reservationService *service.ReservationService, inventoryService *service.InventoryService, alerts notification.Notifier) error {
+	if err := jobs.Add("expire_reservations", cfg.ExpireReservations, func(ctx context.Context) error {
+		count, err := reservationService.ExpireStale(ctx)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			logger.Info("Released expired reservations", "count", count)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if err := jobs.Add("low_stock", cfg.LowStock, func(ctx context.Context) error {
+		return inventoryService.CheckLowStock(ctx, alerts)
+	}); err != nil {
+		return err
+	}
+
+	if err := jobs.Add("sales_rollup", cfg.SalesRollup, func(ctx context.Context) error {
+		yesterday := time.Now().UTC().AddDate(0, 0, -1)
+		count, err := inventoryService.RollupDailySales(ctx, yesterday)
+		if err != nil {
+			return err
+		}
+		logger.Info("Rolled up daily sales", "day", yesterday.Format(time.DateOnly), "products", count)
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	return jobs.Add("abandoned_carts", cfg.AbandonedCarts, func(ctx context.Context) error {
+		count, err := reservationService.RemindAbandonedCarts(ctx, cfg.AbandonedCartAfter)
+		if count > 0 {
+			logger.Info("Reminded users of abandoned carts", "count", count)
+		}
+		return err
+	})
+}
+
+// registerNotifications registers the queues sending transactional emails,
+// one per email so that a failing email does not hold up the others
+func registerNotifications(runtime *worker.Runtime, notificationService *service.NotificationService) {
+	worker.Register(runtime, worker.QueueConfig{
+		Name:     "worker.notifications.welcome",
+		Bindings: []string{events.UserCreated},
+	}, func(ctx context.Context, event events.UserCreatedEvent) error {
+		return notificationError(notificationService.SendWelcome(ctx, messageID(ctx), event.UserID))
+	})
+
+	worker.Register(runtime, worker.QueueConfig{
+		Name:     "worker.notifications.order-confirmation",
+		Bindings: []string{events.OrderCreated},
+	}, func(ctx context.Context, event events.OrderCreatedEvent) error {
+		return notificationError(notificationService.SendOrderConfirmation(ctx, messageID(ctx), event.OrderID))
+	})
+
+	worker.Register(runtime, worker.QueueConfig{
+		Name:     "worker.notifications.order-shipped",
+		Bindings: []string{events.OrderShipped},
+	}, func(ctx context.Context, event events.OrderStatusChangedEvent) error {
+		return notificationError(notificationService.SendOrderShipped(ctx, messageID(ctx), event.OrderID))
+	})
+
+	worker.Register(runtime, worker.QueueConfig{
+		Name:     "worker.notifications.refund",
+		Bindings: []string{events.ReturnRefunded},
+	}, func(ctx context.Context, event events.ReturnStatusChangedEvent) error {
+		return notificationError(notificationService.SendRefund(ctx, messageID(ctx), event.ReturnID))
+	})
+}
+
+// messageID returns the ID of the message being handled
+func messageID(ctx context.Context) string {
+	msg, _ := worker.MessageFromContext(ctx)
+	return msg.ID
+}
+
+// notificationError stops retrying emails about records that no longer exist
+func notificationError(err error) error {
+	var baseErr appError.BaseError
+	if errors.As(err, &baseErr) && baseErr.Type() == appError.ErrorTypeNotFound {
+		return worker.Permanent(err)
+	}
+	return err
+}
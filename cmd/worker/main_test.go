@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"ecom-go/internal/worker"
+	appError "ecom-go/pkg/errors"
+)
+
+func TestNotificationError(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantPermanent bool
+	}{
+		{name: "sent", err: nil},
+		{name: "not found", err: appError.NewNotFoundError("order not found", errors.New("record not found")), wantPermanent: true},
+		{name: "server error", err: appError.NewServerError("error sending email", errors.New("connection refused"))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := notificationError(tt.err)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("error = %v, want it to wrap %v", err, tt.err)
+			}
+			if worker.IsPermanent(err) != tt.wantPermanent {
+				t.Errorf("permanent = %t, want %t", worker.IsPermanent(err), tt.wantPermanent)
+			}
+		})
+	}
+}
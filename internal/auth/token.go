@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// issuer is the issuer of the access tokens, tokens of other issuers are rejected
+const issuer = "ecom-go"
+
+// ErrInvalidToken is returned for tokens that are malformed, expired or not signed with the secret
+var ErrInvalidToken = errors.New("invalid access token")
+
+// Claims identify the user an access token was issued to
+type Claims struct {
+	UserID uint
+	Role   string
+}
+
+// tokenClaims are the claims encoded in an access token
+type tokenClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Tokens issues and verifies the access tokens of signed in users, JWTs
+// signed with HMAC-SHA256
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewTokens creates the access token issuer, failing when no secret is configured
+func NewTokens(cfg config.AuthConfig) (*Tokens, error) {
+	if cfg.Secret == "" {
+		return nil, errors.New("auth.secret is required to sign access tokens")
+	}
+	return &Tokens{
+		secret: []byte(cfg.Secret),
+		ttl:    cfg.TokenTTL,
+	}, nil
+}
+
+// Issue returns an access token for the user and when it expires
+func (t *Tokens) Issue(userID uint, role string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.ttl)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, tokenClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+	signed, err := token.SignedString(t.secret)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to sign access token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// Verify checks an access token and returns the user it was issued to, or ErrInvalidToken
+func (t *Tokens) Verify(token string) (*Claims, error) {
+	var claims tokenClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return t.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return nil, fmt.Errorf("%w: bad subject", ErrInvalidToken)
+	}
+	return &Claims{UserID: uint(userID), Role: claims.Role}, nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"ecom-go/internal/config"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func TestTokens(t *testing.T) {
+	tokens, err := NewTokens(config.AuthConfig{Secret: testSecret, TokenTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid, _, err := tokens.Issue(42, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, _, err := (&Tokens{secret: []byte(testSecret), ttl: -time.Minute}).Issue(42, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSecret, _, err := (&Tokens{secret: []byte("another secret of at least 32 chars"), ttl: time.Hour}).Issue(42, "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  *Claims
+	}{
+		{"valid", valid, &Claims{UserID: 42, Role: "admin"}},
+		{"expired", expired, nil},
+		{"signed with another secret", otherSecret, nil},
+		{"tampered", valid[:len(valid)-2] + "xx", nil},
+		{"unsigned", "eyJhbGciOiJub25lIn0.eyJzdWIiOiI0MiIsInJvbGUiOiJhZG1pbiJ9.", nil},
+		{"malformed", "not a token", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tokens.Verify(tt.token)
+			if tt.want == nil {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if *claims != *tt.want {
+				t.Errorf("Verify() = %+v, want %+v", *claims, *tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTokensRequiresSecret(t *testing.T) {
+	if _, err := NewTokens(config.AuthConfig{TokenTTL: time.Hour}); err == nil {
+		t.Error("NewTokens() without a secret succeeded")
+	}
+}
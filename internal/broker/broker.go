@@ -0,0 +1,132 @@
+package broker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ecom-go/internal/config"
+
+	"gorm.io/gorm"
+)
+
+// ErrClosed is returned when the broker has been closed
+var ErrClosed = errors.New("broker closed")
+
+// Message is an event travelling through the broker
+type Message struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Body      []byte            `json:"body"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Timestamp time.Time         `json:"timestamp"`
+	// Priority orders the messages waiting in a queue, higher first
+	Priority int `json:"priority,omitempty"`
+	// Key makes the message unique: it is dropped while a message with the
+	// same key is still waiting in the queue. RabbitMQ ignores it
+	Key string `json:"key,omitempty"`
+}
+
+// NewMessage creates a message with the JSON encoding of the payload as its body
+func NewMessage(messageType string, payload interface{}) (Message, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Message{}, fmt.Errorf("error encoding %s message: %w", messageType, err)
+	}
+	return Message{
+		ID:        newID(),
+		Type:      messageType,
+		Body:      body,
+		Headers:   map[string]string{},
+		Timestamp: time.Now().UTC(),
+	}, nil
+}
+
+// Delivery is a message handed to a consumer, which must settle it exactly once
+type Delivery interface {
+	// Message returns the delivered message
+	Message() Message
+	// Ack tells the broker the message has been handled
+	Ack() error
+	// Nack tells the broker the message could not be handled, putting it
+	// back on the queue when requeue is set
+	Nack(requeue bool) error
+}
+
+// QueueSpec describes a queue a consumer reads from
+type QueueSpec struct {
+	Name string
+	// Bindings are the routing key patterns routed to the queue, "*" matches
+	// one dot separated word and "#" zero or more
+	Bindings []string
+	// Prefetch is how many unacknowledged messages the queue hands out at once, 0 for no limit
+	Prefetch int
+}
+
+// Broker publishes messages to a topic exchange and consumes them from queues
+type Broker interface {
+	// Publish sends the message to all queues bound to the routing key
+	Publish(ctx context.Context, routingKey string, msg Message) error
+	// PublishToQueue sends the message straight to a single queue, bypassing
+	// the exchange. With a delay it only becomes available once the delay has passed
+	PublishToQueue(ctx context.Context, queue string, msg Message, delay time.Duration) error
+	// Consume declares the queue and its bindings and streams its messages.
+	// The channel is closed once the context is canceled
+	Consume(ctx context.Context, spec QueueSpec) (<-chan Delivery, error)
+	// Close releases the broker's connections, deliveries that are not
+	// settled yet are put back on their queues
+	Close() error
+}
+
+// New creates the broker selected in the configuration. The Postgres broker
+// keeps its queues in the application's database
+func New(cfg *config.Config, db *gorm.DB) (Broker, error) {
+	switch cfg.Worker.Broker {
+	case "", "rabbitmq":
+		return NewRabbitMQBroker(cfg.RabbitMQ)
+	case "memory":
+		return NewMemoryBroker(), nil
+	case "postgres":
+		return NewPostgresBroker(db, cfg.Worker.Postgres), nil
+	default:
+		return nil, fmt.Errorf("unknown broker %q", cfg.Worker.Broker)
+	}
+}
+
+// matchRoutingKey reports whether the routing key matches a binding pattern
+func matchRoutingKey(pattern, key string) bool {
+	return matchWords(strings.Split(pattern, "."), strings.Split(key, "."))
+}
+
+func matchWords(pattern, key []string) bool {
+	if len(pattern) == 0 {
+		return len(key) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(key); i++ {
+			if matchWords(pattern[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(key) > 0 && matchWords(pattern[1:], key[1:])
+	default:
+		return len(key) > 0 && pattern[0] == key[0] && matchWords(pattern[1:], key[1:])
+	}
+}
+
+// newID returns a random message ID
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
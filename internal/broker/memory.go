@@ -0,0 +1,265 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryBroker is an in-process broker, for tests and running without RabbitMQ
+type MemoryBroker struct {
+	mu     sync.Mutex
+	queues map[string]*memoryQueue
+	closed bool
+}
+
+// memoryQueue holds the messages of a queue that are not acknowledged yet
+type memoryQueue struct {
+	bindings []string
+	prefetch int
+	ready    []Message
+	unacked  int
+	// changed is closed and replaced whenever consumers may be able to make progress
+	changed chan struct{}
+}
+
+// NewMemoryBroker creates a new in-memory broker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{queues: make(map[string]*memoryQueue)}
+}
+
+// Publish sends the message to all queues bound to the routing key
+func (b *MemoryBroker) Publish(ctx context.Context, routingKey string, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	for _, q := range b.queues {
+		for _, binding := range q.bindings {
+			if matchRoutingKey(binding, routingKey) {
+				q.push(msg)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// PublishToQueue sends the message straight to a single queue, after the delay
+func (b *MemoryBroker) PublishToQueue(ctx context.Context, queue string, msg Message, delay time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+	q := b.queue(queue)
+	if delay <= 0 {
+		q.push(msg)
+		return nil
+	}
+
+	time.AfterFunc(delay, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if b.closed {
+			return
+		}
+		q.push(msg)
+	})
+	return nil
+}
+
+// Consume declares the queue and its bindings and streams its messages
+func (b *MemoryBroker) Consume(ctx context.Context, spec QueueSpec) (<-chan Delivery, error) {
+	if spec.Name == "" {
+		return nil, errors.New("queue name is required")
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil, ErrClosed
+	}
+	q := b.queue(spec.Name)
+	for _, binding := range spec.Bindings {
+		if !containsString(q.bindings, binding) {
+			q.bindings = append(q.bindings, binding)
+		}
+	}
+	q.prefetch = spec.Prefetch
+	b.mu.Unlock()
+
+	deliveries := make(chan Delivery)
+	go b.dispatch(ctx, q, deliveries)
+	return deliveries, nil
+}
+
+// dispatch hands the queue's messages to a consumer, respecting the prefetch limit
+func (b *MemoryBroker) dispatch(ctx context.Context, q *memoryQueue, deliveries chan<- Delivery) {
+	defer close(deliveries)
+
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return
+		}
+		if len(q.ready) == 0 || (q.prefetch > 0 && q.unacked >= q.prefetch) {
+			changed := q.changed
+			b.mu.Unlock()
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+			continue
+		}
+		msg := q.ready[0]
+		q.ready = q.ready[1:]
+		q.unacked++
+		b.mu.Unlock()
+
+		delivery := &memoryDelivery{broker: b, queue: q, msg: msg}
+		select {
+		case deliveries <- delivery:
+		case <-ctx.Done():
+			delivery.Nack(true)
+			return
+		}
+	}
+}
+
+// Close stops all consumers, messages that are not acknowledged stay on their queues
+func (b *MemoryBroker) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, q := range b.queues {
+		q.notify()
+	}
+	return nil
+}
+
+// Pending returns how many messages of the queue are waiting or unacknowledged
+func (b *MemoryBroker) Pending(queue string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	q, ok := b.queues[queue]
+	if !ok {
+		return 0
+	}
+	return len(q.ready) + q.unacked
+}
+
+// queue returns the named queue, declaring it if needed. The broker's lock must be held
+func (b *MemoryBroker) queue(name string) *memoryQueue {
+	q, ok := b.queues[name]
+	if !ok {
+		q = &memoryQueue{changed: make(chan struct{})}
+		b.queues[name] = q
+	}
+	return q
+}
+
+// push adds a message to the queue behind those of the same or a higher
+// priority, unless a message with the same key is waiting already. The
+// broker's lock must be held
+func (q *memoryQueue) push(msg Message) {
+	if q.waiting(msg.Key) {
+		return
+	}
+	i := len(q.ready)
+	for j, waiting := range q.ready {
+		if i == len(q.ready) && waiting.Priority < msg.Priority {
+			i = j
+		}
+	}
+
+	q.ready = append(q.ready, Message{})
+	copy(q.ready[i+1:], q.ready[i:])
+	q.ready[i] = msg
+	q.notify()
+}
+
+// waiting reports whether a message with the key is waiting in the queue. The
+// broker's lock must be held
+func (q *memoryQueue) waiting(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, msg := range q.ready {
+		if msg.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// notify wakes up the queue's consumers, the broker's lock must be held
+func (q *memoryQueue) notify() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// memoryDelivery is a message handed out by the in-memory broker
+type memoryDelivery struct {
+	broker  *MemoryBroker
+	queue   *memoryQueue
+	msg     Message
+	settled bool
+}
+
+// Message returns the delivered message
+func (d *memoryDelivery) Message() Message {
+	return d.msg
+}
+
+// Ack removes the message from the queue
+func (d *memoryDelivery) Ack() error {
+	return d.settle(false)
+}
+
+// Nack drops the message, or puts it back at the head of the queue when
+// requeue is set, unless a message with the same key was queued meanwhile
+func (d *memoryDelivery) Nack(requeue bool) error {
+	return d.settle(requeue)
+}
+
+func (d *memoryDelivery) settle(requeue bool) error {
+	d.broker.mu.Lock()
+	defer d.broker.mu.Unlock()
+
+	if d.settled {
+		return errors.New("delivery already settled")
+	}
+	d.settled = true
+	d.queue.unacked--
+	if requeue && !d.queue.waiting(d.msg.Key) {
+		d.queue.ready = append([]Message{d.msg}, d.queue.ready...)
+	}
+	d.queue.notify()
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package broker
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMemoryBrokerKeys(t *testing.T) {
+	b := NewMemoryBroker()
+	defer b.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msg := Message{ID: "1", Type: "test", Key: "order-1"}
+	for range 2 {
+		if err := b.PublishToQueue(ctx, "jobs", msg, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := b.Pending("jobs"); n != 1 {
+		t.Fatalf("Pending = %d with the same key queued twice, want 1", n)
+	}
+
+	deliveries, err := b.Consume(ctx, QueueSpec{Name: "jobs", Prefetch: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := receive(t, deliveries)
+
+	// A retry queued while the message is being handled is kept
+	if err := b.PublishToQueue(ctx, "jobs", msg, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Pending("jobs"); n != 2 {
+		t.Fatalf("Pending = %d after queuing a retry of a delivered message, want 2", n)
+	}
+	// Requeuing gives way to the retry waiting already
+	if err := first.Nack(true); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Pending("jobs"); n != 1 {
+		t.Fatalf("Pending = %d after requeuing with a duplicate waiting, want 1", n)
+	}
+	if err := receive(t, deliveries).Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if n := b.Pending("jobs"); n != 0 {
+		t.Errorf("Pending = %d, want 0", n)
+	}
+}
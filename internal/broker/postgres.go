@@ -0,0 +1,353 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+	"ecom-go/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errJobReclaimed is returned when settling a job whose visibility timeout
+// passed and which was handed to another consumer since
+var errJobReclaimed = errors.New("job was claimed again after its visibility timeout")
+
+// PostgresBroker is a durable job queue stored in Postgres, for environments
+// without RabbitMQ. Consumers claim jobs with SELECT ... FOR UPDATE SKIP
+// LOCKED, so any number of them can share a queue without blocking each other.
+type PostgresBroker struct {
+	db  *gorm.DB
+	cfg config.PostgresQueueConfig
+
+	mu          sync.Mutex
+	closed      bool
+	outstanding map[int64]string // Lock tokens of the jobs handed out and not settled yet
+}
+
+// NewPostgresBroker creates a new Postgres-backed broker
+func NewPostgresBroker(db *gorm.DB, cfg config.PostgresQueueConfig) *PostgresBroker {
+	return &PostgresBroker{
+		db:          db,
+		cfg:         cfg,
+		outstanding: make(map[int64]string),
+	}
+}
+
+// Publish adds a job to every queue bound to the routing key
+func (b *PostgresBroker) Publish(ctx context.Context, routingKey string, msg Message) error {
+	if b.isClosed() {
+		return ErrClosed
+	}
+
+	var bindings []models.QueueBinding
+	if err := b.db.WithContext(ctx).Find(&bindings).Error; err != nil {
+		return err
+	}
+
+	var queues []string
+	for _, binding := range bindings {
+		if matchRoutingKey(binding.Pattern, routingKey) && !containsString(queues, binding.Queue) {
+			queues = append(queues, binding.Queue)
+		}
+	}
+	if len(queues) == 0 {
+		return nil
+	}
+
+	return b.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, queue := range queues {
+			if err := insertJob(tx, queue, msg, 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// PublishToQueue adds a job to a single queue that is handed out once the delay has passed
+func (b *PostgresBroker) PublishToQueue(ctx context.Context, queue string, msg Message, delay time.Duration) error {
+	if b.isClosed() {
+		return ErrClosed
+	}
+	return insertJob(b.db.WithContext(ctx), queue, msg, delay)
+}
+
+// Consume binds the queue to its routing keys and streams its jobs as they become due
+func (b *PostgresBroker) Consume(ctx context.Context, spec QueueSpec) (<-chan Delivery, error) {
+	if spec.Name == "" {
+		return nil, errors.New("queue name is required")
+	}
+	if b.isClosed() {
+		return nil, ErrClosed
+	}
+
+	for _, pattern := range spec.Bindings {
+		err := b.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.QueueBinding{Queue: spec.Name, Pattern: pattern}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	deliveries := make(chan Delivery)
+	go b.dispatch(ctx, spec, deliveries)
+	return deliveries, nil
+}
+
+// dispatch claims the queue's due jobs and hands them to a consumer,
+// respecting the prefetch limit
+func (b *PostgresBroker) dispatch(ctx context.Context, spec QueueSpec, deliveries chan<- Delivery) {
+	defer close(deliveries)
+
+	limit := spec.Prefetch
+	if limit <= 0 {
+		limit = 10
+	}
+	// slots holds one token per unsettled delivery, freed signals a settlement
+	slots := make(chan struct{}, limit)
+	freed := make(chan struct{}, 1)
+
+	for ctx.Err() == nil && !b.isClosed() {
+		available := limit - len(slots)
+		if spec.Prefetch <= 0 {
+			available = limit
+		}
+		if available == 0 {
+			select {
+			case <-ctx.Done():
+			case <-freed:
+			}
+			continue
+		}
+
+		jobs, token, err := b.claim(ctx, spec.Name, available)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Failed to claim jobs", "queue", spec.Name, "error", err)
+			}
+		}
+		if len(jobs) == 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(b.cfg.PollInterval):
+			}
+			continue
+		}
+
+		for i, job := range jobs {
+			delivery := &postgresDelivery{broker: b, job: job, token: token}
+			if spec.Prefetch > 0 {
+				slots <- struct{}{}
+				delivery.onSettle = func() {
+					<-slots
+					select {
+					case freed <- struct{}{}:
+					default:
+					}
+				}
+			}
+
+			select {
+			case deliveries <- delivery:
+			case <-ctx.Done():
+				// Give the jobs that were claimed but not handed out back to the queue
+				for _, job := range jobs[i:] {
+					(&postgresDelivery{broker: b, job: job, token: token}).Nack(true)
+				}
+				return
+			}
+		}
+	}
+}
+
+// claim locks up to limit due jobs of the queue for the visibility timeout
+func (b *PostgresBroker) claim(ctx context.Context, queue string, limit int) ([]*models.QueueJob, string, error) {
+	now := time.Now()
+	token := newID()
+
+	var jobs []*models.QueueJob
+	err := b.db.WithContext(ctx).Raw(`UPDATE queue_jobs
+		SET locked_until = ?, lock_token = ?, deliveries = deliveries + 1
+		WHERE id IN (
+			SELECT id FROM queue_jobs
+			WHERE queue = ? AND run_at <= ? AND (locked_until IS NULL OR locked_until <= ?)
+			ORDER BY priority DESC, run_at, id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(b.cfg.VisibilityTimeout), token, queue, now, now, limit).Scan(&jobs).Error
+	if err != nil {
+		return nil, "", err
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Priority != jobs[j].Priority {
+			return jobs[i].Priority > jobs[j].Priority
+		}
+		if !jobs[i].RunAt.Equal(jobs[j].RunAt) {
+			return jobs[i].RunAt.Before(jobs[j].RunAt)
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	b.mu.Lock()
+	for _, job := range jobs {
+		b.outstanding[job.ID] = token
+	}
+	b.mu.Unlock()
+	return jobs, token, nil
+}
+
+// Close stops all consumers and hands the jobs that were not settled back to their queues
+func (b *PostgresBroker) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	outstanding := b.outstanding
+	b.outstanding = make(map[int64]string)
+	b.mu.Unlock()
+
+	for id, token := range outstanding {
+		if err := unlockJob(b.db, id, token); err != nil && !errors.Is(err, errJobReclaimed) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *PostgresBroker) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+// insertJob adds a job to a queue. A job whose key is already waiting in the
+// queue is dropped. Claimed jobs don't count, so that a job being handled can
+// be queued again, as retries are
+func insertJob(db *gorm.DB, queue string, msg Message, delay time.Duration) error {
+	now := time.Now()
+	job := &models.QueueJob{
+		Queue:       queue,
+		Priority:    msg.Priority,
+		RunAt:       now.Add(delay),
+		MessageID:   msg.ID,
+		MessageType: msg.Type,
+		Body:        msg.Body,
+		Headers:     msg.Headers,
+		PublishedAt: msg.Timestamp,
+	}
+	if msg.Key != "" {
+		job.UniqueKey = &msg.Key
+	}
+	if job.PublishedAt.IsZero() {
+		job.PublishedAt = now
+	}
+
+	return db.Clauses(clause.OnConflict{
+		Columns:     []clause.Column{{Name: "queue"}, {Name: "unique_key"}},
+		TargetWhere: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "unique_key IS NOT NULL AND lock_token IS NULL"}}},
+		DoNothing:   true,
+	}).Create(job).Error
+}
+
+// deleteJob removes a job, provided it is still held by the given claim
+func deleteJob(db *gorm.DB, id int64, token string) error {
+	result := db.Where("id = ? AND lock_token = ?", id, token).Delete(&models.QueueJob{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errJobReclaimed
+	}
+	return nil
+}
+
+// unlockJob makes a job available again, provided it is still held by the
+// given claim. When a job with the same key was queued while it was claimed,
+// the claimed one is removed instead, the other one taking its place
+func unlockJob(db *gorm.DB, id int64, token string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`DELETE FROM queue_jobs
+			WHERE id = ? AND lock_token = ? AND unique_key IS NOT NULL AND EXISTS (
+				SELECT 1 FROM queue_jobs waiting
+				WHERE waiting.queue = queue_jobs.queue AND waiting.unique_key = queue_jobs.unique_key
+					AND waiting.lock_token IS NULL
+			)`, id, token)
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+
+		result = tx.Model(&models.QueueJob{}).Where("id = ? AND lock_token = ?", id, token).
+			Updates(map[string]interface{}{"locked_until": nil, "lock_token": nil})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errJobReclaimed
+		}
+		return nil
+	})
+}
+
+// postgresDelivery is a job handed out by the Postgres-backed broker
+type postgresDelivery struct {
+	broker   *PostgresBroker
+	job      *models.QueueJob
+	token    string
+	onSettle func()
+	settled  bool
+}
+
+// Message returns the delivered message
+func (d *postgresDelivery) Message() Message {
+	msg := Message{
+		ID:        d.job.MessageID,
+		Type:      d.job.MessageType,
+		Body:      d.job.Body,
+		Headers:   d.job.Headers,
+		Timestamp: d.job.PublishedAt,
+		Priority:  d.job.Priority,
+	}
+	if d.job.UniqueKey != nil {
+		msg.Key = *d.job.UniqueKey
+	}
+	return msg
+}
+
+// Ack removes the job from its queue
+func (d *postgresDelivery) Ack() error {
+	return d.settle(func() error {
+		return deleteJob(d.broker.db, d.job.ID, d.token)
+	})
+}
+
+// Nack removes the job, or makes it available again right away when requeue is set
+func (d *postgresDelivery) Nack(requeue bool) error {
+	return d.settle(func() error {
+		if requeue {
+			return unlockJob(d.broker.db, d.job.ID, d.token)
+		}
+		return deleteJob(d.broker.db, d.job.ID, d.token)
+	})
+}
+
+func (d *postgresDelivery) settle(apply func() error) error {
+	if d.settled {
+		return errors.New("delivery already settled")
+	}
+	d.settled = true
+
+	d.broker.mu.Lock()
+	delete(d.broker.outstanding, d.job.ID)
+	d.broker.mu.Unlock()
+	if d.onSettle != nil {
+		d.onSettle()
+	}
+	return apply()
+}
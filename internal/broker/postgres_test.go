@@ -0,0 +1,198 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/migrate"
+	"ecom-go/internal/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB connects to the database of APP_TEST_DATABASE_URL, migrated and with
+// empty queues. Tests needing Postgres are skipped without it
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("APP_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("APP_TEST_DATABASE_URL not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrate.New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("TRUNCATE queue_jobs, queue_bindings").Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestPostgresBroker(t *testing.T, visibilityTimeout time.Duration) (*PostgresBroker, *gorm.DB) {
+	db := testDB(t)
+	b := NewPostgresBroker(db, config.PostgresQueueConfig{PollInterval: 10 * time.Millisecond, VisibilityTimeout: visibilityTimeout})
+	t.Cleanup(func() { b.Close() })
+	return b, db
+}
+
+func countJobs(t *testing.T, db *gorm.DB, queue string) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(&models.QueueJob{}).Where("queue = ?", queue).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+func receive(t *testing.T, deliveries <-chan Delivery) Delivery {
+	t.Helper()
+	select {
+	case delivery := <-deliveries:
+		return delivery
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+		return nil
+	}
+}
+
+func TestPostgresBrokerKeys(t *testing.T) {
+	b, db := newTestPostgresBroker(t, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msg := Message{ID: "1", Type: "test", Body: []byte("{}"), Key: "order-1"}
+	for range 2 {
+		if err := b.PublishToQueue(ctx, "jobs", msg, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := countJobs(t, db, "jobs"); n != 1 {
+		t.Fatalf("%d jobs waiting with the same key, want 1", n)
+	}
+
+	deliveries, err := b.Consume(ctx, QueueSpec{Name: "jobs", Prefetch: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := receive(t, deliveries)
+
+	// A retry queued while the job is being handled is kept, as the worker
+	// runtime queues it before acknowledging the failed attempt
+	retry := msg
+	retry.Headers = map[string]string{"x-attempt": "2"}
+	if err := b.PublishToQueue(ctx, "jobs", retry, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := countJobs(t, db, "jobs"); n != 2 {
+		t.Fatalf("%d jobs after queuing a retry of a claimed job, want 2", n)
+	}
+	// Waiting jobs are still unique
+	if err := b.PublishToQueue(ctx, "jobs", retry, 0); err != nil {
+		t.Fatal(err)
+	}
+	if n := countJobs(t, db, "jobs"); n != 2 {
+		t.Fatalf("%d jobs after queuing the retry twice, want 2", n)
+	}
+
+	if err := first.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	second := receive(t, deliveries)
+	if got := second.Message().Headers["x-attempt"]; got != "2" {
+		t.Errorf("x-attempt = %q, want the retry", got)
+	}
+	if err := second.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countJobs(t, db, "jobs"); n != 0 {
+		t.Errorf("%d jobs left, want 0", n)
+	}
+}
+
+func TestPostgresBrokerRequeueWithWaitingDuplicate(t *testing.T) {
+	b, db := newTestPostgresBroker(t, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	msg := Message{ID: "1", Type: "test", Body: []byte("{}"), Key: "order-1"}
+	if err := b.PublishToQueue(ctx, "jobs", msg, 0); err != nil {
+		t.Fatal(err)
+	}
+	deliveries, err := b.Consume(ctx, QueueSpec{Name: "jobs", Prefetch: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery := receive(t, deliveries)
+
+	if err := b.PublishToQueue(ctx, "jobs", msg, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := delivery.Nack(true); err != nil {
+		t.Fatal(err)
+	}
+	// The requeued job gives way to the one waiting
+	var jobs []models.QueueJob
+	if err := db.Where("queue = ?", "jobs").Find(&jobs).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].LockToken != nil || jobs[0].RunAt.Before(time.Now().Add(time.Minute)) {
+		t.Errorf("jobs = %+v, want only the delayed one", jobs)
+	}
+}
+
+func TestPostgresBrokerVisibilityTimeout(t *testing.T) {
+	b, db := newTestPostgresBroker(t, 200*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := b.Publish(ctx, "order.created", Message{ID: "1", Type: "order.created", Body: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+	if n := countJobs(t, db, "orders"); n != 0 {
+		t.Fatalf("%d jobs routed before the queue was bound, want 0", n)
+	}
+
+	deliveries, err := b.Consume(ctx, QueueSpec{Name: "orders", Bindings: []string{"order.*"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Publish(ctx, "order.created", Message{ID: "2", Type: "order.created", Body: []byte("{}")}); err != nil {
+		t.Fatal(err)
+	}
+
+	abandoned := receive(t, deliveries)
+	// Not settled in time, the job is handed out again
+	redelivered := receive(t, deliveries)
+	if redelivered.Message().ID != abandoned.Message().ID {
+		t.Fatalf("redelivered %s, want %s", redelivered.Message().ID, abandoned.Message().ID)
+	}
+	if err := abandoned.Ack(); !errors.Is(err, errJobReclaimed) {
+		t.Errorf("Ack of the expired claim = %v, want errJobReclaimed", err)
+	}
+	if err := redelivered.Ack(); err != nil {
+		t.Fatal(err)
+	}
+	if n := countJobs(t, db, "orders"); n != 0 {
+		t.Errorf("%d jobs left, want 0", n)
+	}
+}
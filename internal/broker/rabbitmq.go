@@ -0,0 +1,264 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"ecom-go/internal/config"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RabbitMQBroker publishes to a durable topic exchange and consumes from durable queues
+type RabbitMQBroker struct {
+	conn     *amqp.Connection
+	exchange string
+
+	// publishing shares one confirm-mode channel
+	publishMu sync.Mutex
+	publishCh *amqp.Channel
+
+	mu        sync.Mutex
+	consumers []*amqp.Channel
+}
+
+// NewRabbitMQBroker connects to RabbitMQ and declares the exchange
+func NewRabbitMQBroker(cfg config.RabbitMQConfig) (*RabbitMQBroker, error) {
+	conn, err := amqp.Dial(cfg.GetURL())
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to RabbitMQ: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error opening RabbitMQ channel: %w", err)
+	}
+	if err := ch.ExchangeDeclare(cfg.Exchange, "topic", true, false, false, false, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error declaring exchange %s: %w", cfg.Exchange, err)
+	}
+	if err := ch.Confirm(false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error enabling publisher confirms: %w", err)
+	}
+
+	return &RabbitMQBroker{
+		conn:      conn,
+		exchange:  cfg.Exchange,
+		publishCh: ch,
+	}, nil
+}
+
+// Publish sends the message to the exchange and waits for RabbitMQ to confirm it
+func (b *RabbitMQBroker) Publish(ctx context.Context, routingKey string, msg Message) error {
+	b.publishMu.Lock()
+	confirmation, err := b.publishCh.PublishWithDeferredConfirmWithContext(ctx, b.exchange, routingKey, false, false, publishing(msg))
+	b.publishMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error publishing %s: %w", routingKey, err)
+	}
+	return waitConfirmation(ctx, confirmation, routingKey)
+}
+
+// PublishToQueue sends the message to a queue through the default exchange.
+// Delayed messages are parked in a delay queue whose messages expire into the
+// target queue. Delays are grouped in buckets of a power of two seconds so
+// that the number of delay queues stays small, each message expiring after its
+// own delay and at the latest after the bucket's.
+func (b *RabbitMQBroker) PublishToQueue(ctx context.Context, queue string, msg Message, delay time.Duration) error {
+	b.publishMu.Lock()
+	routingKey, err := b.declareDelivery(queue, delay)
+	if err != nil {
+		b.publishMu.Unlock()
+		return err
+	}
+	publishing := publishing(msg)
+	if routingKey != queue {
+		publishing.Expiration = strconv.FormatInt(max(delay.Milliseconds(), 1), 10)
+	}
+	confirmation, err := b.publishCh.PublishWithDeferredConfirmWithContext(ctx, "", routingKey, false, false, publishing)
+	b.publishMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("error publishing to queue %s: %w", routingKey, err)
+	}
+	return waitConfirmation(ctx, confirmation, routingKey)
+}
+
+// declareDelivery declares the queue a message for the target queue is
+// published to and returns its name. The publishing lock must be held
+func (b *RabbitMQBroker) declareDelivery(queue string, delay time.Duration) (string, error) {
+	if _, err := b.publishCh.QueueDeclare(queue, true, false, false, false, nil); err != nil {
+		return "", fmt.Errorf("error declaring queue %s: %w", queue, err)
+	}
+	if delay <= 0 {
+		return queue, nil
+	}
+
+	bucket := delayBucket(delay)
+	ttl := bucket.Milliseconds()
+	delayQueue := fmt.Sprintf("%s.delay.%s", queue, bucket)
+	_, err := b.publishCh.QueueDeclare(delayQueue, true, false, false, false, amqp.Table{
+		"x-message-ttl":             ttl,
+		"x-dead-letter-exchange":    "",
+		"x-dead-letter-routing-key": queue,
+		// Unused delay queues remove themselves
+		"x-expires": ttl + time.Minute.Milliseconds(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error declaring delay queue %s: %w", delayQueue, err)
+	}
+	return delayQueue, nil
+}
+
+// delayBucket rounds the delay up to a power of two seconds
+func delayBucket(delay time.Duration) time.Duration {
+	bucket := time.Second
+	for bucket < delay {
+		bucket *= 2
+	}
+	return bucket
+}
+
+// waitConfirmation waits for RabbitMQ to confirm a published message
+func waitConfirmation(ctx context.Context, confirmation *amqp.DeferredConfirmation, routingKey string) error {
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return fmt.Errorf("RabbitMQ rejected %s", routingKey)
+	}
+	return nil
+}
+
+// publishing converts a message to an AMQP publishing
+func publishing(msg Message) amqp.Publishing {
+	headers := amqp.Table{}
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	return amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    msg.ID,
+		Type:         msg.Type,
+		Timestamp:    msg.Timestamp,
+		Headers:      headers,
+		Body:         msg.Body,
+		Priority:     uint8(min(max(msg.Priority, 0), 9)),
+	}
+}
+
+// Consume declares the queue and its bindings and streams its messages on a
+// dedicated channel
+func (b *RabbitMQBroker) Consume(ctx context.Context, spec QueueSpec) (<-chan Delivery, error) {
+	if spec.Name == "" {
+		return nil, errors.New("queue name is required")
+	}
+
+	ch, err := b.conn.Channel()
+	if err != nil {
+		return nil, fmt.Errorf("error opening RabbitMQ channel: %w", err)
+	}
+	if _, err := ch.QueueDeclare(spec.Name, true, false, false, false, nil); err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("error declaring queue %s: %w", spec.Name, err)
+	}
+	for _, binding := range spec.Bindings {
+		if err := ch.QueueBind(spec.Name, binding, b.exchange, false, nil); err != nil {
+			ch.Close()
+			return nil, fmt.Errorf("error binding queue %s to %s: %w", spec.Name, binding, err)
+		}
+	}
+	if spec.Prefetch > 0 {
+		if err := ch.Qos(spec.Prefetch, 0, false); err != nil {
+			ch.Close()
+			return nil, fmt.Errorf("error setting prefetch for queue %s: %w", spec.Name, err)
+		}
+	}
+
+	consumerTag := spec.Name + "-" + newID()
+	raw, err := ch.Consume(spec.Name, consumerTag, false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return nil, fmt.Errorf("error consuming queue %s: %w", spec.Name, err)
+	}
+
+	b.mu.Lock()
+	b.consumers = append(b.consumers, ch)
+	b.mu.Unlock()
+
+	deliveries := make(chan Delivery)
+	go func() {
+		defer close(deliveries)
+		for {
+			select {
+			case <-ctx.Done():
+				// Stop receiving new messages and hand back what was prefetched
+				ch.Cancel(consumerTag, false)
+				for d := range raw {
+					d.Nack(false, true)
+				}
+				return
+			case d, ok := <-raw:
+				if !ok {
+					return
+				}
+				select {
+				case deliveries <- &rabbitMQDelivery{d: d}:
+				case <-ctx.Done():
+					d.Nack(false, true)
+				}
+			}
+		}
+	}()
+	return deliveries, nil
+}
+
+// Close closes the consumer channels and the connection
+func (b *RabbitMQBroker) Close() error {
+	b.mu.Lock()
+	for _, ch := range b.consumers {
+		ch.Close()
+	}
+	b.consumers = nil
+	b.mu.Unlock()
+
+	return b.conn.Close()
+}
+
+// rabbitMQDelivery is a message handed out by RabbitMQ
+type rabbitMQDelivery struct {
+	d amqp.Delivery
+}
+
+// Message returns the delivered message
+func (d *rabbitMQDelivery) Message() Message {
+	headers := make(map[string]string, len(d.d.Headers))
+	for k, v := range d.d.Headers {
+		headers[k] = fmt.Sprint(v)
+	}
+	return Message{
+		ID:        d.d.MessageId,
+		Type:      d.d.Type,
+		Body:      d.d.Body,
+		Headers:   headers,
+		Timestamp: d.d.Timestamp,
+		Priority:  int(d.d.Priority),
+	}
+}
+
+// Ack tells RabbitMQ the message has been handled
+func (d *rabbitMQDelivery) Ack() error {
+	return d.d.Ack(false)
+}
+
+// Nack tells RabbitMQ the message could not be handled
+func (d *rabbitMQDelivery) Nack(requeue bool) error {
+	return d.d.Nack(false, requeue)
+}
@@ -0,0 +1,25 @@
+package broker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDelayBucket(t *testing.T) {
+	tests := []struct {
+		delay time.Duration
+		want  time.Duration
+	}{
+		{time.Millisecond, time.Second},
+		{time.Second, time.Second},
+		{1500 * time.Millisecond, 2 * time.Second},
+		{5 * time.Second, 8 * time.Second},
+		{17 * time.Second, 32 * time.Second},
+		{10 * time.Minute, 1024 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := delayBucket(tt.delay); got != tt.want {
+			t.Errorf("delayBucket(%s) = %s, want %s", tt.delay, got, tt.want)
+		}
+	}
+}
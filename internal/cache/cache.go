@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecom-go/internal/config"
+)
+
+// ErrMiss is returned when a key is not in the cache
+var ErrMiss = errors.New("cache miss")
+
+// Cache is a key-value store for values that can be recomputed
+type Cache interface {
+	// Get returns the value stored under the key, or ErrMiss
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the value under the key until the TTL passes, 0 for no expiry
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the keys
+	Delete(ctx context.Context, keys ...string) error
+	// Incr increments the counter stored under the key and returns its new value
+	Incr(ctx context.Context, key string) (int64, error)
+	// Close releases the cache's connections
+	Close() error
+}
+
+// New creates the cache selected in the configuration, or nil when caching is disabled
+func New(cfg *config.Config) (Cache, error) {
+	if !cfg.Cache.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.Cache.Backend {
+	case "", "redis":
+		return NewRedisCache(cfg.Redis, cfg.Cache.Prefix)
+	case "memory":
+		return NewMemoryCache(), nil
+	default:
+		return nil, fmt.Errorf("unknown cache backend %q", cfg.Cache.Backend)
+	}
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryCache stores values in process, for tests and running without Redis.
+// Replicas do not see each other's values or invalidations
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+type memoryEntry struct {
+	value     []byte
+	expiresAt time.Time // Zero for no expiry
+}
+
+// NewMemoryCache creates a new in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]memoryEntry)}
+}
+
+// Get returns the value stored under the key, or ErrMiss
+func (c *MemoryCache) Get(ctx context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+	if !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, ErrMiss
+	}
+	return append([]byte(nil), entry.value...), nil
+}
+
+// Set stores the value under the key until the TTL passes, 0 for no expiry
+func (c *MemoryCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := memoryEntry{value: append([]byte(nil), value...)}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+	c.entries[key] = entry
+	return nil
+}
+
+// Delete removes the keys
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+// Incr increments the counter stored under the key and returns its new value
+func (c *MemoryCache) Incr(ctx context.Context, key string) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var n int64
+	if entry, ok := c.entries[key]; ok {
+		n, _ = strconv.ParseInt(string(entry.value), 10, 64)
+	}
+	n++
+	c.entries[key] = memoryEntry{value: []byte(strconv.FormatInt(n, 10))}
+	return n, nil
+}
+
+// Close does nothing, the values are dropped with the cache
+func (c *MemoryCache) Close() error {
+	return nil
+}
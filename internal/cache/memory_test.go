@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryCache(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if _, err := c.Get(ctx, "missing"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get of a missing key = %v, want ErrMiss", err)
+	}
+
+	value := []byte("kettle")
+	if err := c.Set(ctx, "product:1", value, 0); err != nil {
+		t.Fatal(err)
+	}
+	// The cache keeps its own copy
+	value[0] = 'K'
+	got, err := c.Get(ctx, "product:1")
+	if err != nil || string(got) != "kettle" {
+		t.Fatalf("Get = %q, %v, want kettle", got, err)
+	}
+	got[0] = 'K'
+	if got, _ := c.Get(ctx, "product:1"); string(got) != "kettle" {
+		t.Errorf("Get after modifying a returned value = %q, want kettle", got)
+	}
+
+	if err := c.Set(ctx, "product:2", []byte("mug"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Delete(ctx, "product:1", "product:2", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"product:1", "product:2"} {
+		if _, err := c.Get(ctx, key); !errors.Is(err, ErrMiss) {
+			t.Errorf("Get of deleted %s = %v, want ErrMiss", key, err)
+		}
+	}
+}
+
+func TestMemoryCacheExpiry(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	if err := c.Set(ctx, "short", []byte("1"), 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Set(ctx, "forever", []byte("1"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Get(ctx, "short"); err != nil {
+		t.Fatalf("Get before expiry = %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if _, err := c.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get after expiry = %v, want ErrMiss", err)
+	}
+	if _, err := c.Get(ctx, "forever"); err != nil {
+		t.Errorf("Get without expiry = %v", err)
+	}
+}
+
+func TestMemoryCacheIncr(t *testing.T) {
+	ctx := context.Background()
+	c := NewMemoryCache()
+
+	for want := int64(1); want <= 3; want++ {
+		n, err := c.Incr(ctx, "generation")
+		if err != nil || n != want {
+			t.Fatalf("Incr = %d, %v, want %d", n, err, want)
+		}
+	}
+	if got, _ := c.Get(ctx, "generation"); string(got) != "3" {
+		t.Errorf("Get of the counter = %q, want 3", got)
+	}
+
+	// Counting continues from a number stored with Set
+	if err := c.Set(ctx, "generation", []byte("10"), 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := c.Incr(ctx, "generation"); n != 11 {
+		t.Errorf("Incr of a set counter = %d, want 11", n)
+	}
+}
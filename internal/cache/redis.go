@@ -0,0 +1,86 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache stores values in Redis, so that they are shared by every replica
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisClient connects to Redis
+func NewRedisClient(cfg config.RedisConfig) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("error connecting to Redis: %w", err)
+	}
+	return client, nil
+}
+
+// NewRedisCache connects to Redis. Keys are stored with the given prefix
+func NewRedisCache(cfg config.RedisConfig, prefix string) (*RedisCache, error) {
+	client, err := NewRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RedisCache{
+		client: client,
+		prefix: prefix,
+	}, nil
+}
+
+// Get returns the value stored under the key, or ErrMiss
+func (c *RedisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set stores the value under the key until the TTL passes, 0 for no expiry
+func (c *RedisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, c.prefix+key, value, ttl).Err()
+}
+
+// Delete removes the keys
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// Incr increments the counter stored under the key and returns its new value
+func (c *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
+	return c.client.Incr(ctx, c.prefix+key).Result()
+}
+
+// Close closes the connection to Redis
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
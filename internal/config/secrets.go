@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// secretKeys are the settings holding secrets. Each can also be read from the
+// file named by its environment variable with a _FILE suffix, such as
+// APP_DATABASE_PASSWORD_FILE, to use Kubernetes secrets mounted as files
+var secretKeys = []string{
+	"auth.secret",
+	"database.password",
+	"redis.password",
+	"rabbitmq.password",
+	"smtp.password",
+	"alerts.webhook_url",
+}
+
+// redacted replaces secrets in printed settings
+const redacted = "[redacted]"
+
+// readSecretFiles sets the secrets whose _FILE environment variable is set from their file
+func readSecretFiles(v *viper.Viper) error {
+	for _, key := range secretKeys {
+		envName := "APP_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		path := os.Getenv(envName + "_FILE")
+		if path == "" {
+			continue
+		}
+		if os.Getenv(envName) != "" {
+			return fmt.Errorf("both %s and %s_FILE are set, set only one", envName, envName)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading %s_FILE: %w", envName, err)
+		}
+		v.Set(key, strings.TrimRight(string(content), "\r\n"))
+	}
+	return nil
+}
+
+// RedactedSettings returns the effective settings, as nested maps, with the
+// secrets and the passwords of the replicas' URLs redacted
+func RedactedSettings() map[string]interface{} {
+	return redactedSettings(viper.GetViper())
+}
+
+// redactedSettings returns the settings of the viper instance with the secrets redacted
+func redactedSettings(v *viper.Viper) map[string]interface{} {
+	settings := v.AllSettings()
+	for _, key := range secretKeys {
+		if v.GetString(key) != "" {
+			setNested(settings, key, redacted)
+		}
+	}
+
+	replicas := v.GetStringSlice("database.replicas")
+	for i, replica := range replicas {
+		if u, err := url.Parse(replica); err == nil {
+			replicas[i] = u.Redacted()
+		} else {
+			replicas[i] = redacted
+		}
+	}
+	setNested(settings, "database.replicas", replicas)
+	return settings
+}
+
+// setNested sets a dotted key in nested maps of settings
+func setNested(settings map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := settings[part].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			settings[part] = child
+		}
+		settings = child
+	}
+	settings[parts[len(parts)-1]] = value
+}
@@ -0,0 +1,207 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ValidationError lists every problem found in the configuration, so that
+// they can all be fixed at once
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  " + strings.Join(e.Problems, "\n  ")
+}
+
+// Validate checks the configuration, returning a *ValidationError listing every problem
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port("server.port", c.Server.Port)
+	for i, proxy := range c.Server.TrustedProxies {
+		v.ipOrCIDR(fmt.Sprintf("server.trusted_proxies[%d]", i), proxy)
+	}
+	v.check(c.Auth.Secret == "" || len(c.Auth.Secret) >= 32, "auth.secret", "must be at least 32 characters")
+	v.positive("auth.token_ttl", c.Auth.TokenTTL)
+	apiKeyNames := map[string]bool{}
+	for i, apiKey := range c.Auth.APIKeys {
+		key := fmt.Sprintf("auth.api_keys[%d]", i)
+		v.required(key+".name", apiKey.Name)
+		v.check(!apiKeyNames[apiKey.Name], key+".name", "duplicate name %q", apiKey.Name)
+		apiKeyNames[apiKey.Name] = true
+		v.sha256(key+".hash", apiKey.Hash)
+	}
+	v.oneOf("log.format", c.Log.Format, "console", "json")
+	v.oneOf("log.level", c.Log.Level, "debug", "info", "warn", "error")
+	if c.Log.Sampling.Burst > 0 {
+		v.positive("log.sampling.period", c.Log.Sampling.Period)
+	}
+
+	v.required("database.host", c.Database.Host)
+	v.port("database.port", c.Database.Port)
+	v.required("database.name", c.Database.Name)
+	v.required("database.user", c.Database.User)
+	v.oneOf("database.sslmode", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	for i, replica := range c.Database.Replicas {
+		u, err := url.Parse(replica)
+		v.check(err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") && u.Host != "",
+			fmt.Sprintf("database.replicas[%d]", i), "must be a postgres:// URL")
+	}
+	v.positive("database.replica_check_interval", c.Database.ReplicaCheckInterval)
+	v.check(c.Database.ReadYourWritesWindow >= 0, "database.read_your_writes_window", "must not be negative, got %s", c.Database.ReadYourWritesWindow)
+	v.atLeast("database.tx_max_attempts", c.Database.TxMaxAttempts, 1)
+	v.atLeast("database.pool.max_open_conns", c.Database.Pool.MaxOpenConns, 0)
+	v.atLeast("database.pool.max_idle_conns", c.Database.Pool.MaxIdleConns, 0)
+	v.oneOf("database.log_level", c.Database.LogLevel, "silent", "error", "warn", "info")
+
+	if c.Cache.Enabled {
+		v.oneOf("cache.backend", c.Cache.Backend, "redis", "memory")
+		v.positive("cache.ttl", c.Cache.TTL)
+	}
+	if c.RateLimit.Enabled {
+		v.oneOf("rate_limit.backend", c.RateLimit.Backend, "redis", "memory")
+		v.rateLimitRule("rate_limit.per_ip", c.RateLimit.PerIP)
+		v.rateLimitRule("rate_limit.per_user", c.RateLimit.PerUser)
+		v.rateLimitRule("rate_limit.per_api_key", c.RateLimit.PerAPIKey)
+	}
+	if (c.Cache.Enabled && c.Cache.Backend == "redis") || (c.RateLimit.Enabled && c.RateLimit.Backend == "redis") {
+		v.required("redis.host", c.Redis.Host)
+		v.port("redis.port", c.Redis.Port)
+	}
+
+	v.atLeast("returns.window_days", c.Returns.WindowDays, 0)
+	v.positive("reservations.cart_ttl", c.Reservations.CartTTL)
+	v.positive("reservations.order_ttl", c.Reservations.OrderTTL)
+	v.oneOf("inventory.allocation_strategy", c.Inventory.AllocationStrategy, "nearest", "fewest_splits")
+	v.atLeast("inventory.sales_window_days", c.Inventory.SalesWindowDays, 1)
+	v.atLeast("inventory.reorder_cover_days", c.Inventory.ReorderCoverDays, 1)
+
+	v.oneOf("notifications.sender", c.Notifications.Sender, "smtp", "file")
+	if c.Notifications.Sender == "file" {
+		v.required("notifications.file_dir", c.Notifications.FileDir)
+	}
+	if c.Notifications.Sender == "smtp" || c.Alerts.Notifier == "email" {
+		v.required("smtp.host", c.SMTP.Host)
+		v.port("smtp.port", c.SMTP.Port)
+		v.required("smtp.from", c.SMTP.From)
+		v.positive("smtp.timeout", c.SMTP.Timeout)
+	}
+	v.oneOf("alerts.notifier", c.Alerts.Notifier, "log", "email", "webhook")
+	switch c.Alerts.Notifier {
+	case "email":
+		v.check(len(c.Alerts.EmailTo) > 0, "alerts.email_to", "is required by the email notifier")
+	case "webhook":
+		v.required("alerts.webhook_url", c.Alerts.WebhookURL)
+	}
+
+	v.oneOf("worker.broker", c.Worker.Broker, "rabbitmq", "postgres", "memory")
+	if c.Worker.Broker == "rabbitmq" {
+		v.required("rabbitmq.host", c.RabbitMQ.Host)
+		v.port("rabbitmq.port", c.RabbitMQ.Port)
+		v.required("rabbitmq.exchange", c.RabbitMQ.Exchange)
+	}
+	v.atLeast("worker.prefetch", c.Worker.Prefetch, 1)
+	v.atLeast("worker.concurrency", c.Worker.Concurrency, 1)
+	v.positive("worker.shutdown_timeout", c.Worker.ShutdownTimeout)
+	v.retry("worker.retry", c.Worker.Retry)
+	if c.Worker.Broker == "postgres" {
+		v.positive("worker.postgres.poll_interval", c.Worker.Postgres.PollInterval)
+		v.positive("worker.postgres.visibility_timeout", c.Worker.Postgres.VisibilityTimeout)
+	}
+
+	v.positive("outbox.poll_interval", c.Outbox.PollInterval)
+	v.atLeast("outbox.batch_size", c.Outbox.BatchSize, 1)
+	v.positive("outbox.max_backoff", c.Outbox.MaxBackoff)
+	v.positive("outbox.retention", c.Outbox.Retention)
+
+	v.positive("scheduler.abandoned_cart_after", c.Scheduler.AbandonedCartAfter)
+
+	v.positive("webhooks.timeout", c.Webhooks.Timeout)
+	v.atLeast("webhooks.disable_after", c.Webhooks.DisableAfter, 0)
+	v.retry("webhooks.retry", c.Webhooks.Retry)
+
+	v.positive("feature_flags.refresh_interval", c.FeatureFlags.RefreshInterval)
+	for _, key := range slices.Sorted(maps.Keys(c.FeatureFlags.Flags)) {
+		v.featureFlag("feature_flags.flags."+key, c.FeatureFlags.Flags[key])
+	}
+
+	if len(v.problems) > 0 {
+		return &ValidationError{Problems: v.problems}
+	}
+	return nil
+}
+
+// validator collects the problems found in the configuration
+type validator struct {
+	problems []string
+}
+
+// check records a problem with a setting unless ok
+func (v *validator) check(ok bool, key, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, key+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (v *validator) required(key, value string) {
+	v.check(value != "", key, "is required")
+}
+
+func (v *validator) port(key string, port int) {
+	v.check(port > 0 && port <= 65535, key, "must be between 1 and 65535, got %d", port)
+}
+
+func (v *validator) atLeast(key string, value, min int) {
+	v.check(value >= min, key, "must be at least %d, got %d", min, value)
+}
+
+func (v *validator) positive(key string, d time.Duration) {
+	v.check(d > 0, key, "must be a positive duration, got %s", d)
+}
+
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	v.check(slices.Contains(allowed, value), key, "must be one of %s, got %q", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) rateLimitRule(key string, rule RateLimitRule) {
+	v.atLeast(key+".requests", rule.Requests, 1)
+	v.positive(key+".period", rule.Period)
+	v.atLeast(key+".burst", rule.Burst, 0)
+}
+
+func (v *validator) retry(key string, retry RetryConfig) {
+	v.atLeast(key+".max_attempts", retry.MaxAttempts, 1)
+	v.positive(key+".initial_backoff", retry.InitialBackoff)
+	v.check(retry.MaxBackoff >= retry.InitialBackoff, key+".max_backoff", "must be at least initial_backoff")
+}
+
+func (v *validator) featureFlag(key string, flag FeatureFlagConfig) {
+	if flag.Percentage != nil {
+		v.check(*flag.Percentage >= 0 && *flag.Percentage <= 100, key+".percentage", "must be between 0 and 100, got %d", *flag.Percentage)
+	}
+	for i, rule := range flag.Rules {
+		ruleKey := fmt.Sprintf("%s.rules[%d]", key, i)
+		v.required(ruleKey+".attribute", rule.Attribute)
+		v.check(len(rule.Values) > 0, ruleKey+".values", "is required")
+	}
+}
+
+func (v *validator) ipOrCIDR(key, value string) {
+	_, prefixErr := netip.ParsePrefix(value)
+	_, addrErr := netip.ParseAddr(value)
+	v.check(prefixErr == nil || addrErr == nil, key, "must be an IP or a CIDR range")
+}
+
+func (v *validator) sha256(key, value string) {
+	hash, err := hex.DecodeString(value)
+	v.check(err == nil && len(hash) == sha256.Size, key, "must be a hex SHA-256 hash")
+}
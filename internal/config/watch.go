@@ -0,0 +1,180 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"ecom-go/pkg/logger"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// reloadableKeys are the settings, and the sections of settings, that can
+// change while the application runs. Changing any other setting needs a restart
+var reloadableKeys = []string{
+	"log",
+	"rate_limit.per_ip",
+	"rate_limit.per_user",
+	"rate_limit.per_api_key",
+	"cache.ttl",
+	"feature_flags.flags",
+}
+
+// reloadDelay collapses the bursts of file events of a single save into one reload
+const reloadDelay = 500 * time.Millisecond
+
+// Event is published to subscribers when a section of the configuration changed
+type Event[T any] struct {
+	Old T
+	New T
+}
+
+// Watcher reloads the configuration when its files change and publishes the
+// changed sections to their subscribers. A change is only applied when every
+// changed setting is reloadable, otherwise it is logged as needing a restart
+type Watcher struct {
+	opts        loadOptions
+	files       *fsnotify.Watcher
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(old, new *Config)
+}
+
+// NewWatcher creates a watcher of the files of the configuration loaded by
+// LoadConfig, which is the current configuration
+func NewWatcher(current *Config) (*Watcher, error) {
+	files, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch config files: %w", err)
+	}
+	// The directory is watched rather than the files, as editors and
+	// Kubernetes ConfigMaps replace files instead of writing to them
+	if err := files.Add(loaded.configDir); err != nil {
+		files.Close()
+		return nil, fmt.Errorf("failed to watch config directory: %w", err)
+	}
+
+	return &Watcher{
+		opts:    loaded,
+		files:   files,
+		current: current,
+	}, nil
+}
+
+// Subscribe calls fn with the old and new value of a section of the
+// configuration whenever a reload changes it. Only the reloadable settings of
+// the section can change
+func Subscribe[T any](w *Watcher, section func(*Config) T, fn func(Event[T])) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, func(old, new *Config) {
+		event := Event[T]{Old: section(old), New: section(new)}
+		if !reflect.DeepEqual(event.Old, event.New) {
+			fn(event)
+		}
+	})
+}
+
+// Run reloads the configuration on file changes until the context is canceled
+func (w *Watcher) Run(ctx context.Context) {
+	defer w.files.Close()
+
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.files.Events:
+			if !ok {
+				return
+			}
+			if w.isConfigFile(event.Name) {
+				reload = time.After(reloadDelay)
+			}
+		case err, ok := <-w.files.Errors:
+			if !ok {
+				return
+			}
+			logger.Error("Config watcher failed", "error", err)
+		case <-reload:
+			reload = nil
+			w.reload()
+		}
+	}
+}
+
+// isConfigFile reports whether a changed file may hold configuration. Any
+// YAML file counts, as well as the ..data link Kubernetes swaps on updates
+func (w *Watcher) isConfigFile(name string) bool {
+	return strings.HasSuffix(name, ".yaml") || strings.HasSuffix(name, "..data")
+}
+
+// reload reads the configuration again and applies it when it only changed reloadable settings
+func (w *Watcher) reload() {
+	next, err := load(viper.New(), w.opts)
+	if err != nil {
+		logger.Error("Ignoring config change, the new configuration is invalid", "error", err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changed := changedKeys(reflect.ValueOf(*w.current), reflect.ValueOf(*next), "")
+	if len(changed) == 0 {
+		return
+	}
+
+	var restart []string
+	for _, key := range changed {
+		if !isReloadable(key) {
+			restart = append(restart, key)
+		}
+	}
+	if len(restart) > 0 {
+		logger.Warn("Config change needs a restart, none of it was applied", "settings", restart)
+		return
+	}
+
+	old := w.current
+	w.current = next
+	logger.Info("Config reloaded", "settings", changed)
+	for _, notify := range w.subscribers {
+		notify(old, next)
+	}
+}
+
+// isReloadable reports whether a setting can change while the application runs
+func isReloadable(key string) bool {
+	for _, reloadable := range reloadableKeys {
+		if key == reloadable || strings.HasPrefix(key, reloadable+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// changedKeys returns the dotted keys of the settings that differ between two
+// configuration structs
+func changedKeys(old, new reflect.Value, prefix string) []string {
+	if old.Kind() != reflect.Struct {
+		if reflect.DeepEqual(old.Interface(), new.Interface()) {
+			return nil
+		}
+		return []string{prefix}
+	}
+
+	var keys []string
+	for i := 0; i < old.NumField(); i++ {
+		key := old.Type().Field(i).Tag.Get("mapstructure")
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		keys = append(keys, changedKeys(old.Field(i), new.Field(i), key)...)
+	}
+	return keys
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// baseConfig is the config.yaml the watcher tests start from
+const baseConfig = `
+server:
+  port: 8080
+log:
+  level: info
+rate_limit:
+  per_ip:
+    requests: 120
+    period: 1m
+cache:
+  ttl: 5m
+`
+
+// newTestWatcher loads the configuration from a config.yaml holding content
+// and returns a watcher of it, without watching the files, and a function
+// replacing the file's content
+func newTestWatcher(t *testing.T, content string) (*Watcher, func(string)) {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(content string) {
+		if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(content)
+
+	opts := loadOptions{configDir: dir}
+	current, err := load(viper.New(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Watcher{opts: opts, current: current}, write
+}
+
+func TestWatcherReload(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		wantApplied bool
+	}{
+		{
+			name:        "reloadable settings",
+			content:     baseConfig + "feature_flags:\n  flags:\n    new_checkout:\n      enabled: true\n",
+			wantApplied: true,
+		},
+		{
+			name: "setting needing a restart",
+			content: `
+server:
+  port: 9090
+log:
+  level: info
+rate_limit:
+  per_ip:
+    requests: 120
+    period: 1m
+cache:
+  ttl: 5m
+`,
+		},
+		{
+			name: "reloadable and restart-needed settings together",
+			content: `
+server:
+  port: 9090
+log:
+  level: debug
+rate_limit:
+  per_ip:
+    requests: 60
+    period: 1m
+cache:
+  ttl: 1m
+`,
+		},
+		{
+			name:    "invalid configuration",
+			content: baseConfig + "  backend: unknown\n",
+		},
+		{
+			name: "reloadable settings made invalid",
+			content: `
+server:
+  port: 8080
+log:
+  level: verbose
+rate_limit:
+  per_ip:
+    requests: 120
+    period: 1m
+cache:
+  ttl: 5m
+`,
+		},
+		{
+			name:    "no change",
+			content: baseConfig + "\n# comment\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, write := newTestWatcher(t, baseConfig)
+			initial := w.current
+
+			var logEvents []Event[LogConfig]
+			var flagEvents []Event[map[string]FeatureFlagConfig]
+			Subscribe(w, func(c *Config) LogConfig { return c.Log }, func(e Event[LogConfig]) { logEvents = append(logEvents, e) })
+			Subscribe(w, func(c *Config) map[string]FeatureFlagConfig { return c.FeatureFlags.Flags }, func(e Event[map[string]FeatureFlagConfig]) {
+				flagEvents = append(flagEvents, e)
+			})
+
+			write(tt.content)
+			w.reload()
+
+			if !tt.wantApplied {
+				if w.current != initial {
+					t.Error("configuration replaced")
+				}
+				if len(logEvents) != 0 || len(flagEvents) != 0 {
+					t.Errorf("subscribers notified of %d log and %d flag changes, want none", len(logEvents), len(flagEvents))
+				}
+				return
+			}
+			if w.current == initial {
+				t.Fatal("configuration not replaced")
+			}
+			// Only the subscribers of changed sections are notified
+			if len(logEvents) != 0 {
+				t.Errorf("log subscriber notified of %+v", logEvents)
+			}
+			if len(flagEvents) != 1 || len(flagEvents[0].Old) != 0 || !flagEvents[0].New["new_checkout"].Enabled {
+				t.Errorf("flag events = %+v, want the new flag", flagEvents)
+			}
+		})
+	}
+}
+
+func TestChangedKeys(t *testing.T) {
+	base := Config{
+		Server:    ServerConfig{Port: 8080},
+		Cache:     CacheConfig{TTL: 5 * time.Minute},
+		RateLimit: RateLimitConfig{PerIP: RateLimitRule{Requests: 120, Period: time.Minute}},
+	}
+	tests := []struct {
+		name           string
+		change         func(c *Config)
+		want           []string
+		wantReloadable bool
+	}{
+		{name: "nothing", change: func(c *Config) {}, wantReloadable: true},
+		{name: "cache TTL", change: func(c *Config) { c.Cache.TTL = time.Minute }, want: []string{"cache.ttl"}, wantReloadable: true},
+		{name: "cache backend", change: func(c *Config) { c.Cache.Backend = "memory" }, want: []string{"cache.backend"}},
+		{
+			name:           "rate limit rule",
+			change:         func(c *Config) { c.RateLimit.PerIP.Requests, c.RateLimit.PerIP.Burst = 60, 10 },
+			want:           []string{"rate_limit.per_ip.requests", "rate_limit.per_ip.burst"},
+			wantReloadable: true,
+		},
+		{
+			name:   "server port and log level",
+			change: func(c *Config) { c.Server.Port, c.Log.Level = 9090, "debug" },
+			want:   []string{"server.port", "log.level"},
+		},
+		{name: "trusted proxies", change: func(c *Config) { c.Server.TrustedProxies = []string{"10.0.0.1"} }, want: []string{"server.trusted_proxies"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := base
+			tt.change(&next)
+			got := changedKeys(reflect.ValueOf(base), reflect.ValueOf(next), "")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("changedKeys = %v, want %v", got, tt.want)
+			}
+			reloadable := true
+			for _, key := range got {
+				reloadable = reloadable && isReloadable(key)
+			}
+			if reloadable != tt.wantReloadable {
+				t.Errorf("reloadable = %v, want %v", reloadable, tt.wantReloadable)
+			}
+		})
+	}
+}
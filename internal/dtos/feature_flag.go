@@ -0,0 +1,16 @@
+package dtos
+
+// SetFeatureFlagDTO represents the input for setting a feature flag through
+// the admin API. Fields left out keep their current value
+type SetFeatureFlagDTO struct {
+	Description *string               `json:"description" binding:"omitempty,max=255"`
+	Enabled     *bool                 `json:"enabled"`
+	Percentage  *int                  `json:"percentage" binding:"omitempty,min=0,max=100"`
+	Rules       *[]FeatureFlagRuleDTO `json:"rules" binding:"omitempty,dive"`
+}
+
+// FeatureFlagRuleDTO represents a rule targeting the users whose attribute has one of the values
+type FeatureFlagRuleDTO struct {
+	Attribute string   `json:"attribute" binding:"required,max=64"`
+	Values    []string `json:"values" binding:"required,min=1"`
+}
@@ -0,0 +1,13 @@
+package dtos
+
+// NotificationPreferenceDTO represents whether a user gets notified of an event type on a channel
+type NotificationPreferenceDTO struct {
+	EventType string `json:"event_type" binding:"required"`
+	Channel   string `json:"channel" binding:"required,oneof=email in_app"`
+	Enabled   *bool  `json:"enabled" binding:"required"`
+}
+
+// UpdateNotificationPreferencesDTO represents the input for changing a user's notification preferences
+type UpdateNotificationPreferencesDTO struct {
+	Preferences []NotificationPreferenceDTO `json:"preferences" binding:"required,min=1,dive"`
+}
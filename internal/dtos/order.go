@@ -6,8 +6,13 @@ import (
 
 // CreateOrderDTO represents the input for creating a new order
 type CreateOrderDTO struct {
-	UserID   int                `json:"user_id" binding:"required"`
+	UserID   int                `json:"-"` // The authenticated user placing the order
 	Products []models.OrderItem `json:"products" binding:"required"`
+	CartID   string             `json:"cart_id"` // Stock held for this cart is handed over to the order
+
+	// Where the order ships to, used to pick the nearest warehouses
+	ShippingLatitude  *float64 `json:"shipping_latitude" binding:"omitempty,latitude"`
+	ShippingLongitude *float64 `json:"shipping_longitude" binding:"omitempty,longitude"`
 }
 
 type ViewOrderDTO struct {
@@ -21,3 +26,8 @@ type ListOrderDTO struct {
 	Filter string `json:"filter"`
 	Sort   string `json:"sort"`
 }
+
+// UpdateOrderStatusDTO represents the input for changing an order's status
+type UpdateOrderStatusDTO struct {
+	Status string `json:"status" binding:"required,oneof=shipped completed canceled"`
+}
@@ -1,23 +1,44 @@
 package dtos
 
+import (
+	"ecom-go/internal/models"
+)
+
 type ViewProductDTO struct {
 	ID int `json:"id"`
 }
 
 type CreateProductDTO struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description" binding:"required"`
-	Price       float64 `json:"price" binding:"required"`
-	Stock       int     `json:"stock" binding:"required"`
+	Name             string  `json:"name" binding:"required"`
+	Description      string  `json:"description" binding:"required"`
+	Price            float64 `json:"price" binding:"required"`
+	Stock            int     `json:"stock" binding:"required"`
+	ReorderThreshold int     `json:"reorder_threshold" binding:"min=0"`
 }
 
 type UpdateProductDTO struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	Price       float64 `json:"price"`
-	Stock       int     `json:"stock"`
+	Name             string  `json:"name"`
+	Description      string  `json:"description"`
+	Price            float64 `json:"price"`
+	ReorderThreshold *int    `json:"reorder_threshold" binding:"omitempty,min=0"`
 }
 
 type DeleteProductDTO struct {
 	ID int `json:"id"`
 }
+
+// StockAdjustmentDTO represents a manual change of a product's stock
+type StockAdjustmentDTO struct {
+	Quantity    int    `json:"quantity" binding:"required"` // Positive to add stock, negative to remove it
+	Reason      string `json:"reason" binding:"required,oneof=adjustment restock"`
+	Note        string `json:"note"`
+	ActorID     *int   `json:"-"`            // The authenticated user making the adjustment
+	WarehouseID *int   `json:"warehouse_id"` // Warehouse whose stock changes, if any
+}
+
+// LowStockItemDTO represents a product at or below its reorder threshold with a reorder suggestion
+type LowStockItemDTO struct {
+	Product          *models.Product `json:"product"`
+	DailySales       float64         `json:"daily_sales"`
+	SuggestedReorder int             `json:"suggested_reorder"`
+}
@@ -0,0 +1,13 @@
+package dtos
+
+// ReservationItemDTO represents a product quantity to hold
+type ReservationItemDTO struct {
+	ProductID int `json:"product_id" binding:"required"`
+	Quantity  int `json:"quantity" binding:"required,min=1"`
+}
+
+// ReserveCartDTO represents the input for holding stock for a cart during checkout
+type ReserveCartDTO struct {
+	UserID int                  `json:"-"` // The authenticated user, if any, reminded of an abandoned cart
+	Items  []ReservationItemDTO `json:"items" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,21 @@
+package dtos
+
+// CreateReturnItemDTO represents a single product in a return request
+type CreateReturnItemDTO struct {
+	ProductID int    `json:"product_id" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+	Reason    string `json:"reason" binding:"required"`
+}
+
+// CreateReturnDTO represents the input for requesting a return
+type CreateReturnDTO struct {
+	OrderID int                   `json:"order_id" binding:"required"`
+	UserID  int                   `json:"-"` // The authenticated user returning the order
+	Items   []CreateReturnItemDTO `json:"items" binding:"required,min=1,dive"`
+	Note    string                `json:"note"`
+}
+
+// RejectReturnDTO represents the input for rejecting a return request
+type RejectReturnDTO struct {
+	Reason string `json:"reason" binding:"required"`
+}
@@ -0,0 +1,27 @@
+package dtos
+
+// CreateWarehouseDTO represents the input for creating a new warehouse
+type CreateWarehouseDTO struct {
+	Code      string  `json:"code" binding:"required,max=32"`
+	Name      string  `json:"name" binding:"required"`
+	Latitude  float64 `json:"latitude" binding:"latitude"`
+	Longitude float64 `json:"longitude" binding:"longitude"`
+}
+
+// UpdateWarehouseDTO represents the input for updating a warehouse
+type UpdateWarehouseDTO struct {
+	Name      string   `json:"name"`
+	Latitude  *float64 `json:"latitude" binding:"omitempty,latitude"`
+	Longitude *float64 `json:"longitude" binding:"omitempty,longitude"`
+	Active    *bool    `json:"active"`
+}
+
+// StockTransferDTO represents the input for moving stock between warehouses
+type StockTransferDTO struct {
+	ProductID       int    `json:"product_id" binding:"required"`
+	FromWarehouseID int    `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   int    `json:"to_warehouse_id" binding:"required,nefield=FromWarehouseID"`
+	Quantity        int    `json:"quantity" binding:"required,min=1"`
+	Note            string `json:"note"`
+	ActorID         *int   `json:"-"` // The authenticated user making the transfer
+}
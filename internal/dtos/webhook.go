@@ -0,0 +1,16 @@
+package dtos
+
+// CreateWebhookDTO represents the input for subscribing an endpoint to webhooks
+type CreateWebhookDTO struct {
+	URL         string   `json:"url" binding:"required,url"`
+	Description string   `json:"description" binding:"max=255"`
+	EventTypes  []string `json:"event_types" binding:"required,min=1"`
+}
+
+// UpdateWebhookDTO represents the input for updating a webhook subscription
+type UpdateWebhookDTO struct {
+	URL         string   `json:"url" binding:"omitempty,url"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	EventTypes  []string `json:"event_types" binding:"omitempty,min=1"`
+	Active      *bool    `json:"active"`
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Aggregate types events belong to, events of the same aggregate are published in order
+const (
+	AggregateOrder   = "order"
+	AggregateProduct = "product"
+	AggregateCart    = "cart"
+	AggregateUser    = "user"
+	AggregateReturn  = "return"
+	AggregateWebhook = "webhook"
+)
+
+// Event types, also used as routing keys on the broker
+const (
+	UserCreated    = "user.created"
+	OrderCreated   = "order.created"
+	OrderShipped   = "order.shipped"
+	ReturnRefunded = "return.refunded"
+	StockChanged   = "stock.changed"
+	ProductCreated = "product.created"
+	ProductUpdated = "product.updated"
+	ProductDeleted = "product.deleted"
+	CartAbandoned  = "cart.abandoned"
+	// WebhookDeliveryRequested asks the worker to deliver an event to a single webhook endpoint
+	WebhookDeliveryRequested = "webhook.delivery_requested"
+)
+
+// OrderItem is a line of an order in an order event
+type OrderItem struct {
+	ProductID   int     `json:"product_id"`
+	Quantity    int     `json:"quantity"`
+	WarehouseID *int    `json:"warehouse_id,omitempty"`
+	UnitPrice   float64 `json:"unit_price"`
+}
+
+// OrderCreatedEvent is published when an order is placed
+type OrderCreatedEvent struct {
+	OrderID    int         `json:"order_id"`
+	UserID     int         `json:"user_id"`
+	TotalPrice float64     `json:"total_price"`
+	Items      []OrderItem `json:"items"`
+}
+
+// StockChangedEvent is published whenever a product's stock level changes
+type StockChangedEvent struct {
+	ProductID    int    `json:"product_id"`
+	WarehouseID  *int   `json:"warehouse_id,omitempty"`
+	Quantity     int    `json:"quantity"`
+	Reason       string `json:"reason"`
+	BalanceAfter int    `json:"balance_after"`
+}
+
+// ProductChangedEvent is published when a product is created, updated or deleted
+type ProductChangedEvent struct {
+	ProductID        int     `json:"product_id"`
+	Name             string  `json:"name,omitempty"`
+	Description      string  `json:"description,omitempty"`
+	Price            float64 `json:"price,omitempty"`
+	ReorderThreshold int     `json:"reorder_threshold,omitempty"`
+}
+
+// CartItem is a product left in a cart
+type CartItem struct {
+	ProductID int `json:"product_id"`
+	Quantity  int `json:"quantity"`
+}
+
+// CartAbandonedEvent is published when a user left a cart without checking out
+type CartAbandonedEvent struct {
+	CartID string     `json:"cart_id"`
+	UserID int        `json:"user_id"`
+	Items  []CartItem `json:"items"`
+}
+
+// WebhookDeliveryRequestedEvent is an event to deliver to a webhook endpoint
+type WebhookDeliveryRequestedEvent struct {
+	SubscriptionID int             `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// UserCreatedEvent is published when a user signs up
+type UserCreatedEvent struct {
+	UserID    uint   `json:"user_id"`
+	Email     string `json:"email"`
+	FirstName string `json:"first_name"`
+}
+
+// OrderStatusChangedEvent is published as "order.<status>" whenever an order changes status
+type OrderStatusChangedEvent struct {
+	OrderID        int    `json:"order_id"`
+	UserID         int    `json:"user_id"`
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+}
+
+// ReturnStatusChangedEvent is published as "return.<status>" whenever a return request changes status
+type ReturnStatusChangedEvent struct {
+	ReturnID       int     `json:"return_id"`
+	OrderID        int     `json:"order_id"`
+	UserID         int     `json:"user_id"`
+	PreviousStatus string  `json:"previous_status"`
+	Status         string  `json:"status"`
+	RefundAmount   float64 `json:"refund_amount"`
+}
+
+// OrderStatusEvent returns the type of the event published when an order moves to the status
+func OrderStatusEvent(status string) string {
+	return "order." + status
+}
+
+// ReturnStatusEvent returns the type of the event published when a return request moves to the status
+func ReturnStatusEvent(status string) string {
+	return "return." + status
+}
@@ -0,0 +1,91 @@
+package featureflags
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Subject is who flags are evaluated for
+type Subject struct {
+	// UserID identifies the user, empty for anonymous requests. Percentage
+	// rollouts hash it, so a user keeps getting the same variant
+	UserID string
+	// Attributes are matched by the flags' rules, such as "country" or "plan".
+	// Rules on "user_id" match UserID
+	Attributes map[string]string
+}
+
+// attribute returns the value of a subject's attribute
+func (s Subject) attribute(name string) (string, bool) {
+	if name == "user_id" {
+		return s.UserID, s.UserID != ""
+	}
+	value, ok := s.Attributes[name]
+	return value, ok
+}
+
+// evaluationKey is the context key of the flags' evaluation
+type evaluationKey struct{}
+
+// evaluation evaluates flags for the subject of a request, remembering the
+// results so that a flag toggled mid-request doesn't change behavior halfway
+type evaluation struct {
+	evaluator *Evaluator
+	subject   Subject
+	mu        sync.Mutex
+	results   map[string]bool
+}
+
+// WithSubject returns a context in which Enabled evaluates flags for the subject
+func WithSubject(ctx context.Context, evaluator *Evaluator, subject Subject) context.Context {
+	return context.WithValue(ctx, evaluationKey{}, &evaluation{
+		evaluator: evaluator,
+		subject:   subject,
+		results:   map[string]bool{},
+	})
+}
+
+// authenticatedAttributes come from the access token and can't be replaced by WithAttributes
+var authenticatedAttributes = []string{"user_id", "role"}
+
+// WithAttributes returns a context whose subject has additional attributes,
+// such as those only known to a service. Results cached so far are not kept
+func WithAttributes(ctx context.Context, attributes map[string]string) context.Context {
+	e, ok := ctx.Value(evaluationKey{}).(*evaluation)
+	if !ok {
+		return ctx
+	}
+	subject := Subject{
+		UserID:     e.subject.UserID,
+		Attributes: maps.Clone(e.subject.Attributes),
+	}
+	if subject.Attributes == nil {
+		subject.Attributes = map[string]string{}
+	}
+	for name, value := range attributes {
+		if !slices.Contains(authenticatedAttributes, name) {
+			subject.Attributes[name] = value
+		}
+	}
+	return WithSubject(ctx, e.evaluator, subject)
+}
+
+// Enabled reports whether the flag is on for the subject of the context. Flags
+// are off in contexts without a subject, such as those of background jobs
+func Enabled(ctx context.Context, key string) bool {
+	e, ok := ctx.Value(evaluationKey{}).(*evaluation)
+	if !ok {
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if result, ok := e.results[key]; ok {
+		return result
+	}
+	result := e.evaluator.Evaluate(key, e.subject)
+	e.results[key] = result
+	return result
+}
@@ -0,0 +1,169 @@
+package featureflags
+
+import (
+	"context"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	"ecom-go/pkg/logger"
+)
+
+// Evaluator evaluates the feature flags defined in the configuration and
+// those set through the admin API, which are stored in the database and take
+// precedence over the configuration
+type Evaluator struct {
+	repo            repository.FeatureFlagRepository
+	refreshInterval time.Duration
+	mu              sync.Mutex
+	configured      map[string]*models.FeatureFlag
+	stored          map[string]*models.FeatureFlag
+	// flags are the configured and stored flags merged, replaced as a whole on every change
+	flags atomic.Pointer[map[string]*models.FeatureFlag]
+}
+
+// NewEvaluator creates an evaluator of the configured flags. The stored flags
+// are only read by Refresh
+func NewEvaluator(cfg config.FeatureFlagsConfig, repo repository.FeatureFlagRepository) *Evaluator {
+	e := &Evaluator{
+		repo:            repo,
+		refreshInterval: cfg.RefreshInterval,
+		stored:          map[string]*models.FeatureFlag{},
+	}
+	e.SetConfig(cfg)
+	return e
+}
+
+// SetConfig replaces the configured flags, when the configuration is reloaded
+func (e *Evaluator) SetConfig(cfg config.FeatureFlagsConfig) {
+	configured := make(map[string]*models.FeatureFlag, len(cfg.Flags))
+	for key, flagConfig := range cfg.Flags {
+		flag := &models.FeatureFlag{
+			Key:         key,
+			Description: flagConfig.Description,
+			Enabled:     flagConfig.Enabled,
+			Percentage:  100,
+			Source:      models.FeatureFlagSourceConfig,
+		}
+		if flagConfig.Percentage != nil {
+			flag.Percentage = *flagConfig.Percentage
+		}
+		for _, rule := range flagConfig.Rules {
+			flag.Rules = append(flag.Rules, models.FeatureFlagRule{Attribute: rule.Attribute, Values: rule.Values})
+		}
+		configured[key] = flag
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.configured = configured
+	e.publish()
+}
+
+// Refresh reads the stored flags again
+func (e *Evaluator) Refresh(ctx context.Context) error {
+	flags, err := e.repo.List(ctx)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]*models.FeatureFlag, len(flags))
+	for _, flag := range flags {
+		flag.Source = models.FeatureFlagSourceDatabase
+		stored[flag.Key] = flag
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stored = stored
+	e.publish()
+	return nil
+}
+
+// Run refreshes the stored flags periodically until the context is canceled,
+// picking up the flags toggled through other replicas
+func (e *Evaluator) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.refreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := e.Refresh(ctx); err != nil && ctx.Err() == nil {
+				logger.Error("Failed to refresh feature flags, keeping the previous ones", "error", err)
+			}
+		}
+	}
+}
+
+// publish merges the configured and stored flags, the caller holds mu
+func (e *Evaluator) publish() {
+	flags := maps.Clone(e.configured)
+	maps.Copy(flags, e.stored)
+	e.flags.Store(&flags)
+}
+
+// Flags returns every flag ordered by key
+func (e *Evaluator) Flags() []*models.FeatureFlag {
+	flags := *e.flags.Load()
+	keys := slices.Sorted(maps.Keys(flags))
+	list := make([]*models.FeatureFlag, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, flags[key])
+	}
+	return list
+}
+
+// Flag returns the flag with the key
+func (e *Evaluator) Flag(key string) (*models.FeatureFlag, bool) {
+	flag, ok := (*e.flags.Load())[key]
+	return flag, ok
+}
+
+// Evaluate reports whether the flag is on for the subject. Unknown flags are off
+func (e *Evaluator) Evaluate(key string, subject Subject) bool {
+	flag, ok := e.Flag(key)
+	if !ok {
+		logger.Debug("Unknown feature flag", "flag", key)
+		return false
+	}
+	return evaluate(flag, subject)
+}
+
+// evaluate reports whether an enabled flag targets the subject by one of its
+// rules or includes it in its percentage rollout
+func evaluate(flag *models.FeatureFlag, subject Subject) bool {
+	if !flag.Enabled {
+		return false
+	}
+	for _, rule := range flag.Rules {
+		if value, ok := subject.attribute(rule.Attribute); ok && slices.Contains(rule.Values, value) {
+			return true
+		}
+	}
+
+	switch {
+	case flag.Percentage >= 100:
+		return true
+	case flag.Percentage <= 0 || subject.UserID == "":
+		// Anonymous subjects can't be kept in the same bucket from one request to the next
+		return false
+	default:
+		return bucket(flag.Key, subject.UserID) < flag.Percentage
+	}
+}
+
+// bucket places a user in one of 100 buckets of a flag. The flag's key is
+// hashed along with the user's ID, so that the users of a 10% rollout of one
+// flag aren't the same as those of another
+func bucket(key, userID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(key + ":" + userID))
+	return int(hash.Sum32() % 100)
+}
@@ -0,0 +1,192 @@
+package featureflags
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+)
+
+// fakeRepository holds the stored flags in memory
+type fakeRepository struct {
+	flags []*models.FeatureFlag
+}
+
+func (r *fakeRepository) List(context.Context) ([]*models.FeatureFlag, error) {
+	return r.flags, nil
+}
+
+func (r *fakeRepository) GetByKey(context.Context, string) (*models.FeatureFlag, error) {
+	return nil, nil
+}
+
+func (r *fakeRepository) Save(context.Context, *models.FeatureFlag) error {
+	return nil
+}
+
+func (r *fakeRepository) Delete(context.Context, string) error {
+	return nil
+}
+
+func percentage(p int) *int {
+	return &p
+}
+
+func TestBucketIsStable(t *testing.T) {
+	for i := range 1000 {
+		userID := strconv.Itoa(i)
+		first := bucket("new_checkout", userID)
+		if first < 0 || first >= 100 {
+			t.Fatalf("bucket(%q) = %d, want 0-99", userID, first)
+		}
+		if again := bucket("new_checkout", userID); again != first {
+			t.Fatalf("bucket(%q) = %d then %d", userID, first, again)
+		}
+	}
+}
+
+func TestPercentageRollout(t *testing.T) {
+	flag := &models.FeatureFlag{Key: "new_checkout", Enabled: true, Percentage: 10}
+	other := &models.FeatureFlag{Key: "fast_search", Enabled: true, Percentage: 10}
+
+	const users = 10000
+	on, onBoth := 0, 0
+	for i := range users {
+		subject := Subject{UserID: strconv.Itoa(i)}
+		if evaluate(flag, subject) {
+			on++
+			if evaluate(other, subject) {
+				onBoth++
+			}
+		}
+		// Raising the percentage keeps the users already included
+		wider := &models.FeatureFlag{Key: flag.Key, Enabled: true, Percentage: 50}
+		if evaluate(flag, subject) && !evaluate(wider, subject) {
+			t.Fatalf("user %d left the rollout when it grew", i)
+		}
+	}
+
+	if on < users*8/100 || on > users*12/100 {
+		t.Errorf("flag on for %d of %d users, want about 10%%", on, users)
+	}
+	// Flags with the same percentage don't roll out to the same users
+	if onBoth > on/2 {
+		t.Errorf("%d of the %d users of one flag have the other, want about 10%%", onBoth, on)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []models.FeatureFlagRule{
+		{Attribute: "user_id", Values: []string{"7"}},
+		{Attribute: "country", Values: []string{"NL", "BE"}},
+	}
+	tests := []struct {
+		name    string
+		flag    models.FeatureFlag
+		subject Subject
+		want    bool
+	}{
+		{"disabled", models.FeatureFlag{Percentage: 100}, Subject{UserID: "1"}, false},
+		{"disabled flag ignores rules", models.FeatureFlag{Percentage: 100, Rules: rules}, Subject{UserID: "7"}, false},
+		{"everyone", models.FeatureFlag{Enabled: true, Percentage: 100}, Subject{UserID: "1"}, true},
+		{"everyone, anonymous", models.FeatureFlag{Enabled: true, Percentage: 100}, Subject{}, true},
+		{"nobody", models.FeatureFlag{Enabled: true, Percentage: 0}, Subject{UserID: "1"}, false},
+		{"partial rollout, anonymous", models.FeatureFlag{Enabled: true, Percentage: 99}, Subject{}, false},
+		{"user_id rule", models.FeatureFlag{Enabled: true, Rules: rules}, Subject{UserID: "7"}, true},
+		{"user_id rule, other user", models.FeatureFlag{Enabled: true, Rules: rules}, Subject{UserID: "8"}, false},
+		{"user_id rule, anonymous", models.FeatureFlag{Enabled: true, Rules: []models.FeatureFlagRule{{Attribute: "user_id", Values: []string{""}}}}, Subject{}, false},
+		{"attribute rule", models.FeatureFlag{Enabled: true, Rules: rules}, Subject{UserID: "8", Attributes: map[string]string{"country": "BE"}}, true},
+		{"attribute rule, other value", models.FeatureFlag{Enabled: true, Rules: rules}, Subject{UserID: "8", Attributes: map[string]string{"country": "FR"}}, false},
+		{"attribute rule, anonymous", models.FeatureFlag{Enabled: true, Rules: rules}, Subject{Attributes: map[string]string{"country": "NL"}}, true},
+		{"user_id attribute doesn't match the rule", models.FeatureFlag{Enabled: true, Rules: rules}, Subject{UserID: "8", Attributes: map[string]string{"user_id": "7"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.flag.Key = "new_checkout"
+			if got := evaluate(&tt.flag, tt.subject); got != tt.want {
+				t.Errorf("evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoredFlagsTakePrecedence(t *testing.T) {
+	repo := &fakeRepository{}
+	evaluator := NewEvaluator(config.FeatureFlagsConfig{Flags: map[string]config.FeatureFlagConfig{
+		"new_checkout": {Enabled: true},
+		"fast_search":  {Enabled: true, Percentage: percentage(0)},
+	}}, repo)
+	user := Subject{UserID: "1"}
+
+	if !evaluator.Evaluate("new_checkout", user) || evaluator.Evaluate("fast_search", user) {
+		t.Fatal("configured flags not evaluated")
+	}
+	if evaluator.Evaluate("unknown", user) {
+		t.Error("unknown flag is on")
+	}
+
+	repo.flags = []*models.FeatureFlag{{Key: "new_checkout", Enabled: false, Percentage: 100}}
+	if err := evaluator.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if evaluator.Evaluate("new_checkout", user) {
+		t.Error("stored flag doesn't override the configured one")
+	}
+	if flag, _ := evaluator.Flag("new_checkout"); flag.Source != models.FeatureFlagSourceDatabase {
+		t.Errorf("Source = %q, want %q", flag.Source, models.FeatureFlagSourceDatabase)
+	}
+
+	// Reloading the configuration keeps the stored override
+	evaluator.SetConfig(config.FeatureFlagsConfig{Flags: map[string]config.FeatureFlagConfig{
+		"new_checkout": {Enabled: true},
+	}})
+	if evaluator.Evaluate("new_checkout", user) {
+		t.Error("configuration reload replaced the stored flag")
+	}
+	if _, ok := evaluator.Flag("fast_search"); ok {
+		t.Error("flag removed from the configuration is still there")
+	}
+
+	// Deleting the stored flag falls back to the configuration
+	repo.flags = nil
+	if err := evaluator.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !evaluator.Evaluate("new_checkout", user) {
+		t.Error("configured flag not used after the stored one was deleted")
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	repo := &fakeRepository{}
+	evaluator := NewEvaluator(config.FeatureFlagsConfig{Flags: map[string]config.FeatureFlagConfig{
+		"admin_tools": {Enabled: true, Percentage: percentage(0), Rules: []config.FeatureFlagRuleConfig{{Attribute: "role", Values: []string{"admin"}}}},
+	}}, repo)
+
+	if Enabled(context.Background(), "admin_tools") {
+		t.Error("flag on without a subject")
+	}
+
+	ctx := WithSubject(context.Background(), evaluator, Subject{UserID: "1", Attributes: map[string]string{"role": "user"}})
+	if Enabled(ctx, "admin_tools") {
+		t.Error("flag on for a user")
+	}
+	if Enabled(WithAttributes(ctx, map[string]string{"role": "admin"}), "admin_tools") {
+		t.Error("WithAttributes replaced the role of the token")
+	}
+
+	admin := WithSubject(context.Background(), evaluator, Subject{UserID: "2", Attributes: map[string]string{"role": "admin"}})
+	if !Enabled(admin, "admin_tools") {
+		t.Fatal("flag off for an admin")
+	}
+	// The result is kept for the rest of the request
+	repo.flags = []*models.FeatureFlag{{Key: "admin_tools", Enabled: false}}
+	if err := evaluator.Refresh(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !Enabled(admin, "admin_tools") {
+		t.Error("flag changed mid-request")
+	}
+}
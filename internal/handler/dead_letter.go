@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DeadLetterHandler handles HTTP requests related to dead-lettered worker messages
+type DeadLetterHandler struct {
+	deadLetterService *service.DeadLetterService
+}
+
+// NewDeadLetterHandler creates a new dead letter handler
+func NewDeadLetterHandler(deadLetterService *service.DeadLetterService) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetterService: deadLetterService,
+	}
+}
+
+// Register sets up routes for the dead letter handler
+func (h *DeadLetterHandler) Register(router *gin.RouterGroup) {
+	deadLetters := router.Group("/admin/dead-letters", middleware.RequireAdmin())
+	{
+		deadLetters.GET("", h.List)
+		deadLetters.GET("/:id", h.GetByID)
+		deadLetters.POST("/:id/replay", h.Replay)
+		deadLetters.DELETE("", h.Purge)
+	}
+}
+
+// List handles retrieving dead-lettered messages with pagination, filtered by the queue query parameter
+func (h *DeadLetterHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	deadLetters, total, err := h.deadLetterService.ListDeadLetters(c.Request.Context(), c.Query("queue"), page, pageSize)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, deadLetters, page, pageSize, total)
+}
+
+// GetByID handles inspecting a dead-lettered message
+func (h *DeadLetterHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid dead letter ID"))
+		return
+	}
+
+	deadLetter, err := h.deadLetterService.GetDeadLetter(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, deadLetter)
+}
+
+// Replay handles sending a dead-lettered message back to its queue
+func (h *DeadLetterHandler) Replay(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid dead letter ID"))
+		return
+	}
+
+	deadLetter, err := h.deadLetterService.ReplayDeadLetter(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, deadLetter)
+}
+
+// Purge handles deleting dead-lettered messages, filtered by the queue query parameter
+func (h *DeadLetterHandler) Purge(c *gin.Context) {
+	deleted, err := h.deadLetterService.PurgeDeadLetters(c.Request.Context(), c.Query("queue"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"deleted": deleted})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlagHandler handles HTTP requests related to toggling feature flags at runtime
+type FeatureFlagHandler struct {
+	featureFlagService *service.FeatureFlagService
+}
+
+// NewFeatureFlagHandler creates a new feature flag handler
+func NewFeatureFlagHandler(featureFlagService *service.FeatureFlagService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		featureFlagService: featureFlagService,
+	}
+}
+
+// Register sets up routes for the feature flag handler
+func (h *FeatureFlagHandler) Register(router *gin.RouterGroup) {
+	flags := router.Group("/admin/feature-flags", middleware.RequireAdmin())
+	{
+		flags.GET("", h.List)
+		flags.GET("/:key", h.GetByKey)
+		flags.PUT("/:key", h.Set)
+		flags.DELETE("/:key", h.Delete)
+	}
+}
+
+// List handles retrieving every feature flag
+func (h *FeatureFlagHandler) List(c *gin.Context) {
+	flags, err := h.featureFlagService.ListFlags(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, flags)
+}
+
+// GetByKey handles retrieving a feature flag by key
+func (h *FeatureFlagHandler) GetByKey(c *gin.Context) {
+	flag, err := h.featureFlagService.GetFlag(c.Request.Context(), c.Param("key"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, flag)
+}
+
+// Set handles toggling a feature flag or changing its rollout
+func (h *FeatureFlagHandler) Set(c *gin.Context) {
+	var setFeatureFlagDTO dtos.SetFeatureFlagDTO
+	if err := c.ShouldBindJSON(&setFeatureFlagDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	flag, err := h.featureFlagService.SetFlag(c.Request.Context(), c.Param("key"), setFeatureFlagDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, flag)
+}
+
+// Delete handles removing a feature flag set through the admin API
+func (h *FeatureFlagHandler) Delete(c *gin.Context) {
+	if err := h.featureFlagService.DeleteFlag(c.Request.Context(), c.Param("key")); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
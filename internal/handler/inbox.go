@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/models"
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InboxHandler handles HTTP requests related to the current user's notifications
+type InboxHandler struct {
+	inboxService *service.InboxService
+}
+
+// NewInboxHandler creates a new inbox handler
+func NewInboxHandler(inboxService *service.InboxService) *InboxHandler {
+	return &InboxHandler{
+		inboxService: inboxService,
+	}
+}
+
+// inboxPage is a page of the inbox along with how many notifications are unread
+type inboxPage struct {
+	Notifications []*models.Notification `json:"notifications"`
+	UnreadCount   int64                  `json:"unread_count"`
+}
+
+// Register sets up routes for the inbox handler
+func (h *InboxHandler) Register(router *gin.RouterGroup) {
+	me := router.Group("/me", middleware.NoStore(), middleware.RequireUser())
+	{
+		me.GET("/notifications", h.List)
+		me.POST("/notifications/:id/read", h.MarkRead)
+		me.POST("/notifications/read-all", h.MarkAllRead)
+		me.GET("/notification-preferences", h.GetPreferences)
+		me.PUT("/notification-preferences", h.UpdatePreferences)
+	}
+}
+
+// List handles retrieving the user's notifications with pagination, only the unread ones when unread=true
+func (h *InboxHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+	unreadOnly, _ := strconv.ParseBool(c.DefaultQuery("unread", "false"))
+
+	notifications, total, unread, err := h.inboxService.ListNotifications(c.Request.Context(), middleware.UserID(c), unreadOnly, page, pageSize)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, inboxPage{
+		Notifications: notifications,
+		UnreadCount:   unread,
+	}, page, pageSize, total)
+}
+
+// MarkRead handles marking one of the user's notifications as read
+func (h *InboxHandler) MarkRead(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid notification ID"))
+		return
+	}
+
+	notification, err := h.inboxService.MarkRead(c.Request.Context(), middleware.UserID(c), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, notification)
+}
+
+// MarkAllRead handles marking all of the user's notifications as read
+func (h *InboxHandler) MarkAllRead(c *gin.Context) {
+	count, err := h.inboxService.MarkAllRead(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, gin.H{"marked_read": count})
+}
+
+// GetPreferences handles retrieving the user's notification preferences
+func (h *InboxHandler) GetPreferences(c *gin.Context) {
+	preferences, err := h.inboxService.GetPreferences(c.Request.Context(), middleware.UserID(c))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, preferences)
+}
+
+// UpdatePreferences handles opting the user in or out of notifications
+func (h *InboxHandler) UpdatePreferences(c *gin.Context) {
+	var updateDTO dtos.UpdateNotificationPreferencesDTO
+	if err := c.ShouldBindJSON(&updateDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	preferences, err := h.inboxService.UpdatePreferences(c.Request.Context(), middleware.UserID(c), updateDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, preferences)
+}
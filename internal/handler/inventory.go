@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InventoryHandler handles HTTP requests related to stock
+type InventoryHandler struct {
+	inventoryService *service.InventoryService
+}
+
+// NewInventoryHandler creates a new inventory handler
+func NewInventoryHandler(inventoryService *service.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// Register sets up routes for the inventory handler
+func (h *InventoryHandler) Register(router *gin.RouterGroup) {
+	router.GET("/products/:id/stock-history", h.StockHistory)
+
+	admin := router.Group("/admin/products", middleware.RequireAdmin())
+	{
+		admin.POST("/:id/stock-adjustments", h.AdjustStock)
+	}
+
+	inventory := router.Group("/admin/inventory", middleware.RequireAdmin())
+	{
+		inventory.GET("/low-stock", h.LowStock)
+	}
+}
+
+// StockHistory handles retrieving a product's stock movements
+func (h *InventoryHandler) StockHistory(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid product ID"))
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	movements, total, err := h.inventoryService.StockHistory(c.Request.Context(), id, page, pageSize)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, movements, page, pageSize, total)
+}
+
+// AdjustStock handles a manual stock adjustment
+func (h *InventoryHandler) AdjustStock(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid product ID"))
+		return
+	}
+
+	var adjustmentDTO dtos.StockAdjustmentDTO
+	if err := c.ShouldBindJSON(&adjustmentDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+	adjustmentDTO.ActorID = actorID(c)
+
+	movement, err := h.inventoryService.AdjustStock(c.Request.Context(), id, adjustmentDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, movement)
+}
+
+// LowStock handles listing products that need to be reordered
+func (h *InventoryHandler) LowStock(c *gin.Context) {
+	items, err := h.inventoryService.LowStockReport(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, items)
+}
+
+// actorID returns the authenticated user recorded as the cause of a stock movement
+func actorID(c *gin.Context) *int {
+	userID := middleware.UserID(c)
+	if userID == 0 {
+		return nil
+	}
+	return &userID
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/service"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler handles HTTP requests related to scheduled worker jobs
+type JobHandler struct {
+	jobService *service.JobService
+}
+
+// NewJobHandler creates a new job handler
+func NewJobHandler(jobService *service.JobService) *JobHandler {
+	return &JobHandler{
+		jobService: jobService,
+	}
+}
+
+// Register sets up routes for the job handler
+func (h *JobHandler) Register(router *gin.RouterGroup) {
+	jobs := router.Group("/admin/jobs", middleware.RequireAdmin())
+	{
+		jobs.GET("/runs", h.ListRuns)
+	}
+}
+
+// ListRuns handles retrieving the run history of scheduled jobs, filtered by the job query parameter
+func (h *JobHandler) ListRuns(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	runs, total, err := h.jobService.ListRuns(c.Request.Context(), c.Query("job"), page, pageSize)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, runs, page, pageSize, total)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"ecom-go/internal/middleware"
 	"ecom-go/internal/service"
 	"ecom-go/pkg/errors"
 	"ecom-go/pkg/http/response"
@@ -23,11 +24,18 @@ func NewOrderHandler(orderService *service.OrderService) *OrderHandler {
 }
 
 func (h *OrderHandler) Register(router *gin.RouterGroup) {
-	orders := router.Group("/orders")
+	orders := router.Group("/orders", middleware.RequireUser())
 	{
 		orders.POST("", h.CreateOrder)
 		orders.GET("", h.ListOrders)
 		orders.GET("/:id", h.GetOrder)
+		orders.POST("/:id/pay", h.PayOrder)
+	}
+
+	admin := router.Group("/admin/orders", middleware.RequireAdmin())
+	{
+		admin.GET("", h.ListAllOrders)
+		admin.PATCH("/:id/status", h.UpdateOrderStatus)
 	}
 }
 
@@ -37,6 +45,7 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		response.Error(c, errors.NewBadRequestError("Invalid request payload", err))
 		return
 	}
+	createOrderDTO.UserID = middleware.UserID(c)
 
 	order, err := h.orderService.CreateOrder(c.Request.Context(), &createOrderDTO)
 	if err != nil {
@@ -48,8 +57,18 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 }
 
 func (h *OrderHandler) ListOrders(c *gin.Context) {
+	h.listOrders(c, middleware.UserID(c))
+}
+
+func (h *OrderHandler) ListAllOrders(c *gin.Context) {
+	h.listOrders(c, 0)
+}
 
-	orders, err := h.orderService.ListOrders(c.Request.Context(), 0, 10)
+func (h *OrderHandler) listOrders(c *gin.Context, userID int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	orders, err := h.orderService.ListOrders(c.Request.Context(), userID, page, pageSize)
 	if err != nil {
 		response.Error(c, err)
 		return
@@ -70,6 +89,48 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		response.Error(c, err)
 		return
 	}
+	if order.UserID != middleware.UserID(c) && !middleware.IsAdmin(c) {
+		response.Error(c, errors.NewNotFoundError("order not found"))
+		return
+	}
+
+	response.Success(c, http.StatusOK, order)
+}
+
+func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("Invalid order ID", err))
+		return
+	}
+
+	var updateOrderStatusDTO dtos.UpdateOrderStatusDTO
+	if err := c.ShouldBindJSON(&updateOrderStatusDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("Invalid request payload", err))
+		return
+	}
+
+	order, err := h.orderService.UpdateOrderStatus(c.Request.Context(), orderID, updateOrderStatusDTO.Status)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, order)
+}
+
+func (h *OrderHandler) PayOrder(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("Invalid order ID", err))
+		return
+	}
+
+	order, err := h.orderService.PayOrder(c.Request.Context(), orderID, middleware.UserID(c))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
 
 	response.Success(c, http.StatusOK, order)
 }
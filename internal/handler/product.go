@@ -2,8 +2,10 @@ package handler
 
 import (
 	"ecom-go/internal/dtos"
+	"ecom-go/internal/middleware"
 	"net/http"
 	"strconv"
+	"time"
 
 	"ecom-go/internal/service"
 	"ecom-go/pkg/errors"
@@ -22,12 +24,21 @@ func NewProductHandler(productService *service.ProductService) *ProductHandler {
 	}
 }
 
+// catalogCachePolicy lets storefronts and CDNs reuse catalog responses briefly,
+// stock levels in them must not go stale for long
+var catalogCachePolicy = middleware.CachePolicy{
+	MaxAge:               30 * time.Second,
+	SharedMaxAge:         60 * time.Second,
+	StaleWhileRevalidate: 30 * time.Second,
+	Vary:                 []string{"Accept-Encoding"},
+}
+
 func (h *ProductHandler) Register(router *gin.RouterGroup) {
 	products := router.Group("/products")
 	{
 		products.POST("", h.Create)
-		products.GET("", h.List)
-		products.GET("/:id", h.GetByID)
+		products.GET("", middleware.HTTPCache(catalogCachePolicy), h.List)
+		products.GET("/:id", middleware.HTTPCache(catalogCachePolicy), h.GetByID)
 		products.PUT("/:id", h.Update)
 		products.DELETE("/:id", h.Delete)
 	}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"ecom-go/internal/dtos"
+	"net/http"
+
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReservationHandler handles HTTP requests related to stock reservations
+type ReservationHandler struct {
+	reservationService *service.ReservationService
+}
+
+// NewReservationHandler creates a new reservation handler
+func NewReservationHandler(reservationService *service.ReservationService) *ReservationHandler {
+	return &ReservationHandler{
+		reservationService: reservationService,
+	}
+}
+
+// Register sets up routes for the reservation handler
+func (h *ReservationHandler) Register(router *gin.RouterGroup) {
+	carts := router.Group("/carts")
+	{
+		carts.PUT("/:id/reservations", h.Reserve)
+		carts.GET("/:id/reservations", h.List)
+		carts.DELETE("/:id/reservations", h.Release)
+	}
+}
+
+// Reserve handles holding stock for a cart
+func (h *ReservationHandler) Reserve(c *gin.Context) {
+	var reserveCartDTO dtos.ReserveCartDTO
+	if err := c.ShouldBindJSON(&reserveCartDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+	reserveCartDTO.UserID = middleware.UserID(c)
+
+	reservations, err := h.reservationService.ReserveCart(c.Request.Context(), c.Param("id"), reserveCartDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, reservations)
+}
+
+// List handles retrieving the stock held for a cart
+func (h *ReservationHandler) List(c *gin.Context) {
+	reservations, err := h.reservationService.GetCartReservations(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, reservations)
+}
+
+// Release handles giving back the stock held for a cart
+func (h *ReservationHandler) Release(c *gin.Context) {
+	if err := h.reservationService.ReleaseCart(c.Request.Context(), c.Param("id")); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"ecom-go/internal/dtos"
+	"net/http"
+	"strconv"
+
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReturnHandler handles HTTP requests related to returns
+type ReturnHandler struct {
+	returnService *service.ReturnService
+}
+
+// NewReturnHandler creates a new return handler
+func NewReturnHandler(returnService *service.ReturnService) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+	}
+}
+
+// Register sets up routes for the return handler
+func (h *ReturnHandler) Register(router *gin.RouterGroup) {
+	returns := router.Group("/returns", middleware.RequireUser())
+	{
+		returns.POST("", h.Create)
+		returns.GET("", h.List)
+		returns.GET("/:id", h.GetByID)
+	}
+
+	admin := router.Group("/admin/returns", middleware.RequireAdmin())
+	{
+		admin.GET("", h.ListAll)
+		admin.POST("/:id/approve", h.Approve)
+		admin.POST("/:id/reject", h.Reject)
+		admin.POST("/:id/receive", h.Receive)
+	}
+}
+
+// Create handles requesting a return
+func (h *ReturnHandler) Create(c *gin.Context) {
+	var createReturnDTO dtos.CreateReturnDTO
+	if err := c.ShouldBindJSON(&createReturnDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+	createReturnDTO.UserID = middleware.UserID(c)
+
+	returnRequest, err := h.returnService.RequestReturn(c.Request.Context(), createReturnDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, returnRequest)
+}
+
+// List handles retrieving the caller's return requests with pagination
+func (h *ReturnHandler) List(c *gin.Context) {
+	h.list(c, middleware.UserID(c))
+}
+
+// ListAll handles retrieving every user's return requests with pagination
+func (h *ReturnHandler) ListAll(c *gin.Context) {
+	h.list(c, 0)
+}
+
+func (h *ReturnHandler) list(c *gin.Context, userID int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	returnRequests, err := h.returnService.ListReturns(c.Request.Context(), userID, page, pageSize)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, returnRequests)
+}
+
+// GetByID handles retrieving a return request by ID
+func (h *ReturnHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid return ID"))
+		return
+	}
+
+	returnRequest, err := h.returnService.GetReturn(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	if returnRequest.UserID != middleware.UserID(c) && !middleware.IsAdmin(c) {
+		response.Error(c, errors.NewNotFoundError("return request not found"))
+		return
+	}
+
+	response.Success(c, http.StatusOK, returnRequest)
+}
+
+// Approve handles accepting a return request
+func (h *ReturnHandler) Approve(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid return ID"))
+		return
+	}
+
+	returnRequest, err := h.returnService.ApproveReturn(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, returnRequest)
+}
+
+// Reject handles declining a return request
+func (h *ReturnHandler) Reject(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid return ID"))
+		return
+	}
+
+	var rejectReturnDTO dtos.RejectReturnDTO
+	if err := c.ShouldBindJSON(&rejectReturnDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	returnRequest, err := h.returnService.RejectReturn(c.Request.Context(), id, rejectReturnDTO.Reason)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, returnRequest)
+}
+
+// Receive handles the arrival of returned goods, which restocks and refunds them
+func (h *ReturnHandler) Receive(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid return ID"))
+		return
+	}
+
+	returnRequest, err := h.returnService.ReceiveReturn(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, returnRequest)
+}
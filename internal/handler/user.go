@@ -4,35 +4,94 @@ import (
 	"ecom-go/internal/dtos"
 	"net/http"
 	"strconv"
+	"time"
 
+	"ecom-go/internal/auth"
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/models"
 	"ecom-go/internal/service"
 	"ecom-go/pkg/errors"
 	"ecom-go/pkg/http/response"
 	"github.com/gin-gonic/gin"
 )
 
+// signupRateLimit keeps sign-ups from being scripted, on top of the API's limits
+var signupRateLimit = middleware.RateLimitPolicy{
+	PerIP:     middleware.Limit{Requests: 10, Period: time.Hour, Burst: 3},
+	PerUser:   middleware.Limit{Requests: 10, Period: time.Hour, Burst: 3},
+	PerAPIKey: middleware.Limit{Requests: 100, Period: time.Hour},
+}
+
+// loginRateLimit slows down password guessing, on top of the API's limits
+var loginRateLimit = middleware.RateLimitPolicy{
+	PerIP: middleware.Limit{Requests: 10, Period: time.Minute, Burst: 5},
+}
+
 // UserHandler handles HTTP requests related to users
 type UserHandler struct {
 	userService *service.UserService
+	tokens      *auth.Tokens
+	limiter     middleware.RateLimiter
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService *service.UserService) *UserHandler {
+func NewUserHandler(userService *service.UserService, tokens *auth.Tokens, limiter middleware.RateLimiter) *UserHandler {
 	return &UserHandler{
 		userService: userService,
+		tokens:      tokens,
+		limiter:     limiter,
 	}
 }
 
+// accessToken is the token a user signed in with, sent as "Authorization: Bearer <token>"
+type accessToken struct {
+	Token     string       `json:"token"`
+	TokenType string       `json:"token_type"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	User      *models.User `json:"user"`
+}
+
 // Register sets up routes for the user handler
 func (h *UserHandler) Register(router *gin.RouterGroup) {
+	router.POST("/auth/login", middleware.NoStore(), middleware.RateLimit(h.limiter, "login", loginRateLimit), h.Login)
+
 	users := router.Group("/users")
 	{
-		users.POST("", h.Create)
-		users.GET("", h.List)
-		users.GET("/:id", h.GetByID)
-		users.PUT("/:id", h.Update)
-		users.DELETE("/:id", h.Delete)
+		users.POST("", middleware.RateLimit(h.limiter, "signup", signupRateLimit), h.Create)
+		users.GET("", middleware.RequireAdmin(), h.List)
+		users.GET("/:id", middleware.RequireUser(), h.GetByID)
+		users.PUT("/:id", middleware.RequireUser(), h.Update)
+		users.DELETE("/:id", middleware.RequireAdmin(), h.Delete)
+	}
+}
+
+// Login handles signing in with an email and password, returning an access token
+func (h *UserHandler) Login(c *gin.Context) {
+	var loginDTO dtos.LoginDTO
+	if err := c.ShouldBindJSON(&loginDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	user, err := h.userService.Authenticate(c.Request.Context(), loginDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	token, expiresAt, err := h.tokens.Issue(user.ID, user.Role)
+	if err != nil {
+		response.Error(c, errors.NewServerError("error issuing access token", err))
+		return
 	}
+
+	response.Success(c, http.StatusOK, accessToken{Token: token, TokenType: "Bearer", ExpiresAt: expiresAt, User: user})
+}
+
+// canAccessUser reports whether the caller may see or change the user, only
+// admins can for users other than themselves
+func canAccessUser(c *gin.Context, id uint64) bool {
+	return uint64(middleware.UserID(c)) == id || middleware.IsAdmin(c)
 }
 
 // Create handles user creation
@@ -59,6 +118,10 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 		response.Error(c, errors.NewBadRequestError("invalid user ID"))
 		return
 	}
+	if !canAccessUser(c, id) {
+		response.Error(c, errors.NewForbiddenError("not allowed to access this user"))
+		return
+	}
 
 	user, err := h.userService.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
@@ -76,6 +139,10 @@ func (h *UserHandler) Update(c *gin.Context) {
 		response.Error(c, errors.NewBadRequestError("invalid user ID"))
 		return
 	}
+	if !canAccessUser(c, id) {
+		response.Error(c, errors.NewForbiddenError("not allowed to access this user"))
+		return
+	}
 
 	var updateUserDTO dtos.UpdateUserDTO
 	if err := c.ShouldBindJSON(&updateUserDTO); err != nil {
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/middleware"
+	"net/http"
+	"strconv"
+
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WarehouseHandler handles HTTP requests related to warehouses
+type WarehouseHandler struct {
+	warehouseService *service.WarehouseService
+}
+
+// NewWarehouseHandler creates a new warehouse handler
+func NewWarehouseHandler(warehouseService *service.WarehouseService) *WarehouseHandler {
+	return &WarehouseHandler{
+		warehouseService: warehouseService,
+	}
+}
+
+// Register sets up routes for the warehouse handler
+func (h *WarehouseHandler) Register(router *gin.RouterGroup) {
+	warehouses := router.Group("/admin/warehouses", middleware.RequireAdmin())
+	{
+		warehouses.POST("", h.Create)
+		warehouses.GET("", h.List)
+		warehouses.GET("/:id", h.GetByID)
+		warehouses.PUT("/:id", h.Update)
+		warehouses.GET("/:id/stock", h.StockLevels)
+		warehouses.POST("/transfers", h.Transfer)
+	}
+}
+
+// Create handles warehouse creation
+func (h *WarehouseHandler) Create(c *gin.Context) {
+	var createWarehouseDTO dtos.CreateWarehouseDTO
+	if err := c.ShouldBindJSON(&createWarehouseDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	warehouse, err := h.warehouseService.CreateWarehouse(c.Request.Context(), createWarehouseDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, warehouse)
+}
+
+// List handles retrieving all warehouses
+func (h *WarehouseHandler) List(c *gin.Context) {
+	warehouses, err := h.warehouseService.ListWarehouses(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, warehouses)
+}
+
+// GetByID handles retrieving a warehouse by ID
+func (h *WarehouseHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid warehouse ID"))
+		return
+	}
+
+	warehouse, err := h.warehouseService.GetWarehouse(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, warehouse)
+}
+
+// Update handles updating a warehouse
+func (h *WarehouseHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid warehouse ID"))
+		return
+	}
+
+	var updateWarehouseDTO dtos.UpdateWarehouseDTO
+	if err := c.ShouldBindJSON(&updateWarehouseDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	warehouse, err := h.warehouseService.UpdateWarehouse(c.Request.Context(), id, updateWarehouseDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, warehouse)
+}
+
+// StockLevels handles retrieving the stock levels of a warehouse
+func (h *WarehouseHandler) StockLevels(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid warehouse ID"))
+		return
+	}
+
+	levels, err := h.warehouseService.StockLevels(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, levels)
+}
+
+// Transfer handles moving stock between warehouses
+func (h *WarehouseHandler) Transfer(c *gin.Context) {
+	var transferDTO dtos.StockTransferDTO
+	if err := c.ShouldBindJSON(&transferDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+	transferDTO.ActorID = actorID(c)
+
+	if err := h.warehouseService.TransferStock(c.Request.Context(), transferDTO); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
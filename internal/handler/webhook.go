@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/middleware"
+	"ecom-go/internal/models"
+	"ecom-go/internal/service"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler handles HTTP requests related to partner webhook subscriptions
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler
+func NewWebhookHandler(webhookService *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// createdWebhook is a new webhook subscription along with its secret, which is only shown once
+type createdWebhook struct {
+	*models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// Register sets up routes for the webhook handler
+func (h *WebhookHandler) Register(router *gin.RouterGroup) {
+	webhooks := router.Group("/admin/webhooks", middleware.RequireAdmin())
+	{
+		webhooks.POST("", h.Create)
+		webhooks.GET("", h.List)
+		webhooks.GET("/:id", h.GetByID)
+		webhooks.PUT("/:id", h.Update)
+		webhooks.DELETE("/:id", h.Delete)
+		webhooks.GET("/:id/deliveries", h.ListDeliveries)
+	}
+
+	deliveries := router.Group("/admin/webhook-deliveries", middleware.RequireAdmin())
+	{
+		deliveries.POST("/:id/redeliver", h.Redeliver)
+	}
+}
+
+// Create handles subscribing an endpoint to webhooks
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var createWebhookDTO dtos.CreateWebhookDTO
+	if err := c.ShouldBindJSON(&createWebhookDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	subscription, secret, err := h.webhookService.CreateWebhook(c.Request.Context(), createWebhookDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, createdWebhook{WebhookSubscription: subscription, Secret: secret})
+}
+
+// List handles retrieving webhook subscriptions with pagination
+func (h *WebhookHandler) List(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	subscriptions, total, err := h.webhookService.ListWebhooks(c.Request.Context(), page, pageSize)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, subscriptions, page, pageSize, total)
+}
+
+// GetByID handles retrieving a webhook subscription by ID
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid webhook ID"))
+		return
+	}
+
+	subscription, err := h.webhookService.GetWebhook(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, subscription)
+}
+
+// Update handles updating a webhook subscription, including enabling it again
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid webhook ID"))
+		return
+	}
+
+	var updateWebhookDTO dtos.UpdateWebhookDTO
+	if err := c.ShouldBindJSON(&updateWebhookDTO); err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid input", err))
+		return
+	}
+
+	subscription, err := h.webhookService.UpdateWebhook(c.Request.Context(), id, updateWebhookDTO)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, subscription)
+}
+
+// Delete handles removing a webhook subscription
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid webhook ID"))
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ListDeliveries handles retrieving a webhook subscription's delivery log with pagination
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid webhook ID"))
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), id, page, pageSize)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.SuccessWithPagination(c, http.StatusOK, deliveries, page, pageSize, total)
+}
+
+// Redeliver handles sending the event of a logged delivery to its endpoint again
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.Error(c, errors.NewBadRequestError("invalid delivery ID"))
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, delivery)
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"strings"
+
+	"ecom-go/internal/auth"
+	"ecom-go/internal/models"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+	"ecom-go/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries the API key of partners calling the API
+const APIKeyHeader = "X-API-Key"
+
+// Gin context keys of the authenticated user and partner
+const (
+	userIDKey = "user_id"
+	roleKey   = "role"
+	apiKeyKey = "api_key"
+)
+
+// Authenticate is a middleware that identifies the user of the bearer access
+// token in the Authorization header, and the partner of the API key in the
+// X-API-Key header. Requests without either go on anonymously, those with an
+// invalid or expired one are rejected
+func Authenticate(tokens *auth.Tokens, apiKeys *auth.APIKeys) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			name, ok := apiKeys.Verify(key)
+			if !ok {
+				response.Error(c, errors.NewUnauthorizedError("invalid API key"))
+				c.Abort()
+				return
+			}
+			c.Set(apiKeyKey, name)
+			c.Request = c.Request.WithContext(logger.WithFields(c.Request.Context(), "api_key", name))
+		}
+
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			response.Error(c, errors.NewUnauthorizedError("the Authorization header must hold a bearer token"))
+			c.Abort()
+			return
+		}
+		claims, err := tokens.Verify(token)
+		if err != nil {
+			response.Error(c, errors.NewUnauthorizedError("invalid or expired access token", err))
+			c.Abort()
+			return
+		}
+
+		c.Set(userIDKey, int(claims.UserID))
+		c.Set(roleKey, claims.Role)
+		c.Request = c.Request.WithContext(logger.WithFields(c.Request.Context(), "user_id", claims.UserID))
+		c.Next()
+	}
+}
+
+// RequireUser is a middleware that rejects requests not made by a signed in user
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if UserID(c) == 0 {
+			response.Error(c, errors.NewUnauthorizedError("sign in required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin is a middleware that rejects requests not made by a signed in admin
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if UserID(c) == 0 {
+			response.Error(c, errors.NewUnauthorizedError("sign in required"))
+			c.Abort()
+			return
+		}
+		if !IsAdmin(c) {
+			response.Error(c, errors.NewForbiddenError("admin role required"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// UserID returns the ID of the signed in user making the request, 0 for anonymous requests
+func UserID(c *gin.Context) int {
+	return c.GetInt(userIDKey)
+}
+
+// APIKeyName returns the name of the partner's API key the request was made
+// with, empty for requests without one
+func APIKeyName(c *gin.Context) string {
+	return c.GetString(apiKeyKey)
+}
+
+// IsAdmin reports whether the request is made by a signed in admin
+func IsAdmin(c *gin.Context) bool {
+	return c.GetString(roleKey) == models.RoleAdmin
+}
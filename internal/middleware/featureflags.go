@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"strconv"
+
+	"ecom-go/internal/featureflags"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureFlags is a middleware that lets handlers and services check feature
+// flags with featureflags.Enabled on the request context. Flags are evaluated
+// for the user authenticated by the access token, with the token's role as the
+// "role" attribute, or for an anonymous user without a token. It runs after
+// Authenticate
+func FeatureFlags(evaluator *featureflags.Evaluator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var subject featureflags.Subject
+		if userID := UserID(c); userID != 0 {
+			subject.UserID = strconv.Itoa(userID)
+			subject.Attributes = map[string]string{"role": c.GetString(roleKey)}
+		}
+
+		c.Request = c.Request.WithContext(featureflags.WithSubject(c.Request.Context(), evaluator, subject))
+		c.Next()
+	}
+}
@@ -0,0 +1,174 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CachePolicy describes how clients and shared caches such as CDNs may cache a route's responses
+type CachePolicy struct {
+	// MaxAge is how long clients may reuse a response without revalidating it
+	MaxAge time.Duration
+	// SharedMaxAge overrides MaxAge for shared caches when set
+	SharedMaxAge time.Duration
+	// StaleWhileRevalidate is how long a stale response may be served while it is revalidated
+	StaleWhileRevalidate time.Duration
+	// Private keeps responses out of shared caches, for user-specific content
+	Private bool
+	// Vary lists the request headers responses depend on
+	Vary []string
+}
+
+// cacheControl returns the Cache-Control header value of the policy
+func (p CachePolicy) cacheControl() string {
+	directives := []string{"public"}
+	if p.Private {
+		directives = []string{"private"}
+	}
+	directives = append(directives, "max-age="+seconds(p.MaxAge))
+	if p.SharedMaxAge > 0 && !p.Private {
+		directives = append(directives, "s-maxage="+seconds(p.SharedMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(p.StaleWhileRevalidate))
+	}
+	return strings.Join(directives, ", ")
+}
+
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(d / time.Second))
+}
+
+// bufferedWriter holds back the response body so that its ETag can be
+// computed before anything is sent
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+// WriteHeader records the status code, it is sent along with the body
+func (w *bufferedWriter) WriteHeader(code int) {
+	w.status = code
+}
+
+// WriteHeaderNow does nothing, the header is sent along with the body
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+// Write buffers the response body
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// WriteString buffers the response body
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// Status returns the recorded status code
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+// Size returns the size of the buffered body
+func (w *bufferedWriter) Size() int {
+	return w.body.Len()
+}
+
+// Written reports whether anything was written
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0
+}
+
+// HTTPCache is a middleware that makes successful GET responses cacheable
+// according to the policy. Responses get a strong ETag, the one a handler set
+// from its entity's version or else a hash of the body, and requests
+// whose If-None-Match matches it are answered with 304 Not Modified.
+// Requests made on behalf of a user are only ever cached privately.
+func HTTPCache(policy CachePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = original
+
+		header := original.Header()
+		if writer.status != http.StatusOK {
+			original.WriteHeader(writer.status)
+			original.Write(writer.body.Bytes())
+			return
+		}
+
+		// The policy is shared by every request of the route, so it is changed on a copy
+		p := policy
+		if isUserRequest(c.Request) {
+			p.Private = true
+		}
+		header.Set("Cache-Control", p.cacheControl())
+		for _, vary := range p.Vary {
+			header.Add("Vary", vary)
+		}
+
+		etag := header.Get("ETag")
+		if etag == "" {
+			sum := sha256.Sum256(writer.body.Bytes())
+			etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+			header.Set("ETag", etag)
+		}
+
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+
+		original.WriteHeader(http.StatusOK)
+		original.Write(writer.body.Bytes())
+	}
+}
+
+// NoStore is a middleware that keeps responses out of every cache
+func NoStore() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "private, no-store")
+		c.Next()
+	}
+}
+
+// isUserRequest reports whether the request is made on behalf of a user,
+// whose responses may be specific to them
+func isUserRequest(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != "" || r.Header.Get(APIKeyHeader) != ""
+}
+
+// etagMatches implements the weak comparison If-None-Match calls for
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
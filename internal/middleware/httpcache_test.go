@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newCachedRouter(policy CachePolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/products", HTTPCache(policy), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"name": "lamp"})
+	})
+	router.GET("/missing", HTTPCache(policy), func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	})
+	return router
+}
+
+func TestHTTPCache(t *testing.T) {
+	policy := CachePolicy{
+		MaxAge:               30 * time.Second,
+		SharedMaxAge:         60 * time.Second,
+		StaleWhileRevalidate: 30 * time.Second,
+		Vary:                 []string{"Accept-Encoding"},
+	}
+	const public = "public, max-age=30, s-maxage=60, stale-while-revalidate=30"
+	const private = "private, max-age=30, stale-while-revalidate=30"
+
+	// The requests are sent in order to the same route
+	tests := []struct {
+		name             string
+		path             string
+		headers          map[string]string
+		wantStatus       int
+		wantCacheControl string
+	}{
+		{"anonymous", "/products", nil, http.StatusOK, public},
+		{"user", "/products", map[string]string{"Authorization": "Bearer token"}, http.StatusOK, private},
+		{"anonymous after a user", "/products", nil, http.StatusOK, public},
+		{"cookie", "/products", map[string]string{"Cookie": "session=1"}, http.StatusOK, private},
+		{"API key", "/products", map[string]string{APIKeyHeader: "key"}, http.StatusOK, private},
+		{"anonymous again", "/products", nil, http.StatusOK, public},
+		{"errors are not cached", "/missing", nil, http.StatusNotFound, ""},
+	}
+
+	router := newCachedRouter(policy)
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		for name, value := range tt.headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get("Cache-Control"); got != tt.wantCacheControl {
+			t.Errorf("%s: Cache-Control = %q, want %q", tt.name, got, tt.wantCacheControl)
+		}
+	}
+	if policy.Private {
+		t.Error("the route's policy was changed")
+	}
+}
+
+func TestHTTPCacheNotModified(t *testing.T) {
+	router := newCachedRouter(CachePolicy{MaxAge: time.Minute})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("no ETag")
+	}
+
+	tests := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{etag, http.StatusNotModified},
+		{"W/" + etag, http.StatusNotModified},
+		{`"other", ` + etag, http.StatusNotModified},
+		{"*", http.StatusNotModified},
+		{`"other"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/products", nil)
+		req.Header.Set("If-None-Match", tt.ifNoneMatch)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("If-None-Match %s: status = %d, want %d", tt.ifNoneMatch, w.Code, tt.want)
+		}
+		if tt.want == http.StatusNotModified && w.Body.Len() > 0 {
+			t.Errorf("If-None-Match %s: 304 with a body", tt.ifNoneMatch)
+		}
+	}
+}
+
+// TestHTTPCacheConcurrent is meant for the race detector: user and anonymous
+// requests served at the same time must not share state
+func TestHTTPCacheConcurrent(t *testing.T) {
+	router := newCachedRouter(CachePolicy{MaxAge: time.Minute, SharedMaxAge: time.Minute})
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func(user bool) {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			want := "public, max-age=60, s-maxage=60"
+			if user {
+				req.Header.Set("Authorization", "Bearer token")
+				want = "private, max-age=60"
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if got := w.Header().Get("Cache-Control"); got != want {
+				t.Errorf("Cache-Control = %q, want %q", got, want)
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+}
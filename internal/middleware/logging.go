@@ -3,6 +3,7 @@ package middleware
 import (
 	"bytes"
 	"io"
+	"strings"
 	"time"
 
 	"ecom-go/pkg/logger"
@@ -21,28 +22,43 @@ func (w *responseWriter) Write(b []byte) (int, error) {
 	return w.ResponseWriter.Write(b)
 }
 
-// Logger is a middleware that logs HTTP requests and responses
+// credentialRoutes are the routes whose request or response carries a
+// password, token or signing secret, their bodies are never logged
+var credentialRoutes = map[string][]string{
+	"POST": {"/auth/login", "/users", "/admin/webhooks"},
+	"PUT":  {"/users/:id"},
+}
+
+// Logger is a middleware that logs HTTP requests and responses. The logger of
+// the request context, logger.FromContext, adds the route to the logs written
+// while handling the request, and Authenticate adds the signed in user.
+// Request and response bodies are only logged at the debug level, and never
+// for the credential routes
 func Logger() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Start timer
 		start := time.Now()
 
-		// Read the request body
-		var requestBody []byte
-		if c.Request.Body != nil {
-			requestBody, _ = io.ReadAll(c.Request.Body)
-			c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
-		}
+		c.Request = c.Request.WithContext(logger.WithFields(c.Request.Context(), "route", c.FullPath()))
 
-		// Create a response body buffer
-		responseBodyBuffer := &bytes.Buffer{}
+		logBodies := logger.DebugEnabled() && !isCredentialRoute(c.Request.Method, c.FullPath())
 
-		// Replace the writer with our custom one
-		writer := &responseWriter{
-			ResponseWriter: c.Writer,
-			body:           responseBodyBuffer,
+		// Read the request body
+		var requestBody []byte
+		var responseBodyBuffer *bytes.Buffer
+		if logBodies {
+			if c.Request.Body != nil {
+				requestBody, _ = io.ReadAll(c.Request.Body)
+				c.Request.Body = io.NopCloser(bytes.NewBuffer(requestBody))
+			}
+
+			// Replace the writer with one capturing the response body
+			responseBodyBuffer = &bytes.Buffer{}
+			c.Writer = &responseWriter{
+				ResponseWriter: c.Writer,
+				body:           responseBodyBuffer,
+			}
 		}
-		c.Writer = writer
 
 		// Process request
 		c.Next()
@@ -53,39 +69,52 @@ func Logger() gin.HandlerFunc {
 		// Determine status for logging
 		status := c.Writer.Status()
 
-		// Determine log level based on status code
-		var logFn func(string, ...interface{})
-		if status >= 500 {
-			logFn = logger.Error
-		} else if status >= 400 {
-			logFn = logger.Warn
-		} else {
-			logFn = logger.Info
-		}
-
-		// Truncate request and response bodies if they're too large
-		const maxBodyLogSize = 1024 // 1KB
-
-		requestBodyLog := requestBody
-		if len(requestBodyLog) > maxBodyLogSize {
-			requestBodyLog = requestBodyLog[:maxBodyLogSize]
-		}
-
-		responseBodyLog := responseBodyBuffer.Bytes()
-		if len(responseBodyLog) > maxBodyLogSize {
-			responseBodyLog = responseBodyLog[:maxBodyLogSize]
-		}
-
-		// Log request details
-		logFn("HTTP Request",
+		// Log request details, at a level based on the status code
+		requestLogger := logger.FromContext(c.Request.Context())
+		args := []interface{}{
 			"method", c.Request.Method,
 			"path", c.Request.URL.Path,
 			"status", status,
 			"latency", latency,
 			"client_ip", c.ClientIP(),
 			"user_agent", c.Request.UserAgent(),
-			"request_body", string(requestBodyLog),
-			"response_body", string(responseBodyLog),
-		)
+		}
+		switch {
+		case status >= 500:
+			requestLogger.Error("HTTP Request", args...)
+		case status >= 400:
+			requestLogger.Warn("HTTP Request", args...)
+		default:
+			requestLogger.Info("HTTP Request", args...)
+		}
+
+		if logBodies {
+			requestLogger.Debug("HTTP Request bodies",
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"request_body", truncateBody(requestBody),
+				"response_body", truncateBody(responseBodyBuffer.Bytes()),
+			)
+		}
+	}
+}
+
+// isCredentialRoute reports whether the route, as registered under any prefix,
+// is one of the credential routes
+func isCredentialRoute(method, route string) bool {
+	for _, path := range credentialRoutes[method] {
+		if strings.HasSuffix(route, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateBody returns the start of a body, at most 1KB of it
+func truncateBody(body []byte) string {
+	const maxBodyLogSize = 1024
+	if len(body) > maxBodyLogSize {
+		body = body[:maxBodyLogSize]
 	}
+	return string(body)
 }
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"ecom-go/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoggerBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Logger())
+	api := router.Group("/api/v1")
+	api.POST("/auth/login", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"token": "issued-token"})
+	})
+	api.POST("/orders", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"order_id": 42})
+	})
+
+	tests := []struct {
+		name       string
+		level      string
+		path       string
+		body       string
+		wantBodies bool
+	}{
+		{name: "info", level: "info", path: "/api/v1/orders", body: `{"product_id":10}`},
+		{name: "debug", level: "debug", path: "/api/v1/orders", body: `{"product_id":10}`, wantBodies: true},
+		{name: "debug on a credential route", level: "debug", path: "/api/v1/auth/login", body: `{"password":"hunter2"}`},
+	}
+	defer logger.SetOutput(os.Stdout)
+	defer logger.SetLevel("info")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger.SetOutput(&logs)
+			logger.SetLevel(tt.level)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+
+			if !strings.Contains(logs.String(), "HTTP Request") {
+				t.Fatalf("request not logged: %s", logs.String())
+			}
+			if got := strings.Contains(logs.String(), "request_body"); got != tt.wantBodies {
+				t.Errorf("bodies logged = %t, want %t: %s", got, tt.wantBodies, logs.String())
+			}
+			if strings.Contains(logs.String(), "hunter2") || strings.Contains(logs.String(), "issued-token") {
+				t.Errorf("credentials logged: %s", logs.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/pkg/errors"
+	"ecom-go/pkg/http/response"
+	"ecom-go/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Limit allows Requests per Period, in bursts of up to Burst requests
+type Limit struct {
+	Requests int
+	Period   time.Duration
+	// Burst defaults to Requests
+	Burst int
+}
+
+// enabled reports whether the limit applies
+func (l Limit) enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// rate returns how many requests are allowed per second
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Period.Seconds()
+}
+
+// capacity returns the largest burst of requests allowed
+func (l Limit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// RateLimitResult is the outcome of taking a request from a bucket
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long until a request is allowed again, when it was not
+	RetryAfter time.Duration
+	// ResetAfter is how long until the bucket is full again
+	ResetAfter time.Duration
+}
+
+// RateLimiter keeps token buckets of requests, refilled at a steady rate
+type RateLimiter interface {
+	// Allow takes a request from the bucket under the key
+	Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error)
+}
+
+// NewRateLimiter creates the rate limiter selected in the configuration, or nil when rate limiting is disabled
+func NewRateLimiter(cfg *config.Config) (RateLimiter, error) {
+	if !cfg.RateLimit.Enabled {
+		return nil, nil
+	}
+
+	switch cfg.RateLimit.Backend {
+	case "", "redis":
+		limiter, err := NewRedisRateLimiter(cfg.Redis, cfg.Cache.Prefix)
+		if err != nil {
+			return nil, err
+		}
+		return limiter, nil
+	case "memory":
+		return NewMemoryRateLimiter(), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit backend %q", cfg.RateLimit.Backend)
+	}
+}
+
+// RateLimitPolicy sets the limits of a route group. Every request is limited
+// per client IP, and also per API key when made with a valid one, else per
+// user when made with a valid access token
+type RateLimitPolicy struct {
+	PerIP     Limit
+	PerUser   Limit
+	PerAPIKey Limit
+}
+
+// Policy returns the policy itself, so that fixed policies are policy sources
+func (p RateLimitPolicy) Policy() RateLimitPolicy {
+	return p
+}
+
+// RateLimitPolicySource provides the policy applied to each request
+type RateLimitPolicySource interface {
+	Policy() RateLimitPolicy
+}
+
+// ReloadableRateLimitPolicy is a policy source whose policy can be replaced
+// while requests are served, when the configuration is reloaded
+type ReloadableRateLimitPolicy struct {
+	policy atomic.Pointer[RateLimitPolicy]
+}
+
+// NewReloadableRateLimitPolicy creates a reloadable policy starting with the given one
+func NewReloadableRateLimitPolicy(policy RateLimitPolicy) *ReloadableRateLimitPolicy {
+	p := &ReloadableRateLimitPolicy{}
+	p.Store(policy)
+	return p
+}
+
+// Policy returns the current policy
+func (p *ReloadableRateLimitPolicy) Policy() RateLimitPolicy {
+	return *p.policy.Load()
+}
+
+// Store replaces the policy, buckets keep their tokens
+func (p *ReloadableRateLimitPolicy) Store(policy RateLimitPolicy) {
+	p.policy.Store(&policy)
+}
+
+// DefaultRateLimitPolicy returns the limits configured for the whole API
+func DefaultRateLimitPolicy(cfg config.RateLimitConfig) RateLimitPolicy {
+	return RateLimitPolicy{
+		PerIP:     Limit(cfg.PerIP),
+		PerUser:   Limit(cfg.PerUser),
+		PerAPIKey: Limit(cfg.PerAPIKey),
+	}
+}
+
+// rateLimitBucket is a bucket a request is taken from
+type rateLimitBucket struct {
+	key   string
+	limit Limit
+}
+
+// RateLimit is a middleware that rejects requests over the policy's limits with
+// 429 Too Many Requests. Each named group has its own buckets. It runs after
+// Authenticate, so that only verified API keys and users get their own limit.
+// Requests are let through when the limiter is nil or its backend fails
+func RateLimit(limiter RateLimiter, group string, source RateLimitPolicySource) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		policy := source.Policy()
+
+		buckets := []rateLimitBucket{{"ip:" + c.ClientIP(), policy.PerIP}}
+		if name := APIKeyName(c); name != "" {
+			buckets = append(buckets, rateLimitBucket{"key:" + name, policy.PerAPIKey})
+		} else if userID := UserID(c); userID != 0 {
+			buckets = append(buckets, rateLimitBucket{"user:" + strconv.Itoa(userID), policy.PerUser})
+		}
+
+		// The headers describe the bucket closest to running out
+		var result *RateLimitResult
+		for _, bucket := range buckets {
+			if !bucket.limit.enabled() {
+				continue
+			}
+			bucketResult, err := limiter.Allow(c.Request.Context(), "ratelimit:"+group+":"+bucket.key, bucket.limit)
+			if err != nil {
+				logger.Warn("Rate limiter failed, letting request through", "group", group, "error", err)
+				c.Next()
+				return
+			}
+			if result == nil || !bucketResult.Allowed || (result.Allowed && bucketResult.Remaining < result.Remaining) {
+				result = &bucketResult
+			}
+			if !bucketResult.Allowed {
+				break
+			}
+		}
+		if result == nil {
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			response.Error(c, errors.NewTooManyRequestsError("rate limit exceeded, try again later"))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// takeToken refills a bucket holding tokens as of elapsed ago and takes a
+// request from it, returning the result and the tokens left
+func takeToken(tokens float64, elapsed time.Duration, limit Limit) (RateLimitResult, float64) {
+	tokens = math.Min(float64(limit.capacity()), tokens+math.Max(0, elapsed.Seconds())*limit.rate())
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	return bucketResult(allowed, tokens, limit), tokens
+}
+
+// bucketResult describes a bucket left with tokens after a request was taken from it, or not
+func bucketResult(allowed bool, tokens float64, limit Limit) RateLimitResult {
+	rate := limit.rate()
+	result := RateLimitResult{
+		Allowed:    allowed,
+		Limit:      limit.capacity(),
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: secondsToDuration((float64(limit.capacity()) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return result
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
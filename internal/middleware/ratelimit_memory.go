@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryRateLimiter keeps its buckets in process, for single-node deployments and tests
+type MemoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time // When the bucket is full again and can be forgotten
+}
+
+// NewMemoryRateLimiter creates a new in-memory rate limiter
+func NewMemoryRateLimiter() *MemoryRateLimiter {
+	return &MemoryRateLimiter{
+		buckets: make(map[string]*memoryBucket),
+		now:     time.Now,
+	}
+}
+
+// Allow takes a request from the bucket under the key
+func (l *MemoryRateLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(limit.capacity()), updatedAt: now}
+		l.buckets[key] = bucket
+	}
+
+	result, tokens := takeToken(bucket.tokens, now.Sub(bucket.updatedAt), limit)
+	bucket.tokens = tokens
+	bucket.updatedAt = now
+	bucket.fullAt = now.Add(result.ResetAfter)
+	return result, nil
+}
+
+// sweep forgets the buckets that are full again, at most once a minute. The lock must be held
+func (l *MemoryRateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"strconv"
+
+	"ecom-go/internal/cache"
+	"ecom-go/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// takeTokenScript refills and takes a request from a bucket stored as a hash of
+// its tokens and the time they were counted, using Redis' clock so that
+// replicas agree. It returns whether the request was allowed and the tokens left
+var takeTokenScript = redis.NewScript(`
+local now = redis.call('TIME')
+local now_ms = tonumber(now[1]) * 1000 + math.floor(tonumber(now[2]) / 1000)
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now_ms
+
+tokens = math.min(capacity, tokens + math.max(0, now_ms - ts) / 1000 * rate)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now_ms)
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RedisRateLimiter keeps its buckets in Redis, so that replicas share them
+type RedisRateLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRateLimiter connects to Redis. Keys are stored with the given prefix
+func NewRedisRateLimiter(cfg config.RedisConfig, prefix string) (*RedisRateLimiter, error) {
+	client, err := cache.NewRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisRateLimiter{
+		client: client,
+		prefix: prefix,
+	}, nil
+}
+
+// Allow takes a request from the bucket under the key
+func (l *RedisRateLimiter) Allow(ctx context.Context, key string, limit Limit) (RateLimitResult, error) {
+	values, err := takeTokenScript.Run(ctx, l.client, []string{l.prefix + key}, limit.rate(), limit.capacity()).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	allowed, _ := values[0].(int64)
+	tokens, err := strconv.ParseFloat(values[1].(string), 64)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	return bucketResult(allowed == 1, tokens, limit), nil
+}
+
+// Close closes the connection to Redis
+func (l *RedisRateLimiter) Close() error {
+	return l.client.Close()
+}
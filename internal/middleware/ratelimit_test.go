@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"ecom-go/internal/auth"
+	"ecom-go/internal/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+)
+
+// memoryLimiterAt returns an in-memory limiter whose clock is moved by advance
+func memoryLimiterAt(start time.Time) (*MemoryRateLimiter, func(time.Duration)) {
+	now := start
+	limiter := NewMemoryRateLimiter()
+	limiter.now = func() time.Time { return now }
+	return limiter, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestMemoryRateLimiter(t *testing.T) {
+	// 60 requests per minute is one a second, in bursts of up to 3
+	limit := Limit{Requests: 60, Period: time.Minute, Burst: 3}
+
+	steps := []struct {
+		name          string
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}{
+		{"first request gets a full bucket", 0, true, 2, 0},
+		{"burst", 0, true, 1, 0},
+		{"last of the burst", 0, true, 0, 0},
+		{"empty bucket", 0, false, 0, time.Second},
+		{"partly refilled", 500 * time.Millisecond, false, 0, 500 * time.Millisecond},
+		{"refilled one token", 500 * time.Millisecond, true, 0, 0},
+		{"refilled no more than the burst", time.Hour, true, 2, 0},
+	}
+
+	limiter, advance := memoryLimiterAt(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	for _, step := range steps {
+		advance(step.advance)
+		result, err := limiter.Allow(context.Background(), "ip:192.0.2.1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != step.wantAllowed || result.Remaining != step.wantRemaining || result.RetryAfter != step.wantRetry {
+			t.Errorf("%s: got allowed %v, remaining %d, retry after %s; want %v, %d, %s", step.name,
+				result.Allowed, result.Remaining, result.RetryAfter, step.wantAllowed, step.wantRemaining, step.wantRetry)
+		}
+		if result.Limit != 3 {
+			t.Errorf("%s: limit = %d, want 3", step.name, result.Limit)
+		}
+	}
+
+	// Buckets are independent
+	result, _ := limiter.Allow(context.Background(), "ip:192.0.2.2", limit)
+	if !result.Allowed || result.Remaining != 2 {
+		t.Errorf("other key: got allowed %v, remaining %d; want a full bucket", result.Allowed, result.Remaining)
+	}
+}
+
+func TestRedisRateLimiter(t *testing.T) {
+	server := miniredis.RunT(t)
+	port, _ := strconv.Atoi(server.Port())
+	limiter, err := NewRedisRateLimiter(config.RedisConfig{Host: server.Host(), Port: port}, "test:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer limiter.Close()
+
+	limit := Limit{Requests: 2, Period: time.Minute}
+	for i, wantAllowed := range []bool{true, true, false} {
+		result, err := limiter.Allow(context.Background(), "ip:192.0.2.1", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != wantAllowed {
+			t.Errorf("request %d: allowed = %v, want %v", i+1, result.Allowed, wantAllowed)
+		}
+	}
+	if ttl := server.TTL("test:ip:192.0.2.1"); ttl <= 0 || ttl > 61*time.Second {
+		t.Errorf("bucket expires in %s, want when it is full again", ttl)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const partnerKey = "partner-key"
+	sum := sha256.Sum256([]byte(partnerKey))
+	apiKeys := auth.NewAPIKeys([]config.APIKeyConfig{{Name: "partner", Hash: hex.EncodeToString(sum[:])}})
+	tokens, err := auth.NewTokens(config.AuthConfig{Secret: "0123456789abcdef0123456789abcdef", TokenTTL: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	userToken, _, err := tokens.Issue(7, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	policy := RateLimitPolicy{
+		PerIP:     Limit{Requests: 3, Period: time.Minute},
+		PerUser:   Limit{Requests: 2, Period: time.Minute},
+		PerAPIKey: Limit{Requests: 5, Period: time.Minute},
+	}
+
+	type request struct {
+		ip      string
+		headers map[string]string
+		want    int
+	}
+	tests := []struct {
+		name     string
+		requests []request
+	}{
+		{
+			name: "anonymous requests are limited per IP",
+			requests: []request{
+				{ip: "192.0.2.1", want: http.StatusOK},
+				{ip: "192.0.2.1", want: http.StatusOK},
+				{ip: "192.0.2.1", want: http.StatusOK},
+				{ip: "192.0.2.1", want: http.StatusTooManyRequests},
+				{ip: "192.0.2.2", want: http.StatusOK},
+			},
+		},
+		{
+			name: "X-Forwarded-For of untrusted clients is ignored",
+			requests: []request{
+				{ip: "192.0.2.1", headers: map[string]string{"X-Forwarded-For": "198.51.100.1"}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{"X-Forwarded-For": "198.51.100.2"}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{"X-Forwarded-For": "198.51.100.3"}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{"X-Forwarded-For": "198.51.100.4"}, want: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "unknown API keys are rejected",
+			requests: []request{
+				{ip: "192.0.2.1", headers: map[string]string{APIKeyHeader: "guessed"}, want: http.StatusUnauthorized},
+			},
+		},
+		{
+			name: "spoofed user headers are ignored",
+			requests: []request{
+				{ip: "192.0.2.1", headers: map[string]string{"X-User-ID": "1"}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{"X-User-ID": "2"}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{"X-User-ID": "3"}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{"X-User-ID": "4"}, want: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "users are limited per user across IPs",
+			requests: []request{
+				{ip: "192.0.2.1", headers: map[string]string{"Authorization": "Bearer " + userToken}, want: http.StatusOK},
+				{ip: "192.0.2.2", headers: map[string]string{"Authorization": "Bearer " + userToken}, want: http.StatusOK},
+				{ip: "192.0.2.3", headers: map[string]string{"Authorization": "Bearer " + userToken}, want: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "API keys are limited per IP too",
+			requests: []request{
+				{ip: "192.0.2.1", headers: map[string]string{APIKeyHeader: partnerKey}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{APIKeyHeader: partnerKey}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{APIKeyHeader: partnerKey}, want: http.StatusOK},
+				{ip: "192.0.2.1", headers: map[string]string{APIKeyHeader: partnerKey}, want: http.StatusTooManyRequests},
+				{ip: "192.0.2.2", headers: map[string]string{APIKeyHeader: partnerKey}, want: http.StatusOK},
+				{ip: "192.0.2.3", headers: map[string]string{APIKeyHeader: partnerKey}, want: http.StatusOK},
+				{ip: "192.0.2.4", headers: map[string]string{APIKeyHeader: partnerKey}, want: http.StatusTooManyRequests},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limiter, _ := memoryLimiterAt(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+			router := gin.New()
+			if err := router.SetTrustedProxies(nil); err != nil {
+				t.Fatal(err)
+			}
+			router.Use(Authenticate(tokens, apiKeys), RateLimit(limiter, "test", policy))
+			router.GET("/", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			for i, r := range tt.requests {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = r.ip + ":1234"
+				for name, value := range r.headers {
+					req.Header.Set(name, value)
+				}
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, req)
+				if w.Code != r.want {
+					t.Errorf("request %d: status = %d, want %d", i+1, w.Code, r.want)
+				}
+			}
+		})
+	}
+}
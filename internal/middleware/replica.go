@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// primaryUntilCookie holds the Unix time in milliseconds until which a
+// client's reads go to the primary
+const primaryUntilCookie = "primary_until"
+
+// ReadYourWrites is a middleware that lets a client read its own writes: once
+// a request wrote to the database, its reads go to the primary instead of a
+// replica that may not have the write yet. The client's following requests do
+// too for the window, through a cookie set on the response; with no window,
+// only the request that wrote does.
+func ReadYourWrites(window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := repository.WithReadYourWrites(c.Request.Context())
+		if window > 0 && pinnedByCookie(c, window) {
+			ctx = repository.WithPrimary(c.Request.Context())
+		}
+		c.Request = c.Request.WithContext(ctx)
+		if window <= 0 {
+			c.Next()
+			return
+		}
+
+		writer := &pinningWriter{ResponseWriter: c.Writer, c: c, window: window}
+		c.Writer = writer
+		c.Next()
+		// Handlers that wrote no response leave the header to gin
+		writer.pin()
+	}
+}
+
+// pinnedByCookie reports whether the client wrote within the window. Times
+// further ahead than the window are ignored
+func pinnedByCookie(c *gin.Context, window time.Duration) bool {
+	value, err := c.Cookie(primaryUntilCookie)
+	if err != nil {
+		return false
+	}
+	millis, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return false
+	}
+	until := time.UnixMilli(millis)
+	now := time.Now()
+	return until.After(now) && !until.After(now.Add(window))
+}
+
+// pinningWriter sets the cookie pinning the client to the primary before the
+// response header is sent, when the request wrote to the database
+type pinningWriter struct {
+	gin.ResponseWriter
+	c      *gin.Context
+	window time.Duration
+	pinned bool
+}
+
+// pin sets the cookie if the request wrote and the header can still change
+func (w *pinningWriter) pin() {
+	if w.pinned || w.ResponseWriter.Written() || !repository.Wrote(w.c.Request.Context()) {
+		return
+	}
+	w.pinned = true
+	until := time.Now().Add(w.window)
+	http.SetCookie(w.ResponseWriter, &http.Cookie{
+		Name:     primaryUntilCookie,
+		Value:    strconv.FormatInt(until.UnixMilli(), 10),
+		Path:     "/",
+		MaxAge:   int((w.window + time.Second - 1) / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// WriteHeader sets the cookie before recording the status code
+func (w *pinningWriter) WriteHeader(code int) {
+	w.pin()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// WriteHeaderNow sets the cookie before sending the header
+func (w *pinningWriter) WriteHeaderNow() {
+	w.pin()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Write sets the cookie before sending the header along with the body
+func (w *pinningWriter) Write(b []byte) (int, error) {
+	w.pin()
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString sets the cookie before sending the header along with the body
+func (w *pinningWriter) WriteString(s string) (int, error) {
+	w.pin()
+	return w.ResponseWriter.WriteString(s)
+}
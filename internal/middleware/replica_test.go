@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"ecom-go/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// readYourWritesRouter serves POST /write, which writes through the resolver,
+// and GET /read, which tells whether its reads go to the primary
+func readYourWritesRouter(t *testing.T, window time.Duration) *gin.Engine {
+	t.Helper()
+	sqlDB, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolver := repository.NewResolver(db, nil, time.Minute)
+
+	router := gin.New()
+	router.Use(ReadYourWrites(window))
+	router.POST("/write", func(c *gin.Context) {
+		resolver.Writer(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+	router.GET("/read", func(c *gin.Context) {
+		c.String(http.StatusOK, strconv.FormatBool(repository.ReadsFromPrimary(c.Request.Context())))
+	})
+	return router
+}
+
+func TestReadYourWrites(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const window = 5 * time.Second
+	until := func(d time.Duration) string {
+		return strconv.FormatInt(time.Now().Add(d).UnixMilli(), 10)
+	}
+
+	tests := []struct {
+		name        string
+		window      time.Duration
+		method      string
+		cookie      string
+		wantPrimary bool
+		wantPinned  bool
+	}{
+		{name: "write", window: window, method: http.MethodPost, wantPinned: true},
+		{name: "write without a window", window: 0, method: http.MethodPost},
+		{name: "read", window: window, method: http.MethodGet},
+		{name: "read after a write", window: window, method: http.MethodGet, cookie: until(2 * time.Second), wantPrimary: true},
+		{name: "read after the window", window: window, method: http.MethodGet, cookie: until(-time.Millisecond)},
+		{name: "read beyond the window", window: window, method: http.MethodGet, cookie: until(time.Hour)},
+		{name: "read with an invalid cookie", window: window, method: http.MethodGet, cookie: "soon"},
+		{name: "read without a window", window: 0, method: http.MethodGet, cookie: until(2 * time.Second)},
+		{name: "write after a write", window: window, method: http.MethodPost, cookie: until(time.Second), wantPinned: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := readYourWritesRouter(t, tt.window)
+			path := "/read"
+			if tt.method == http.MethodPost {
+				path = "/write"
+			}
+			req := httptest.NewRequest(tt.method, path, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: primaryUntilCookie, Value: tt.cookie})
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if tt.method == http.MethodGet {
+				if got := w.Body.String(); got != strconv.FormatBool(tt.wantPrimary) {
+					t.Errorf("reads from the primary: %s, want %v", got, tt.wantPrimary)
+				}
+			}
+
+			var pin *http.Cookie
+			for _, cookie := range w.Result().Cookies() {
+				if cookie.Name == primaryUntilCookie {
+					pin = cookie
+				}
+			}
+			if !tt.wantPinned {
+				if pin != nil {
+					t.Errorf("cookie %s set, want none", pin)
+				}
+				return
+			}
+			if pin == nil {
+				t.Fatal("no cookie pinning the client to the primary")
+			}
+			millis, err := strconv.ParseInt(pin.Value, 10, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := time.Until(time.UnixMilli(millis)); d <= window-time.Second || d > window {
+				t.Errorf("pinned for %s, want the window", d)
+			}
+			if pin.MaxAge != 5 || !pin.HttpOnly || pin.Path != "/" {
+				t.Errorf("cookie = %s", pin)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package migrate
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// files returns a file system holding the migrations with the given names,
+// each file containing its own name
+func files(names ...string) fstest.MapFS {
+	fsys := fstest.MapFS{}
+	for _, name := range names {
+		fsys["migrations/"+name] = &fstest.MapFile{Data: []byte(name)}
+	}
+	return fsys
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int64
+		wantErr string
+	}{
+		{
+			name:  "sorted by version",
+			files: files("10_orders.up.sql", "2_users.up.sql", "2_users.down.sql", "1_init.up.sql"),
+			want:  []int64{1, 2, 10},
+		},
+		{name: "no migrations", files: files()},
+		{name: "unknown suffix", files: files("1_init.sql"), wantErr: "must end in .up.sql or .down.sql"},
+		{name: "no name", files: files("1.up.sql"), wantErr: "must start with <version>_"},
+		{name: "version not a number", files: files("first_init.up.sql"), wantErr: `invalid version "first"`},
+		{name: "version zero", files: files("0_init.up.sql"), wantErr: `invalid version "0"`},
+		{name: "negative version", files: files("-1_init.up.sql"), wantErr: `invalid version "-1"`},
+		{name: "version with two names", files: files("1_init.up.sql", "1_setup.down.sql"), wantErr: "named both"},
+		{name: "down without up", files: files("1_init.up.sql", "2_users.down.sql"), wantErr: "2_users has no up migration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := load(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(migrations) != len(tt.want) {
+				t.Fatalf("loaded %d migrations, want %d", len(migrations), len(tt.want))
+			}
+			for i, migration := range migrations {
+				if migration.Version != tt.want[i] {
+					t.Errorf("migration %d is version %d, want %d", i, migration.Version, tt.want[i])
+				}
+				if migration.Up == "" {
+					t.Errorf("migration %d has no up migration", migration.Version)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := load(migrationFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, migration := range migrations {
+		if migration.Down == "" {
+			t.Errorf("migration %d_%s has no down migration", migration.Version, migration.Name)
+		}
+		if i > 0 && migration.Version <= migrations[i-1].Version {
+			t.Errorf("migration %d follows %d", migration.Version, migrations[i-1].Version)
+		}
+	}
+}
+
+// newMockMigrator returns a migrator of the migrations over a mocked database
+// on which the applied versions were applied, expecting the lock to be taken
+// and the applied versions to be read
+func newMockMigrator(t *testing.T, fsys fstest.MapFS, applied ...int64) (*Migrator, sqlmock.Sqlmock) {
+	t.Helper()
+	migrations, err := load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		db.Close()
+	})
+
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_lock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT to_regclass('schema_migrations') IS NOT NULL")).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, version := range applied {
+		rows.AddRow(version, time.Now())
+	}
+	mock.ExpectQuery("SELECT version, applied_at FROM schema_migrations").WillReturnRows(rows)
+	return &Migrator{db: db, migrations: migrations}, mock
+}
+
+// expectRevert expects a migration's down file to run and the migration to be forgotten
+func expectRevert(mock sqlmock.Sqlmock, version int64, downFile string) {
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(downFile)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = $1")).WithArgs(version).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+// expectUnlock expects the migration lock to be released
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(regexp.QuoteMeta("SELECT pg_advisory_unlock($1)")).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+var threeMigrations = files(
+	"1_init.up.sql", "1_init.down.sql",
+	"2_users.up.sql", "2_users.down.sql",
+	"3_orders.up.sql", "3_orders.down.sql",
+)
+
+func TestToRevertsNewestFirst(t *testing.T) {
+	migrator, mock := newMockMigrator(t, threeMigrations, 1, 2, 3)
+	expectRevert(mock, 3, "3_orders.down.sql")
+	expectRevert(mock, 2, "2_users.down.sql")
+	expectUnlock(mock)
+
+	count, err := migrator.To(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("reverted %d migrations, want 2", count)
+	}
+}
+
+func TestToApplies(t *testing.T) {
+	migrator, mock := newMockMigrator(t, threeMigrations, 1)
+	for _, migration := range []struct {
+		version int64
+		name    string
+	}{{2, "users"}, {3, "orders"}} {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(migration.name + ".up.sql")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO schema_migrations (version, name) VALUES ($1, $2)")).
+			WithArgs(migration.version, migration.name).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	expectUnlock(mock)
+
+	count, err := migrator.Up(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("applied %d migrations, want 2", count)
+	}
+}
+
+func TestToRefusesUnknownVersions(t *testing.T) {
+	t.Run("target", func(t *testing.T) {
+		migrations, err := load(threeMigrations)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Refused before touching the database
+		migrator := &Migrator{migrations: migrations}
+		if _, err := migrator.To(context.Background(), 4); err == nil || !strings.Contains(err.Error(), "unknown migration version 4") {
+			t.Errorf("error = %v, want the version refused", err)
+		}
+	})
+
+	t.Run("applied by a newer build", func(t *testing.T) {
+		migrator, mock := newMockMigrator(t, threeMigrations, 1, 2, 3, 4)
+		expectUnlock(mock)
+
+		_, err := migrator.To(context.Background(), 2)
+		if err == nil || !strings.Contains(err.Error(), "cannot revert migration 4") {
+			t.Errorf("error = %v, want the unknown migration refused", err)
+		}
+	})
+}
+
+func TestDown(t *testing.T) {
+	migrator, mock := newMockMigrator(t, threeMigrations, 1, 2)
+	expectRevert(mock, 2, "2_users.down.sql")
+	expectUnlock(mock)
+
+	count, err := migrator.Down(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("reverted %d migrations, want 1", count)
+	}
+}
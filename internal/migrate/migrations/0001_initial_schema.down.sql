@@ -0,0 +1,22 @@
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS email_logs;
+DROP TABLE IF EXISTS queue_bindings;
+DROP TABLE IF EXISTS queue_jobs;
+DROP TABLE IF EXISTS cart_reminders;
+DROP TABLE IF EXISTS daily_sales;
+DROP TABLE IF EXISTS job_runs;
+DROP TABLE IF EXISTS dead_letters;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS warehouse_stocks;
+DROP TABLE IF EXISTS warehouses;
+DROP TABLE IF EXISTS stock_reservations;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS return_items;
+DROP TABLE IF EXISTS return_requests;
+DROP TABLE IF EXISTS order_items;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS users;
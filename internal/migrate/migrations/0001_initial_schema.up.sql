@@ -0,0 +1,289 @@
+-- Schema as previously created by GORM's AutoMigrate. Statements are
+-- idempotent so that databases created by AutoMigrate adopt it as is.
+
+CREATE TABLE IF NOT EXISTS users (
+    id         bigserial PRIMARY KEY,
+    email      text NOT NULL,
+    password   text NOT NULL,
+    first_name text,
+    last_name  text,
+    role       text DEFAULT 'user',
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+
+CREATE TABLE IF NOT EXISTS products (
+    id                bigserial PRIMARY KEY,
+    name              varchar(255) NOT NULL,
+    description       text,
+    price             decimal NOT NULL,
+    stock             bigint NOT NULL,
+    reorder_threshold bigint NOT NULL DEFAULT 0,
+    low_stock_since   timestamptz,
+    created_at        timestamptz,
+    updated_at        timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    order_id       bigserial PRIMARY KEY,
+    user_id        bigint,
+    total_price    decimal,
+    status         text DEFAULT 'pending',
+    cart_id        varchar(64),
+    payment_due_at timestamptz,
+    paid_at        timestamptz,
+    shipped_at     timestamptz,
+    completed_at   timestamptz,
+    created_at     timestamptz,
+    updated_at     timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_order_id ON orders (order_id);
+
+CREATE TABLE IF NOT EXISTS order_items (
+    order_item_id    bigserial PRIMARY KEY,
+    product_id       bigint,
+    related_order_id bigint,
+    quantity         bigint,
+    warehouse_id     bigint,
+    CONSTRAINT fk_orders_products FOREIGN KEY (related_order_id) REFERENCES orders (order_id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_items_order_item_id ON order_items (order_item_id);
+
+CREATE TABLE IF NOT EXISTS return_requests (
+    id               bigserial PRIMARY KEY,
+    order_id         bigint NOT NULL,
+    user_id          bigint NOT NULL,
+    status           varchar(32) DEFAULT 'requested',
+    note             text,
+    rejection_reason text,
+    refund_amount    decimal,
+    approved_at      timestamptz,
+    received_at      timestamptz,
+    refunded_at      timestamptz,
+    created_at       timestamptz,
+    updated_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_return_requests_order_id ON return_requests (order_id);
+CREATE INDEX IF NOT EXISTS idx_return_requests_user_id ON return_requests (user_id);
+
+CREATE TABLE IF NOT EXISTS return_items (
+    return_item_id    bigserial PRIMARY KEY,
+    return_request_id bigint NOT NULL,
+    product_id        bigint NOT NULL,
+    quantity          bigint NOT NULL,
+    reason            varchar(255) NOT NULL,
+    CONSTRAINT fk_return_requests_items FOREIGN KEY (return_request_id) REFERENCES return_requests (id)
+);
+CREATE INDEX IF NOT EXISTS idx_return_items_return_request_id ON return_items (return_request_id);
+
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id             bigserial PRIMARY KEY,
+    product_id     bigint NOT NULL,
+    warehouse_id   bigint,
+    quantity       bigint NOT NULL,
+    reason         varchar(32) NOT NULL,
+    actor_id       bigint,
+    reference_type varchar(32),
+    reference_id   bigint,
+    note           text,
+    balance_after  bigint,
+    created_at     timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements (product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_warehouse_id ON stock_movements (warehouse_id);
+
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id             bigserial PRIMARY KEY,
+    product_id     bigint NOT NULL,
+    warehouse_id   bigint,
+    quantity       bigint NOT NULL,
+    reference_type varchar(32) NOT NULL,
+    reference_id   varchar(64) NOT NULL,
+    user_id        bigint,
+    status         varchar(16) NOT NULL DEFAULT 'active',
+    expires_at     timestamptz NOT NULL,
+    created_at     timestamptz,
+    updated_at     timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_product_id ON stock_reservations (product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_warehouse_id ON stock_reservations (warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_reference ON stock_reservations (reference_type, reference_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_status ON stock_reservations (status);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_expires_at ON stock_reservations (expires_at);
+
+CREATE TABLE IF NOT EXISTS warehouses (
+    id         bigserial PRIMARY KEY,
+    code       varchar(32) NOT NULL,
+    name       varchar(255) NOT NULL,
+    latitude   decimal,
+    longitude  decimal,
+    active     boolean NOT NULL DEFAULT true,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_warehouses_code ON warehouses (code);
+
+CREATE TABLE IF NOT EXISTS warehouse_stocks (
+    warehouse_id bigint,
+    product_id   bigint,
+    quantity     bigint NOT NULL DEFAULT 0,
+    updated_at   timestamptz,
+    PRIMARY KEY (warehouse_id, product_id)
+);
+CREATE INDEX IF NOT EXISTS idx_warehouse_stocks_product_id ON warehouse_stocks (product_id);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              bigserial PRIMARY KEY,
+    aggregate_type  varchar(64) NOT NULL,
+    aggregate_id    varchar(64) NOT NULL,
+    event_type      varchar(128) NOT NULL,
+    payload         jsonb NOT NULL,
+    queue           varchar(128),
+    attempts        bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL,
+    last_error      text,
+    published_at    timestamptz,
+    created_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_aggregate ON outbox_events (aggregate_type, aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_next_attempt_at ON outbox_events (next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_published_at ON outbox_events (published_at);
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id           bigserial PRIMARY KEY,
+    queue        varchar(128) NOT NULL,
+    message_id   varchar(64),
+    message_type varchar(128),
+    body         text,
+    headers      jsonb,
+    attempts     bigint,
+    reason       text,
+    replayed_at  timestamptz,
+    created_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_queue ON dead_letters (queue);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    id           bigserial PRIMARY KEY,
+    job          varchar(64) NOT NULL,
+    scheduled_at timestamptz NOT NULL,
+    node         varchar(255),
+    status       varchar(16) NOT NULL,
+    error        text,
+    started_at   timestamptz NOT NULL,
+    finished_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_job_runs_schedule ON job_runs (job, scheduled_at);
+CREATE INDEX IF NOT EXISTS idx_job_runs_status ON job_runs (status);
+
+CREATE TABLE IF NOT EXISTS daily_sales (
+    day        date,
+    product_id bigint,
+    quantity   bigint NOT NULL,
+    orders     bigint NOT NULL,
+    updated_at timestamptz,
+    PRIMARY KEY (day, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS cart_reminders (
+    cart_id    varchar(64) PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_cart_reminders_user_id ON cart_reminders (user_id);
+
+CREATE TABLE IF NOT EXISTS queue_jobs (
+    id           bigserial PRIMARY KEY,
+    queue        varchar(128) NOT NULL,
+    unique_key   varchar(255),
+    priority     bigint NOT NULL DEFAULT 0,
+    run_at       timestamptz NOT NULL,
+    locked_until timestamptz,
+    lock_token   varchar(32),
+    deliveries   bigint NOT NULL DEFAULT 0,
+    message_id   varchar(64),
+    message_type varchar(128),
+    body         bytea NOT NULL,
+    headers      jsonb,
+    published_at timestamptz,
+    created_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_queue_jobs_ready ON queue_jobs (queue, run_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_queue_jobs_key ON queue_jobs (queue, unique_key) WHERE unique_key IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS queue_bindings (
+    queue      varchar(128),
+    pattern    varchar(255),
+    created_at timestamptz,
+    PRIMARY KEY (queue, pattern)
+);
+
+CREATE TABLE IF NOT EXISTS email_logs (
+    id               bigserial PRIMARY KEY,
+    user_id          bigint,
+    recipient        varchar(255) NOT NULL,
+    template         varchar(64) NOT NULL,
+    template_version varchar(16),
+    subject          varchar(255),
+    status           varchar(16) NOT NULL,
+    error            text,
+    message_id       varchar(64),
+    sent_at          timestamptz,
+    created_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_email_logs_user_id ON email_logs (user_id);
+CREATE INDEX IF NOT EXISTS idx_email_logs_message_id ON email_logs (message_id);
+
+CREATE TABLE IF NOT EXISTS notifications (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    event_type varchar(64) NOT NULL,
+    title      varchar(255) NOT NULL,
+    body       text,
+    message_id varchar(64),
+    read_at    timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, read_at);
+CREATE INDEX IF NOT EXISTS idx_notifications_message_id ON notifications (message_id);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id    bigint,
+    event_type varchar(64),
+    channel    varchar(16),
+    enabled    boolean,
+    updated_at timestamptz,
+    PRIMARY KEY (user_id, event_type, channel)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id                   bigserial PRIMARY KEY,
+    url                  varchar(2048) NOT NULL,
+    description          varchar(255),
+    event_types          jsonb NOT NULL,
+    secret               varchar(128) NOT NULL,
+    active               boolean NOT NULL DEFAULT true,
+    consecutive_failures bigint NOT NULL DEFAULT 0,
+    disabled_at          timestamptz,
+    disabled_reason      varchar(255),
+    created_at           timestamptz,
+    updated_at           timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    subscription_id bigint NOT NULL,
+    event_id        varchar(64) NOT NULL,
+    event_type      varchar(64) NOT NULL,
+    occurred_at     timestamptz,
+    payload         jsonb,
+    attempt         bigint,
+    success         boolean,
+    status_code     bigint,
+    response_body   text,
+    error           text,
+    duration_ms     bigint,
+    created_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries (subscription_id, event_id);
@@ -0,0 +1,10 @@
+-- Feature flags toggled through the admin API, overriding those of the configuration
+CREATE TABLE feature_flags (
+    key         varchar(64) PRIMARY KEY,
+    description varchar(255),
+    enabled     boolean NOT NULL DEFAULT false,
+    percentage  bigint NOT NULL DEFAULT 100,
+    rules       jsonb NOT NULL DEFAULT '[]',
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
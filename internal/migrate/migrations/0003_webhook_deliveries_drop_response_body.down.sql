@@ -0,0 +1 @@
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS response_body text;
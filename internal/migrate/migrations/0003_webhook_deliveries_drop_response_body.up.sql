@@ -0,0 +1,2 @@
+-- Endpoint responses aren't kept in the delivery log, only their status and latency
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS response_body;
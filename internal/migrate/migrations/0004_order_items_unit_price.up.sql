@@ -0,0 +1,7 @@
+-- Order items record the price paid, which returns are refunded at. Items of
+-- earlier orders get the product's current price, the best guess left
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS unit_price decimal NOT NULL DEFAULT 0;
+
+UPDATE order_items SET unit_price = products.price
+FROM products
+WHERE products.id = order_items.product_id;
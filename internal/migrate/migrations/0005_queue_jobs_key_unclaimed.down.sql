@@ -0,0 +1,6 @@
+DELETE FROM queue_jobs duplicate
+USING queue_jobs kept
+WHERE duplicate.queue = kept.queue AND duplicate.unique_key = kept.unique_key AND duplicate.id > kept.id;
+
+DROP INDEX IF EXISTS idx_queue_jobs_key;
+CREATE UNIQUE INDEX idx_queue_jobs_key ON queue_jobs (queue, unique_key) WHERE unique_key IS NOT NULL;
@@ -0,0 +1,4 @@
+-- Keys are only unique among the jobs waiting in a queue, so that a claimed job
+-- can be queued again to be retried
+DROP INDEX IF EXISTS idx_queue_jobs_key;
+CREATE UNIQUE INDEX idx_queue_jobs_key ON queue_jobs (queue, unique_key) WHERE unique_key IS NOT NULL AND lock_token IS NULL;
@@ -0,0 +1,9 @@
+-- Orders used to be created without their total, compute it from the prices
+-- their items were recorded at
+UPDATE orders SET total_price = totals.total
+FROM (
+    SELECT related_order_id, ROUND(SUM(unit_price * quantity)::numeric, 2) AS total
+    FROM order_items
+    GROUP BY related_order_id
+) AS totals
+WHERE totals.related_order_id = orders.order_id AND COALESCE(orders.total_price, 0) = 0;
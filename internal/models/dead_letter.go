@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// DeadLetter is a worker message that kept failing after all its retries
+type DeadLetter struct {
+	ID          int               `json:"id" gorm:"primaryKey;autoIncrement"`
+	Queue       string            `json:"queue" gorm:"size:128;not null;index"`
+	MessageID   string            `json:"message_id" gorm:"size:64"`
+	MessageType string            `json:"message_type" gorm:"size:128"`
+	Body        string            `json:"body" gorm:"type:text"`
+	Headers     map[string]string `json:"headers,omitempty" gorm:"type:jsonb;serializer:json"`
+	Attempts    int               `json:"attempts"`
+	Reason      string            `json:"reason" gorm:"type:text"` // Error returned by the last attempt
+	ReplayedAt  *time.Time        `json:"replayed_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Email delivery statuses
+const (
+	EmailStatusSent   = "sent"
+	EmailStatusFailed = "failed"
+)
+
+// EmailLog records an email sent to a customer, one row per attempt
+type EmailLog struct {
+	ID              int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID          int        `json:"user_id" gorm:"index"`
+	Recipient       string     `json:"recipient" gorm:"size:255;not null"`
+	Template        string     `json:"template" gorm:"size:64;not null"`
+	TemplateVersion string     `json:"template_version" gorm:"size:16"`
+	Subject         string     `json:"subject" gorm:"size:255"`
+	Status          string     `json:"status" gorm:"size:16;not null"`
+	Error           string     `json:"error,omitempty" gorm:"type:text"`
+	MessageID       string     `json:"message_id" gorm:"size:64;index"` // Worker message the email was sent for
+	SentAt          *time.Time `json:"sent_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
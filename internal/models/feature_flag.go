@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Feature flag sources
+const (
+	FeatureFlagSourceConfig   = "config"
+	FeatureFlagSourceDatabase = "database"
+)
+
+// FeatureFlag gates a feature. An enabled flag is on for the users matching
+// one of its rules, and for a percentage of the other users
+type FeatureFlag struct {
+	Key         string `json:"key" gorm:"primaryKey;size:64"`
+	Description string `json:"description" gorm:"size:255"`
+	Enabled     bool   `json:"enabled" gorm:"not null;default:false"`
+	// Percentage is the share of the users not matching a rule the flag is on
+	// for, picked by a stable hash of their ID
+	Percentage int               `json:"percentage" gorm:"not null;default:100"`
+	Rules      []FeatureFlagRule `json:"rules" gorm:"type:jsonb;serializer:json;not null"`
+	// Source tells whether the flag is defined in the configuration or was set through the admin API
+	Source    string    `json:"source" gorm:"-"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// FeatureFlagRule targets the users whose attribute has one of the values
+type FeatureFlagRule struct {
+	Attribute string   `json:"attribute"`
+	Values    []string `json:"values"`
+}
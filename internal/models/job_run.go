@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Job run statuses
+const (
+	JobRunStatusRunning   = "running"
+	JobRunStatusSucceeded = "succeeded"
+	JobRunStatusFailed    = "failed"
+)
+
+// JobRun records a run of a scheduled worker job
+type JobRun struct {
+	ID          int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Job         string     `json:"job" gorm:"size:64;not null;uniqueIndex:idx_job_runs_schedule,priority:1"`
+	ScheduledAt time.Time  `json:"scheduled_at" gorm:"not null;uniqueIndex:idx_job_runs_schedule,priority:2"` // Each scheduled run happens on one replica only
+	Node        string     `json:"node" gorm:"size:255"`                                                      // Host the job ran on
+	Status      string     `json:"status" gorm:"size:16;not null;index"`
+	Error       string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt   time.Time  `json:"started_at" gorm:"not null"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Duration returns how long the run took, or has been running
+func (r *JobRun) Duration() time.Duration {
+	if r.FinishedAt == nil {
+		return time.Since(r.StartedAt)
+	}
+	return r.FinishedAt.Sub(r.StartedAt)
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Notification channels
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelInApp = "in_app"
+)
+
+// Notification is a message in a user's in-app inbox
+type Notification struct {
+	ID        int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    int        `json:"user_id" gorm:"index:idx_notifications_user;not null"`
+	EventType string     `json:"event_type" gorm:"size:64;not null"`
+	Title     string     `json:"title" gorm:"size:255;not null"`
+	Body      string     `json:"body" gorm:"type:text"`
+	MessageID string     `json:"-" gorm:"size:64;index"` // Worker message the notification was created for
+	ReadAt    *time.Time `json:"read_at,omitempty" gorm:"index:idx_notifications_user"`
+	CreatedAt time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NotificationPreference records whether a user gets notified of an event
+// type on a channel. Users are notified unless they opted out
+type NotificationPreference struct {
+	UserID    int       `json:"-" gorm:"primaryKey"`
+	EventType string    `json:"event_type" gorm:"primaryKey;size:64"`
+	Channel   string    `json:"channel" gorm:"primaryKey;size:16"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
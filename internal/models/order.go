@@ -2,19 +2,90 @@ package models
 
 import "time"
 
+// Order statuses
+const (
+	OrderStatusPending           = "pending"
+	OrderStatusPaid              = "paid"
+	OrderStatusShipped           = "shipped"
+	OrderStatusCompleted         = "completed" // the order has been delivered to the customer
+	OrderStatusCanceled          = "canceled"
+	OrderStatusReturnRequested   = "return_requested"
+	OrderStatusRefunded          = "refunded"
+	OrderStatusPartiallyRefunded = "partially_refunded"
+)
+
 type OrderItem struct {
-	OrderItemID    int `json:"order_item_id" gorm:"uniqueIndex;primaryKey;autoIncrement"`
-	ProductID      int `json:"product_id"`
-	RelatedOrderID int `json:"order_id"`
-	Quantity       int `json:"quantity"`
+	OrderItemID    int  `json:"order_item_id" gorm:"uniqueIndex;primaryKey;autoIncrement"`
+	ProductID      int  `json:"product_id"`
+	RelatedOrderID int  `json:"order_id"`
+	Quantity       int  `json:"quantity"`
+	WarehouseID    *int `json:"warehouse_id,omitempty"` // Warehouse the item ships from, nil for unassigned stock
+	// UnitPrice is the product's price at checkout, which returns are refunded at
+	UnitPrice float64 `json:"unit_price" gorm:"not null;default:0"`
 }
 
 type Order struct {
-	OrderID    int         `json:"id" gorm:"uniqueIndex;primaryKey;autoIncrement"`
-	UserID     int         `json:"user_id"`
-	Products   []OrderItem `json:"products" gorm:"foreignKey:RelatedOrderID"` // List of products with quantity and price
-	TotalPrice float64     `json:"total_price"`
-	Status     string      `json:"status" gorm:"default:pending"` // e.g., "pending", "completed", "canceled"
-	CreatedAt  time.Time   `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt  time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+	OrderID      int         `json:"id" gorm:"uniqueIndex;primaryKey;autoIncrement"`
+	UserID       int         `json:"user_id"`
+	Products     []OrderItem `json:"products" gorm:"foreignKey:RelatedOrderID"` // List of products with quantity and price
+	TotalPrice   float64     `json:"total_price"`
+	Status       string      `json:"status" gorm:"default:pending"`    // e.g., "pending", "completed", "canceled"
+	CartID       string      `json:"cart_id,omitempty" gorm:"size:64"` // Cart the order was checked out from
+	PaymentDueAt *time.Time  `json:"payment_due_at,omitempty"`         // Stock is held for the order until then
+	PaidAt       *time.Time  `json:"paid_at,omitempty"`
+	ShippedAt    *time.Time  `json:"shipped_at,omitempty"`
+	CompletedAt  *time.Time  `json:"completed_at,omitempty"`
+	CreatedAt    time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// orderTransitions lists the statuses an order may move to from a given status
+var orderTransitions = map[string][]string{
+	OrderStatusPending:           {OrderStatusPaid, OrderStatusCanceled},
+	OrderStatusPaid:              {OrderStatusShipped, OrderStatusCompleted, OrderStatusCanceled},
+	OrderStatusShipped:           {OrderStatusCompleted},
+	OrderStatusCompleted:         {OrderStatusReturnRequested},
+	OrderStatusReturnRequested:   {OrderStatusCompleted, OrderStatusRefunded, OrderStatusPartiallyRefunded},
+	OrderStatusPartiallyRefunded: {OrderStatusReturnRequested},
+}
+
+// CanTransitionTo reports whether the order may move to the given status
+func (o *Order) CanTransitionTo(status string) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+	return false
+}
+
+// WarehouseOf returns the warehouse the given product ships from
+func (o *Order) WarehouseOf(productID int) *int {
+	for _, item := range o.Products {
+		if item.ProductID == productID && item.WarehouseID != nil {
+			return item.WarehouseID
+		}
+	}
+	return nil
+}
+
+// UnitPriceOf returns the price paid per unit of the given product
+func (o *Order) UnitPriceOf(productID int) float64 {
+	for _, item := range o.Products {
+		if item.ProductID == productID {
+			return item.UnitPrice
+		}
+	}
+	return 0
+}
+
+// QuantityOf returns the ordered quantity of the given product
+func (o *Order) QuantityOf(productID int) int {
+	quantity := 0
+	for _, item := range o.Products {
+		if item.ProductID == productID {
+			quantity += item.Quantity
+		}
+	}
+	return quantity
 }
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent is a domain event waiting to be published to the broker. It is
+// written in the same transaction as the change it describes so that no event
+// is lost when the broker is unavailable.
+type OutboxEvent struct {
+	ID            int64           `json:"id" gorm:"primaryKey;autoIncrement"`
+	AggregateType string          `json:"aggregate_type" gorm:"size:64;not null;index:idx_outbox_aggregate,priority:1"`
+	AggregateID   string          `json:"aggregate_id" gorm:"size:64;not null;index:idx_outbox_aggregate,priority:2"`
+	EventType     string          `json:"event_type" gorm:"size:128;not null"` // Also the routing key it is published with
+	Payload       json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Queue         string          `json:"queue,omitempty" gorm:"size:128"` // Publishes straight to this queue instead of the exchange
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"not null;index"`
+	LastError     string          `json:"last_error,omitempty" gorm:"type:text"`
+	PublishedAt   *time.Time      `json:"published_at,omitempty" gorm:"index"`
+	CreatedAt     time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
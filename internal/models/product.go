@@ -4,11 +4,19 @@ import "time"
 
 // Product represents the schema for the product model
 type Product struct {
-	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	Name        string    `json:"name" gorm:"size:255;not null"`
-	Description string    `json:"description" gorm:"type:text"`
-	Price       float64   `json:"price" gorm:"not null"`
-	Stock       int       `json:"stock" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID               int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name             string     `json:"name" gorm:"size:255;not null"`
+	Description      string     `json:"description" gorm:"type:text"`
+	Price            float64    `json:"price" gorm:"not null"`
+	Stock            int        `json:"stock" gorm:"not null"`
+	Available        int        `json:"available_stock" gorm:"-"`                    // Stock minus what is held by reservations
+	ReorderThreshold int        `json:"reorder_threshold" gorm:"not null;default:0"` // Stock level that triggers a low-stock alert, 0 to disable
+	LowStockSince    *time.Time `json:"low_stock_since,omitempty"`                   // When stock last fell to the reorder threshold
+	CreatedAt        time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsLowOnStock reports whether the product's stock is at or below its reorder threshold
+func (p *Product) IsLowOnStock() bool {
+	return p.ReorderThreshold > 0 && p.Stock <= p.ReorderThreshold
 }
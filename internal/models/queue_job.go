@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// QueueJob is a message waiting in a queue of the Postgres-backed broker
+type QueueJob struct {
+	ID          int64             `json:"id" gorm:"primaryKey;autoIncrement"`
+	Queue       string            `json:"queue" gorm:"size:128;not null;index:idx_queue_jobs_ready,priority:1;uniqueIndex:idx_queue_jobs_key,priority:1,where:unique_key IS NOT NULL AND lock_token IS NULL"`
+	UniqueKey   *string           `json:"unique_key,omitempty" gorm:"size:255;uniqueIndex:idx_queue_jobs_key,priority:2,where:unique_key IS NOT NULL AND lock_token IS NULL"` // Unique among the jobs waiting in the queue
+	Priority    int               `json:"priority" gorm:"not null;default:0"`
+	RunAt       time.Time         `json:"run_at" gorm:"not null;index:idx_queue_jobs_ready,priority:2"` // The job is not handed out before then
+	LockedUntil *time.Time        `json:"locked_until,omitempty"`                                       // Claimed jobs are hidden from other consumers until then
+	LockToken   *string           `json:"-" gorm:"size:32"`                                             // Identifies the claim that may settle the job
+	Deliveries  int               `json:"deliveries" gorm:"not null;default:0"`
+	MessageID   string            `json:"message_id" gorm:"size:64"`
+	MessageType string            `json:"message_type" gorm:"size:128"`
+	Body        []byte            `json:"body" gorm:"not null"`
+	Headers     map[string]string `json:"headers,omitempty" gorm:"type:jsonb;serializer:json"`
+	PublishedAt time.Time         `json:"published_at"`
+	CreatedAt   time.Time         `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// QueueBinding routes messages published with a matching routing key to a queue of the Postgres-backed broker
+type QueueBinding struct {
+	Queue     string    `json:"queue" gorm:"size:128;primaryKey"`
+	Pattern   string    `json:"pattern" gorm:"size:255;primaryKey"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
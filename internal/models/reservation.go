@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Stock reservation statuses
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+	ReservationStatusExpired   = "expired"
+)
+
+// Stock reservation reference types
+const (
+	ReservationReferenceCart  = "cart"
+	ReservationReferenceOrder = "order"
+)
+
+// StockReservation holds a quantity of a product for a cart or a pending order until it expires
+type StockReservation struct {
+	ID            int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProductID     int       `json:"product_id" gorm:"index;not null"`
+	WarehouseID   *int      `json:"warehouse_id,omitempty" gorm:"index"`
+	Quantity      int       `json:"quantity" gorm:"not null"`
+	ReferenceType string    `json:"reference_type" gorm:"size:32;not null;index:idx_stock_reservations_reference"`
+	ReferenceID   string    `json:"reference_id" gorm:"size:64;not null;index:idx_stock_reservations_reference"`
+	UserID        *int      `json:"user_id,omitempty"`
+	Status        string    `json:"status" gorm:"size:16;not null;index;default:active"`
+	ExpiresAt     time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsHeld reports whether the reservation currently holds stock
+func (r *StockReservation) IsHeld(now time.Time) bool {
+	return r.Status == ReservationStatusActive && r.ExpiresAt.After(now)
+}
+
+// CartReminder records that a user was reminded of a cart they abandoned
+type CartReminder struct {
+	CartID    string    `json:"cart_id" gorm:"size:64;primaryKey"`
+	UserID    int       `json:"user_id" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Return request statuses
+const (
+	ReturnStatusRequested = "requested"
+	ReturnStatusApproved  = "approved"
+	ReturnStatusRejected  = "rejected"
+	ReturnStatusReceived  = "received"
+	ReturnStatusRefunded  = "refunded"
+)
+
+// ReturnItem represents a single product being returned, with the customer's reason
+type ReturnItem struct {
+	ReturnItemID    int    `json:"return_item_id" gorm:"primaryKey;autoIncrement"`
+	ReturnRequestID int    `json:"return_request_id" gorm:"index;not null"`
+	ProductID       int    `json:"product_id" gorm:"not null"`
+	Quantity        int    `json:"quantity" gorm:"not null"`
+	Reason          string `json:"reason" gorm:"size:255;not null"`
+}
+
+// ReturnRequest represents a customer's request to return items of a delivered order
+type ReturnRequest struct {
+	ID              int          `json:"id" gorm:"primaryKey;autoIncrement"`
+	OrderID         int          `json:"order_id" gorm:"index;not null"`
+	UserID          int          `json:"user_id" gorm:"index;not null"`
+	Items           []ReturnItem `json:"items" gorm:"foreignKey:ReturnRequestID"`
+	Status          string       `json:"status" gorm:"size:32;default:requested"`
+	Note            string       `json:"note" gorm:"type:text"`
+	RejectionReason string       `json:"rejection_reason,omitempty" gorm:"type:text"`
+	RefundAmount    float64      `json:"refund_amount"`
+	ApprovedAt      *time.Time   `json:"approved_at,omitempty"`
+	ReceivedAt      *time.Time   `json:"received_at,omitempty"`
+	RefundedAt      *time.Time   `json:"refunded_at,omitempty"`
+	CreatedAt       time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsOpen reports whether the return request is still being processed
+func (r *ReturnRequest) IsOpen() bool {
+	return r.Status == ReturnStatusRequested || r.Status == ReturnStatusApproved || r.Status == ReturnStatusReceived
+}
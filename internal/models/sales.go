@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// DailySales totals the quantity of a product sold on a day
+type DailySales struct {
+	Day       time.Time `json:"day" gorm:"type:date;primaryKey"`
+	ProductID int       `json:"product_id" gorm:"primaryKey"`
+	Quantity  int       `json:"quantity" gorm:"not null"`
+	Orders    int       `json:"orders" gorm:"not null"` // Number of orders the product was part of
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
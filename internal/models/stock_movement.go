@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Stock movement reasons
+const (
+	StockReasonOrder      = "order"
+	StockReasonCancel     = "cancel"
+	StockReasonReturn     = "return"
+	StockReasonAdjustment = "adjustment"
+	StockReasonRestock    = "restock"
+	StockReasonTransfer   = "transfer"
+)
+
+// StockMovement is an append-only ledger entry recording a change of a product's stock.
+// Product.Stock is the materialized sum of all movements of the product.
+type StockMovement struct {
+	ID            int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ProductID     int       `json:"product_id" gorm:"index;not null"`
+	WarehouseID   *int      `json:"warehouse_id,omitempty" gorm:"index"` // nil for stock not assigned to a warehouse
+	Quantity      int       `json:"quantity" gorm:"not null"`            // Positive for stock in, negative for stock out
+	Reason        string    `json:"reason" gorm:"size:32;not null"`
+	ActorID       *int      `json:"actor_id,omitempty"`                      // User who caused the movement, nil for the system
+	ReferenceType string    `json:"reference_type,omitempty" gorm:"size:32"` // e.g., "order", "return"
+	ReferenceID   *int      `json:"reference_id,omitempty"`
+	Note          string    `json:"note,omitempty" gorm:"type:text"`
+	BalanceAfter  int       `json:"balance_after"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
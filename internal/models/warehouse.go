@@ -0,0 +1,39 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Warehouse represents a location goods are stocked in and shipped from
+type Warehouse struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Code      string    `json:"code" gorm:"size:32;uniqueIndex;not null"`
+	Name      string    `json:"name" gorm:"size:255;not null"`
+	Latitude  float64   `json:"latitude"`
+	Longitude float64   `json:"longitude"`
+	Active    bool      `json:"active" gorm:"not null;default:true"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// DistanceTo returns the great-circle distance in kilometers from the warehouse to a point
+func (w *Warehouse) DistanceTo(latitude, longitude float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRadians := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRadians(latitude - w.Latitude)
+	dLon := toRadians(longitude - w.Longitude)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(w.Latitude))*math.Cos(toRadians(latitude))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// WarehouseStock is the stock level of a product in a warehouse.
+// Product.Stock is the total over all warehouses plus any stock not assigned to one.
+type WarehouseStock struct {
+	WarehouseID int       `json:"warehouse_id" gorm:"primaryKey"`
+	ProductID   int       `json:"product_id" gorm:"primaryKey;index"`
+	Quantity    int       `json:"quantity" gorm:"not null;default:0"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
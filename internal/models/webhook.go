@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// WebhookSubscription is a partner endpoint that receives the events it subscribed to
+type WebhookSubscription struct {
+	ID          int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	URL         string `json:"url" gorm:"size:2048;not null"`
+	Description string `json:"description" gorm:"size:255"`
+	// EventTypes are the event types sent to the endpoint, "order.*" matches
+	// every order event and "*" every event
+	EventTypes          []string   `json:"event_types" gorm:"type:jsonb;serializer:json;not null"`
+	Secret              string     `json:"-" gorm:"size:128;not null"` // Key the payloads are signed with
+	Active              bool       `json:"active" gorm:"not null;default:true"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"`
+	DisabledReason      string     `json:"disabled_reason,omitempty" gorm:"size:255"`
+	CreatedAt           time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt           time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Subscribes reports whether the endpoint receives events of the given type
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, pattern := range s.EventTypes {
+		if MatchEventType(pattern, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchEventType reports whether the event type matches a subscription
+// pattern, where "*" stands for one dot separated word or, on its own, for
+// any event type
+func MatchEventType(pattern, eventType string) bool {
+	if pattern == "*" {
+		return true
+	}
+	patternWords := strings.Split(pattern, ".")
+	words := strings.Split(eventType, ".")
+	if len(patternWords) != len(words) {
+		return false
+	}
+	for i, word := range patternWords {
+		if word != "*" && word != words[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// WebhookDelivery records an attempt to deliver an event to a webhook endpoint
+type WebhookDelivery struct {
+	ID             int             `json:"id" gorm:"primaryKey;autoIncrement"`
+	SubscriptionID int             `json:"subscription_id" gorm:"not null;index:idx_webhook_deliveries_event,priority:1"`
+	EventID        string          `json:"event_id" gorm:"size:64;not null;index:idx_webhook_deliveries_event,priority:2"`
+	EventType      string          `json:"event_type" gorm:"size:64;not null"`
+	OccurredAt     time.Time       `json:"occurred_at"`
+	Payload        json.RawMessage `json:"payload" gorm:"type:jsonb"`
+	Attempt        int             `json:"attempt"`
+	Success        bool            `json:"success"`
+	StatusCode     int             `json:"status_code,omitempty"`
+	Error          string          `json:"error,omitempty" gorm:"type:text"`
+	DurationMS     int64           `json:"duration_ms"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"autoCreateTime"`
+}
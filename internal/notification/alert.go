@@ -0,0 +1,42 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"ecom-go/internal/config"
+)
+
+// Alert is an operational message for the shop's staff
+type Alert struct {
+	Subject string                 `json:"subject"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Notifier sends alerts to the shop's staff
+type Notifier interface {
+	// Notify delivers the alert
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NewNotifier creates the alert notifier selected in the configuration,
+// emailing alerts through the given sender
+func NewNotifier(cfg *config.Config, emails EmailSender) (Notifier, error) {
+	switch cfg.Alerts.Notifier {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "email":
+		if len(cfg.Alerts.EmailTo) == 0 {
+			return nil, fmt.Errorf("email notifier needs at least one recipient")
+		}
+		return NewEmailNotifier(emails, cfg.Alerts.EmailTo), nil
+	case "webhook":
+		if cfg.Alerts.WebhookURL == "" {
+			return nil, fmt.Errorf("webhook notifier needs a URL")
+		}
+		return NewWebhookNotifier(cfg.Alerts.WebhookURL), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", cfg.Alerts.Notifier)
+	}
+}
@@ -0,0 +1,46 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EmailNotifier emails alerts as plain text
+type EmailNotifier struct {
+	sender EmailSender
+	to     []string
+}
+
+// NewEmailNotifier creates a new email notifier
+func NewEmailNotifier(sender EmailSender, to []string) *EmailNotifier {
+	return &EmailNotifier{
+		sender: sender,
+		to:     to,
+	}
+}
+
+// Notify emails the alert to each of the configured recipients
+func (n *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	var body strings.Builder
+	body.WriteString(alert.Message)
+	body.WriteString("\n")
+
+	keys := make([]string, 0, len(alert.Fields))
+	for key := range alert.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&body, "\n%s: %v", key, alert.Fields[key])
+	}
+
+	for _, to := range n.to {
+		email := Email{To: to, Subject: alert.Subject, Text: body.String()}
+		if err := n.sender.Send(ctx, email); err != nil {
+			return fmt.Errorf("sending alert email to %s: %w", to, err)
+		}
+	}
+	return nil
+}
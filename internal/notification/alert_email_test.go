@@ -0,0 +1,84 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// recordingSender records the emails it is asked to send
+type recordingSender struct {
+	emails []Email
+	err    error
+}
+
+func (s *recordingSender) Send(ctx context.Context, email Email) error {
+	s.emails = append(s.emails, email)
+	return s.err
+}
+
+func TestEmailNotifier(t *testing.T) {
+	sender := &recordingSender{}
+	notifier := NewEmailNotifier(sender, []string{"ops@example.com", "buyer@example.com"})
+
+	alert := Alert{
+		Subject: "Low stock: Kettle",
+		Message: "Kettle is running low",
+		Fields:  map[string]interface{}{"stock": 3, "product_id": 7},
+	}
+	if err := notifier.Notify(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sender.emails) != 2 {
+		t.Fatalf("sent %d emails, want one per recipient", len(sender.emails))
+	}
+	for i, to := range []string{"ops@example.com", "buyer@example.com"} {
+		email := sender.emails[i]
+		if email.To != to || email.Subject != alert.Subject {
+			t.Errorf("email %d = %+v, want it to %s about %q", i, email, to, alert.Subject)
+		}
+		if want := "Kettle is running low\n\nproduct_id: 7\nstock: 3"; email.Text != want {
+			t.Errorf("email %d text = %q, want %q", i, email.Text, want)
+		}
+	}
+
+	sender.err = errors.New("connection refused")
+	if err := notifier.Notify(context.Background(), alert); err == nil {
+		t.Error("Notify succeeded although sending failed")
+	}
+}
+
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+	sender := NewFileSender(dir, "shop@example.com")
+
+	email := Email{To: "ada@example.com", Subject: "Grüße", Text: "Hi Ada", HTML: "<p>Hi Ada</p>"}
+	if err := sender.Send(context.Background(), email); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-ada@example.com.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("files = %v (%v), want one email", files, err)
+	}
+	message, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"From: shop@example.com\r\n",
+		"To: ada@example.com\r\n",
+		"Subject: =?utf-8?q?Gr=C3=BC=C3=9Fe?=\r\n",
+		"multipart/alternative",
+		"text/plain; charset=UTF-8",
+		"text/html; charset=UTF-8",
+	} {
+		if !strings.Contains(string(message), want) {
+			t.Errorf("message does not contain %q:\n%s", want, message)
+		}
+	}
+}
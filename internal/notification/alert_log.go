@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"context"
+
+	"ecom-go/pkg/logger"
+)
+
+// LogNotifier writes alerts to the application log
+type LogNotifier struct{}
+
+// NewLogNotifier creates a new log notifier
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+// Notify logs the alert as a warning
+func (n *LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	args := []interface{}{"message", alert.Message}
+	for key, value := range alert.Fields {
+		args = append(args, key, value)
+	}
+	logger.Warn(alert.Subject, args...)
+	return nil
+}
@@ -0,0 +1,38 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+
+	"ecom-go/internal/config"
+)
+
+// Email is a message to a single recipient with plain text and HTML bodies
+type Email struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// EmailSender delivers emails
+type EmailSender interface {
+	// Send delivers the email
+	Send(ctx context.Context, email Email) error
+}
+
+// NewEmailSender creates the email sender selected in the configuration
+func NewEmailSender(cfg *config.Config) (EmailSender, error) {
+	switch cfg.Notifications.Sender {
+	case "", "smtp":
+		return NewSMTPSender(cfg.SMTP), nil
+	case "file":
+		if cfg.Notifications.FileDir == "" {
+			return nil, fmt.Errorf("file email sender needs a directory")
+		}
+		return NewFileSender(cfg.Notifications.FileDir, cfg.SMTP.From), nil
+	default:
+		return nil, fmt.Errorf("unknown email sender %q", cfg.Notifications.Sender)
+	}
+}
@@ -0,0 +1,49 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// unsafeFileChars matches the characters replaced in dumped email file names
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]+`)
+
+// FileSender writes emails to .eml files in a directory instead of sending
+// them, for local development
+type FileSender struct {
+	dir  string
+	from string
+}
+
+// NewFileSender creates a new file email sender
+func NewFileSender(dir, from string) *FileSender {
+	return &FileSender{
+		dir:  dir,
+		from: from,
+	}
+}
+
+// Send writes the email to a file named after the time and the recipient
+func (s *FileSender) Send(ctx context.Context, email Email) error {
+	if email.From == "" {
+		email.From = s.from
+	}
+	message, err := buildMessage(email)
+	if err != nil {
+		return fmt.Errorf("building email: %w", err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("creating email directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(email.To, "_"))
+	if err := os.WriteFile(filepath.Join(s.dir, name), message, 0o644); err != nil {
+		return fmt.Errorf("writing email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/config"
+)
+
+// SMTPSender sends emails through an SMTP server
+type SMTPSender struct {
+	cfg config.SMTPConfig
+}
+
+// NewSMTPSender creates a new SMTP email sender
+func NewSMTPSender(cfg config.SMTPConfig) *SMTPSender {
+	return &SMTPSender{
+		cfg: cfg,
+	}
+}
+
+// Send delivers the email to the SMTP server, from the configured sender address
+// unless the email sets one. It gives up at the deadline of ctx or after the
+// configured timeout, whichever comes first
+func (s *SMTPSender) Send(ctx context.Context, email Email) error {
+	if email.From == "" {
+		email.From = s.cfg.From
+	}
+	message, err := buildMessage(email)
+	if err != nil {
+		return fmt.Errorf("building email: %w", err)
+	}
+
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	var dialer net.Dialer
+	netConn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("sending email: %w", err)
+	}
+	defer netConn.Close()
+
+	// The deadline covers the whole conversation with the server, ending ctx
+	// interrupts it
+	stop := context.AfterFunc(ctx, func() {
+		netConn.SetDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := s.send(netConn, email, message); err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("sending email: %w", ctx.Err())
+		}
+		return fmt.Errorf("sending email: %w", err)
+	}
+	return nil
+}
+
+// send runs the SMTP conversation delivering the message over the connection,
+// upgrading it to TLS when the server supports it
+func (s *SMTPSender) send(netConn net.Conn, email Email, message []byte) error {
+	client, err := smtp.NewClient(netConn, s.cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(email.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(email.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,190 @@
+package notification
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ecom-go/internal/config"
+)
+
+// fakeSMTPServer accepts SMTP conversations on a local port and records the
+// envelope and data of the messages it receives. A silent server accepts
+// connections but never answers
+type fakeSMTPServer struct {
+	listener net.Listener
+	silent   bool
+
+	mu       sync.Mutex
+	from     string
+	to       []string
+	messages []string
+}
+
+func newFakeSMTPServer(t *testing.T, silent bool) *fakeSMTPServer {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeSMTPServer{listener: listener, silent: silent}
+	t.Cleanup(func() { listener.Close() })
+	go server.serve()
+	return server
+}
+
+// config returns the settings of a sender using the server
+func (s *fakeSMTPServer) config() config.SMTPConfig {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return config.SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "shop@example.com", Timeout: 5 * time.Second}
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	if s.silent {
+		// Hold the connection open until the client gives up
+		conn.Read(make([]byte, 1))
+		return
+	}
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 localhost ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+		switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = strings.TrimPrefix(command, "MAIL FROM:")
+			s.mu.Unlock()
+			reply("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.to = append(s.to, strings.TrimPrefix(command, "RCPT TO:"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPSenderSend(t *testing.T) {
+	server := newFakeSMTPServer(t, false)
+	sender := NewSMTPSender(server.config())
+
+	email := Email{To: "buyer@example.com", Subject: "Order shipped", Text: "Your order is on its way", HTML: "<p>Your order is on its way</p>"}
+	if err := sender.Send(context.Background(), email); err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.from != "<shop@example.com>" {
+		t.Errorf("MAIL FROM = %s, want the configured sender", server.from)
+	}
+	if len(server.to) != 1 || server.to[0] != "<buyer@example.com>" {
+		t.Errorf("RCPT TO = %v, want the recipient", server.to)
+	}
+	if len(server.messages) != 1 || !strings.Contains(server.messages[0], "Subject: Order shipped") {
+		t.Errorf("messages = %q, want the email", server.messages)
+	}
+}
+
+func TestSMTPSenderSendGivesUp(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ctx     func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name:    "deadline of the context",
+			timeout: time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 50*time.Millisecond)
+			},
+		},
+		{
+			name:    "configured timeout",
+			timeout: 50 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeSMTPServer(t, true)
+			cfg := server.config()
+			cfg.Timeout = tt.timeout
+			sender := NewSMTPSender(cfg)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			start := time.Now()
+			err := sender.Send(ctx, Email{To: "buyer@example.com", Subject: "Order shipped", Text: "On its way"})
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("error = %v, want the deadline exceeded", err)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("gave up after %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestSMTPSenderSendUnreachable(t *testing.T) {
+	// Nothing listens on the port once the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	sender := NewSMTPSender(config.SMTPConfig{Host: "127.0.0.1", Port: port, From: "shop@example.com", Timeout: time.Second})
+	if err := sender.Send(context.Background(), Email{To: "buyer@example.com", Subject: "Hi", Text: "Hi"}); err == nil {
+		t.Error("sent to a closed port")
+	}
+}
@@ -0,0 +1,134 @@
+package notification
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Template names
+const (
+	TemplateWelcome           = "welcome"
+	TemplateOrderConfirmation = "order_confirmation"
+	TemplateOrderShipped      = "order_shipped"
+	TemplateRefund            = "refund"
+)
+
+// Every template lives in templates/<name>/<version>/ as subject.txt,
+// body.txt and body.html. Versions are named v1, v2, ...
+//
+//go:embed templates
+var templateFiles embed.FS
+
+// Rendered is an email rendered from a template
+type Rendered struct {
+	Template string
+	Version  string
+	Subject  string
+	Text     string
+	HTML     string
+}
+
+// Templates renders the email templates. Each template is rendered in the
+// version pinned for it, or in its latest version
+type Templates struct {
+	files     fs.FS
+	templates map[string]*template
+}
+
+// template is a version of an email template, parsed
+type template struct {
+	version string
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+// NewTemplates parses the email templates, pinning the given versions
+func NewTemplates(versions map[string]string) (*Templates, error) {
+	t := &Templates{files: templateFiles, templates: make(map[string]*template)}
+
+	names, err := fs.ReadDir(t.files, "templates")
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		version := versions[name.Name()]
+		if version == "" {
+			if version, err = t.latestVersion(name.Name()); err != nil {
+				return nil, err
+			}
+		}
+		dir := path.Join("templates", name.Name(), version)
+		if _, err := fs.Stat(t.files, dir); err != nil {
+			return nil, fmt.Errorf("template %s has no version %s", name.Name(), version)
+		}
+
+		parsed := &template{version: version}
+		if parsed.subject, err = texttemplate.ParseFS(t.files, path.Join(dir, "subject.txt")); err != nil {
+			return nil, fmt.Errorf("parsing template %s %s: %w", name.Name(), version, err)
+		}
+		if parsed.text, err = texttemplate.ParseFS(t.files, path.Join(dir, "body.txt")); err != nil {
+			return nil, fmt.Errorf("parsing template %s %s: %w", name.Name(), version, err)
+		}
+		if parsed.html, err = htmltemplate.ParseFS(t.files, path.Join(dir, "body.html")); err != nil {
+			return nil, fmt.Errorf("parsing template %s %s: %w", name.Name(), version, err)
+		}
+		t.templates[name.Name()] = parsed
+	}
+	return t, nil
+}
+
+// Render renders a template with the given data
+func (t *Templates) Render(name string, data interface{}) (*Rendered, error) {
+	tmpl, ok := t.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("rendering %s subject: %w", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("rendering %s text: %w", name, err)
+	}
+	if err := tmpl.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("rendering %s HTML: %w", name, err)
+	}
+
+	return &Rendered{
+		Template: name,
+		Version:  tmpl.version,
+		Subject:  strings.TrimSpace(subject.String()),
+		Text:     text.String(),
+		HTML:     html.String(),
+	}, nil
+}
+
+// latestVersion returns the highest version of a template
+func (t *Templates) latestVersion(name string) (string, error) {
+	entries, err := fs.ReadDir(t.files, path.Join("templates", name))
+	if err != nil {
+		return "", err
+	}
+
+	var versions []int
+	for _, entry := range entries {
+		if n, err := strconv.Atoi(strings.TrimPrefix(entry.Name(), "v")); err == nil && entry.IsDir() {
+			versions = append(versions, n)
+		}
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("template %s has no versions", name)
+	}
+	sort.Ints(versions)
+	return "v" + strconv.Itoa(versions[len(versions)-1]), nil
+}
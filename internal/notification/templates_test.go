@@ -0,0 +1,86 @@
+package notification
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := NewTemplates(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    map[string]interface{}
+		subject string
+		text    []string
+		html    []string
+	}{
+		{
+			name:    TemplateWelcome,
+			data:    map[string]interface{}{"FirstName": "Ada"},
+			subject: "Welcome to the shop, Ada!",
+		},
+		{
+			name: TemplateOrderConfirmation,
+			data: map[string]interface{}{
+				"FirstName":    "Ada",
+				"OrderID":      42,
+				"Items":        []map[string]interface{}{{"Name": "Kettle <XL>", "Quantity": 2}},
+				"Total":        59.9,
+				"PaymentDueAt": "Mon, 02 Mar 2026 10:00:00 UTC",
+			},
+			subject: "Your order #42 is confirmed",
+			text:    []string{"- 2 x Kettle <XL>", "Total: 59.90"},
+			html:    []string{"Kettle &lt;XL&gt;", "Total: 59.90"},
+		},
+		{
+			name: TemplateRefund,
+			data: map[string]interface{}{"FirstName": "Ada", "OrderID": 42, "Amount": 12.5},
+			text: []string{"refunded 12.50"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := templates.Render(tt.name, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rendered.Version != "v1" {
+				t.Errorf("Version = %q, want the latest, v1", rendered.Version)
+			}
+			if tt.subject != "" && rendered.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", rendered.Subject, tt.subject)
+			}
+			for _, want := range tt.text {
+				if !strings.Contains(rendered.Text, want) {
+					t.Errorf("Text = %q, want it to contain %q", rendered.Text, want)
+				}
+			}
+			for _, want := range tt.html {
+				if !strings.Contains(rendered.HTML, want) {
+					t.Errorf("HTML = %q, want it to contain %q", rendered.HTML, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTemplatesVersions(t *testing.T) {
+	if _, err := NewTemplates(map[string]string{TemplateWelcome: "v1"}); err != nil {
+		t.Errorf("pinning an existing version: %v", err)
+	}
+	if _, err := NewTemplates(map[string]string{TemplateWelcome: "v99"}); err == nil {
+		t.Error("pinning a missing version succeeded")
+	}
+
+	templates, err := NewTemplates(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := templates.Render("missing", nil); err == nil {
+		t.Error("rendering an unknown template succeeded")
+	}
+}
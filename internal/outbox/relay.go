@@ -0,0 +1,117 @@
+package outbox
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/broker"
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	"ecom-go/pkg/logger"
+)
+
+// cleanupInterval is how often published events past their retention are deleted
+const cleanupInterval = time.Hour
+
+// Relay publishes the events written to the outbox to the broker
+type Relay struct {
+	repo   repository.OutboxRepository
+	broker broker.Broker
+	cfg    config.OutboxConfig
+}
+
+// NewRelay creates a new outbox relay
+func NewRelay(repo repository.OutboxRepository, b broker.Broker, cfg config.OutboxConfig) *Relay {
+	return &Relay{
+		repo:   repo,
+		broker: b,
+		cfg:    cfg,
+	}
+}
+
+// Run publishes pending events until the context is canceled
+func (r *Relay) Run(ctx context.Context) {
+	poll := time.NewTicker(r.cfg.PollInterval)
+	defer poll.Stop()
+	cleanup := time.NewTicker(cleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			r.drain(ctx)
+		case <-cleanup.C:
+			r.cleanup(ctx)
+		}
+	}
+}
+
+// cleanup deletes the events published longer than the retention ago
+func (r *Relay) cleanup(ctx context.Context) {
+	deleted, err := r.repo.DeletePublished(ctx, time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		logger.Error("Failed to delete published outbox events", "error", err)
+		return
+	}
+	if deleted > 0 {
+		logger.Info("Deleted published outbox events", "count", deleted)
+	}
+}
+
+// drain publishes batches of due events until none are left
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := r.repo.Dispatch(ctx, r.cfg.BatchSize, func(event *models.OutboxEvent) error {
+			return r.publish(ctx, event)
+		}, r.backoff)
+		if err != nil {
+			logger.Error("Failed to dispatch outbox events", "error", err)
+			return
+		}
+		if published == 0 {
+			return
+		}
+	}
+}
+
+// publish sends an event to the broker, using its outbox ID as the message ID
+// so consumers can recognize redeliveries
+func (r *Relay) publish(ctx context.Context, event *models.OutboxEvent) error {
+	msg := broker.Message{
+		ID:   strconv.FormatInt(event.ID, 10),
+		Type: event.EventType,
+		Body: event.Payload,
+		Headers: map[string]string{
+			"aggregate_type": event.AggregateType,
+			"aggregate_id":   event.AggregateID,
+		},
+		Timestamp: event.CreatedAt,
+	}
+	var err error
+	if event.Queue != "" {
+		err = r.broker.PublishToQueue(ctx, event.Queue, msg, 0)
+	} else {
+		err = r.broker.Publish(ctx, event.EventType, msg)
+	}
+	if err != nil {
+		logger.Warn("Failed to publish outbox event", "event_id", event.ID, "type", event.EventType, "attempt", event.Attempts+1, "error", err)
+		return err
+	}
+	return nil
+}
+
+// backoff doubles the delay with every failed attempt, starting at the poll interval
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.cfg.PollInterval
+	for i := 1; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+	return delay
+}
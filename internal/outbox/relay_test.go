@@ -0,0 +1,219 @@
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"ecom-go/internal/broker"
+	"ecom-go/internal/config"
+	"ecom-go/internal/repository"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingBroker records the messages published, failing those whose ID is in fail
+type recordingBroker struct {
+	broker.Broker
+	mu        sync.Mutex
+	published []broker.Message
+	queues    []string
+	fail      map[string]bool
+}
+
+func (b *recordingBroker) Publish(ctx context.Context, routingKey string, msg broker.Message) error {
+	return b.PublishToQueue(ctx, "", msg, 0)
+}
+
+func (b *recordingBroker) PublishToQueue(ctx context.Context, queue string, msg broker.Message, delay time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.fail[msg.ID] {
+		return errors.New("broker unavailable")
+	}
+	b.published = append(b.published, msg)
+	b.queues = append(b.queues, queue)
+	return nil
+}
+
+// newMockRelay returns a relay publishing to a recording broker the events
+// of an outbox over a mocked database
+func newMockRelay(t *testing.T, cfg config.OutboxConfig) (*Relay, sqlmock.Sqlmock, *recordingBroker) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		sqlDB.Close()
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &recordingBroker{fail: map[string]bool{}}
+	return NewRelay(repository.NewOutboxRepo(db), b, cfg), mock, b
+}
+
+// outboxEvent is an event row returned by the outbox
+type outboxEvent struct {
+	id          int64
+	aggregateID string
+	queue       string
+	attempts    int
+}
+
+// expectHeads expects a dispatch round taking the lock and finding the due
+// heads of the aggregates, at most limit of them
+func expectHeads(mock sqlmock.Sqlmock, limit int, events ...outboxEvent) {
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_xact_lock($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(true))
+
+	rows := sqlmock.NewRows([]string{"id", "aggregate_type", "aggregate_id", "event_type", "payload", "queue", "attempts", "next_attempt_at", "created_at"})
+	for _, event := range events {
+		rows.AddRow(event.id, "order", event.aggregateID, "order.created", []byte(`{}`), event.queue, event.attempts, time.Now(), time.Now())
+	}
+	// Only the oldest unpublished event of each aggregate is a candidate
+	mock.ExpectQuery(`SELECT DISTINCT ON \(aggregate_type, aggregate_id\) \* FROM outbox_events\s+WHERE published_at IS NULL\s+ORDER BY aggregate_type, aggregate_id, id`).
+		WithArgs(sqlmock.AnyArg(), limit).
+		WillReturnRows(rows)
+}
+
+// expectPublished expects an event to be marked published
+func expectPublished(mock sqlmock.Sqlmock, event outboxEvent) {
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=$1,"last_error"=$2,"published_at"=$3 WHERE id = $4`)).
+		WithArgs(event.attempts+1, "", sqlmock.AnyArg(), event.id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// expectRetried expects an event to be retried after the delay
+func expectRetried(mock sqlmock.Sqlmock, event outboxEvent, delay time.Duration) {
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "outbox_events" SET "attempts"=$1,"last_error"=$2,"next_attempt_at"=$3 WHERE id = $4`)).
+		WithArgs(event.attempts+1, "broker unavailable", after(delay), event.id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+// after matches a time the delay from now
+type after time.Duration
+
+func (d after) Match(v driver.Value) bool {
+	at, ok := v.(time.Time)
+	want := time.Now().Add(time.Duration(d))
+	return ok && at.After(want.Add(-time.Second)) && at.Before(want.Add(time.Second))
+}
+
+func TestRelayDrainsInBatches(t *testing.T) {
+	relay, mock, b := newMockRelay(t, config.OutboxConfig{PollInterval: time.Second, BatchSize: 2, MaxBackoff: time.Minute})
+
+	first := []outboxEvent{{id: 1, aggregateID: "1"}, {id: 2, aggregateID: "2", queue: "worker.notifications.welcome"}}
+	expectHeads(mock, 2, first...)
+	expectPublished(mock, first[0])
+	expectPublished(mock, first[1])
+	mock.ExpectCommit()
+
+	second := outboxEvent{id: 3, aggregateID: "3"}
+	expectHeads(mock, 2, second)
+	expectPublished(mock, second)
+	mock.ExpectCommit()
+
+	// An empty round ends the drain
+	expectHeads(mock, 2)
+	mock.ExpectCommit()
+
+	relay.drain(context.Background())
+
+	if len(b.published) != 3 {
+		t.Fatalf("published %d events, want 3", len(b.published))
+	}
+	for i, want := range []string{"1", "2", "3"} {
+		if b.published[i].ID != want {
+			t.Errorf("message %d has ID %s, want the outbox ID %s", i, b.published[i].ID, want)
+		}
+	}
+	if b.queues[1] != "worker.notifications.welcome" || b.queues[0] != "" {
+		t.Errorf("queues = %q, want only the queued event sent straight to its queue", b.queues)
+	}
+}
+
+func TestRelayFailedEventBlocksItsAggregate(t *testing.T) {
+	relay, mock, b := newMockRelay(t, config.OutboxConfig{PollInterval: time.Second, BatchSize: 10, MaxBackoff: time.Minute})
+	b.fail["1"] = true
+
+	// Order 7's first event fails, order 8's is published
+	failing := outboxEvent{id: 1, aggregateID: "7", attempts: 2}
+	other := outboxEvent{id: 2, aggregateID: "8"}
+	expectHeads(mock, 10, failing, other)
+	expectRetried(mock, failing, 4*time.Second)
+	expectPublished(mock, other)
+	mock.ExpectCommit()
+
+	// The failed head is not due yet, so order 7's later events are not
+	// candidates either and the round finds nothing
+	expectHeads(mock, 10)
+	mock.ExpectCommit()
+
+	relay.drain(context.Background())
+
+	if len(b.published) != 1 || b.published[0].ID != "2" {
+		t.Errorf("published %+v, want only the other aggregate's event", b.published)
+	}
+}
+
+func TestRelaySkipsWhileAnotherDispatches(t *testing.T) {
+	relay, mock, b := newMockRelay(t, config.OutboxConfig{PollInterval: time.Second, BatchSize: 10, MaxBackoff: time.Minute})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT pg_try_advisory_xact_lock($1)")).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(false))
+	mock.ExpectCommit()
+
+	relay.drain(context.Background())
+	if len(b.published) != 0 {
+		t.Errorf("published %d events without the lock", len(b.published))
+	}
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := NewRelay(nil, nil, config.OutboxConfig{PollInterval: time.Second, MaxBackoff: 10 * time.Second})
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := relay.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRelayCleanup(t *testing.T) {
+	relay, mock, _ := newMockRelay(t, config.OutboxConfig{Retention: 72 * time.Hour})
+
+	// Only events published before the retention are deleted
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "outbox_events" WHERE published_at < $1`)).
+		WithArgs(after(-72 * time.Hour)).
+		WillReturnResult(sqlmock.NewResult(0, 5))
+	mock.ExpectCommit()
+
+	relay.cleanup(context.Background())
+}
@@ -1,142 +1,178 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
-	"strconv"
 	"time"
 
 	"ecom-go/internal/config"
-	"ecom-go/internal/models"
+	"ecom-go/internal/migrate"
 	"ecom-go/pkg/logger"
 
-	_ "github.com/lib/pq"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	gormLogger "gorm.io/gorm/logger"
 )
 
-// NewDatabase establishes a connection to the PostgreSQL database
-// or creates it if it doesn't exist
+// NewDatabase establishes a connection to the PostgreSQL database. A missing
+// database is reported as a *DatabaseNotFoundError, or created first when
+// database.create_if_missing is enabled
 func NewDatabase(config *config.DatabaseConfig) (*gorm.DB, error) {
-	// First, ensure the database exists
-	if err := createDatabaseIfNotExists(config); err != nil {
-		return nil, fmt.Errorf("failed to create database: %w", err)
-	}
-
-	// Configure GORM logger
-	gormLogger := gormLogger.New(
-		log.New(log.Writer(), "\r\n", log.LstdFlags),
-		gormLogger.Config{
-			SlowThreshold:             200 * time.Millisecond,
-			LogLevel:                  gormLogger.Info,
-			IgnoreRecordNotFoundError: true,
-			Colorful:                  true,
-		},
-	)
-
-	// Connect to the database
-
-	fmt.Println("--newDB: connecting")
-	dsn := config.GetDSN()
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
-	})
+	db, err := openDatabase(config)
+	var notFound *DatabaseNotFoundError
+	if errors.As(err, &notFound) && config.CreateIfMissing {
+		if err := ProvisionDatabase(context.Background(), config); err != nil {
+			return nil, fmt.Errorf("failed to provision database: %w", err)
+		}
+		db, err = openDatabase(config)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
-	fmt.Println("--newDB: connected")
 
-	// Get the underlying SQL DB object
-	sqlDB, err := db.DB()
+	sqlDB, err := configurePool(db, config.Pool)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get sql.DB instance: %w", err)
+		return nil, err
 	}
 
-	// Configure connection pool
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
 	// Ping database to verify connection
 	if err := sqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("could not ping database: %w", err)
 	}
 
-	logger.Info("Connected to database", "database", config.Name)
+	logger.Info("Connected to database", "database", config.Name, "host", config.Host)
 	return db, nil
 }
 
-// createDatabaseIfNotExists connects to PostgreSQL and creates
-// the database if it doesn't already exist
-func createDatabaseIfNotExists(config *config.DatabaseConfig) error {
-	fmt.Printf("Host: %s\n", config.Host)
-	fmt.Printf("Port: %d\n", config.Port)
-	fmt.Printf("User: %s\n", config.User)
-	fmt.Printf("Password: %s\n", config.Password) // For security
-	fmt.Printf("Name: %s\n", config.Name)
-	fmt.Printf("SSLMode: %s\n", config.SSLMode)
-	// Connect to the 'postgres' database to create our app database
-	postgresConnStr := fmt.Sprintf("host=%s user=%s password=%s dbname=postgres port=%d sslmode=%s",
-		config.Host, config.User, config.Password, config.Port, config.SSLMode)
-
-	// Connect to postgres database
-
-	fmt.Println("--createDB: connecting to DB")
-	db, err := sql.Open("postgres", postgresConnStr)
+// NewReplica connects to a read replica with the settings of the primary. Its
+// availability isn't checked here, the Resolver keeps it out of rotation until it answers
+func NewReplica(dsn string, config *config.DatabaseConfig) (*gorm.DB, error) {
+	gormConfig := newGormConfig(config)
+	gormConfig.DisableAutomaticPing = true
+	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
 	if err != nil {
-		return fmt.Errorf("error connecting to postgres database: %w", err)
+		return nil, fmt.Errorf("failed to connect to replica: %w", err)
+	}
+	if _, err := configurePool(db, config.Pool); err != nil {
+		return nil, err
 	}
-	fmt.Println("--createDB: connected")
-	defer db.Close()
+	return db, nil
+}
 
-	// Check if the database exists
-	var exists bool
-	query := fmt.Sprintf("SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = '%s')", config.Name)
+// openDatabase connects GORM to the configured database
+func openDatabase(config *config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(config.GetDSN()), newGormConfig(config))
+	if isMissingDatabase(err) {
+		return nil, &DatabaseNotFoundError{Name: config.Name, Err: err}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return db, nil
+}
 
-	fmt.Println("--createDB: checking")
-	if err := db.QueryRow(query).Scan(&exists); err != nil {
-		return fmt.Errorf("error checking if database exists: %w", err)
+// newGormConfig returns the GORM configuration shared by the primary and the replicas
+func newGormConfig(config *config.DatabaseConfig) *gorm.Config {
+	return &gorm.Config{
+		Logger: newSQLLogger(config.LogLevel, config.SlowQueryThreshold),
 	}
-	fmt.Println("--createDB: checked: " + strconv.FormatBool(exists))
+}
 
-	// Create the database if it doesn't exist
-	if !exists {
-		logger.Info("Creating database", "database", config.Name)
+// configurePool sizes the connection pool of the database
+func configurePool(db *gorm.DB, pool config.DatabasePoolConfig) (*sql.DB, error) {
+	// Get the underlying SQL DB object
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB instance: %w", err)
+	}
 
-		// Create the database - need to use fmt.Sprintf as the database name is an identifier
-		// and shouldn't be passed as a parameter
-		_, err = db.Exec(fmt.Sprintf("CREATE DATABASE %s", config.Name))
-		if err != nil {
-			return fmt.Errorf("error creating database: %w", err)
-		}
-		logger.Info("Database created successfully", "database", config.Name)
-	} else {
-		logger.Info("Database already exists", "database", config.Name)
+	// Configure connection pool
+	sqlDB.SetMaxOpenConns(pool.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(pool.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	return sqlDB, nil
+}
+
+// reportPoolStats logs the statistics of the connection pool every interval
+// until the context is canceled, warning when requests had to wait for a connection
+func reportPoolStats(ctx context.Context, db *gorm.DB, interval time.Duration) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.Error("Failed to get sql.DB instance for pool stats", "error", err)
+		return
 	}
-	fmt.Println("--createDB: done")
 
-	return nil
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var previous sql.DBStats
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		stats := sqlDB.Stats()
+		args := []interface{}{
+			"open", stats.OpenConnections,
+			"in_use", stats.InUse,
+			"idle", stats.Idle,
+			"max_open", stats.MaxOpenConnections,
+			"waits", stats.WaitCount - previous.WaitCount,
+			"wait_duration", stats.WaitDuration - previous.WaitDuration,
+			"closed_max_idle", stats.MaxIdleClosed - previous.MaxIdleClosed,
+			"closed_max_idle_time", stats.MaxIdleTimeClosed - previous.MaxIdleTimeClosed,
+			"closed_max_lifetime", stats.MaxLifetimeClosed - previous.MaxLifetimeClosed,
+		}
+		if stats.WaitCount > previous.WaitCount {
+			logger.Warn("Database pool exhausted, queries waited for a connection", args...)
+		} else {
+			logger.Info("Database pool stats", args...)
+		}
+		previous = stats
+	}
 }
 
-// AutoMigrate automatically migrates database schema
-func AutoMigrate(db *gorm.DB) error {
-	logger.Info("Running auto migration")
+// Migrate applies the pending SQL migrations
+func Migrate(db *gorm.DB) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return err
+	}
 
-	// Add models for migration here
-	err := db.AutoMigrate(
-		&models.User{},
-		// Add other models for auto-migration here as they are created
-		&models.Product{},
-		&models.Order{},
-		&models.OrderItem{},
-	)
+	count, err := migrator.Up(context.Background())
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
 
+	logger.Info("Database schema is up to date", "applied", count, "version", migrator.Latest())
+	return nil
+}
+
+// checkMigrations warns when migrations are pending, as the schema is then older than the code expects
+func checkMigrations(db *gorm.DB) error {
+	migrator, err := newMigrator(db)
 	if err != nil {
-		return fmt.Errorf("auto migration failed: %w", err)
+		return err
 	}
 
-	logger.Info("Auto migration completed successfully")
+	pending, err := migrator.Pending(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to check migrations: %w", err)
+	}
+	if pending > 0 {
+		logger.Warn("Database migrations are pending, run \"api migrate up\"", "pending", pending)
+	}
 	return nil
 }
+
+// newMigrator creates a migrator of the database
+func newMigrator(db *gorm.DB) (*migrate.Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sql.DB instance: %w", err)
+	}
+	return migrate.New(sqlDB)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// DeadLetterRepository defines the interface for dead-lettered worker messages
+type DeadLetterRepository interface {
+	// Create stores a message that ran out of retries
+	Create(ctx context.Context, deadLetter *models.DeadLetter) error
+
+	// GetByID retrieves a dead-lettered message by ID
+	GetByID(ctx context.Context, id int) (*models.DeadLetter, error)
+
+	// List retrieves dead-lettered messages, newest first, optionally of a single queue
+	List(ctx context.Context, queue string, offset, limit int) ([]*models.DeadLetter, error)
+
+	// Count returns the number of dead-lettered messages, optionally of a single queue
+	Count(ctx context.Context, queue string) (int64, error)
+
+	// Replay sends a dead-lettered message back to its queue through the outbox
+	Replay(ctx context.Context, id int) (*models.DeadLetter, error)
+
+	// Purge deletes dead-lettered messages, optionally of a single queue
+	Purge(ctx context.Context, queue string) (int64, error)
+}
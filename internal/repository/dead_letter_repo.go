@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeadLetterRepo implements the DeadLetterRepository interface using PostgreSQL/GORM
+type DeadLetterRepo struct {
+	db *gorm.DB
+}
+
+// NewDeadLetterRepo creates a new dead letter repository
+func NewDeadLetterRepo(db *gorm.DB) *DeadLetterRepo {
+	return &DeadLetterRepo{
+		db: db,
+	}
+}
+
+// Create stores a message that ran out of retries
+func (r *DeadLetterRepo) Create(ctx context.Context, deadLetter *models.DeadLetter) error {
+	return conn(ctx, r.db).Create(deadLetter).Error
+}
+
+// GetByID retrieves a dead-lettered message by ID
+func (r *DeadLetterRepo) GetByID(ctx context.Context, id int) (*models.DeadLetter, error) {
+	var deadLetter models.DeadLetter
+	result := conn(ctx, r.db).First(&deadLetter, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &deadLetter, nil
+}
+
+// List retrieves dead-lettered messages, newest first, optionally of a single queue
+func (r *DeadLetterRepo) List(ctx context.Context, queue string, offset, limit int) ([]*models.DeadLetter, error) {
+	var deadLetters []*models.DeadLetter
+	result := r.byQueue(ctx, queue).Order("id DESC").Offset(offset).Limit(limit).Find(&deadLetters)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return deadLetters, nil
+}
+
+// Count returns the number of dead-lettered messages, optionally of a single queue
+func (r *DeadLetterRepo) Count(ctx context.Context, queue string) (int64, error) {
+	var count int64
+	result := r.byQueue(ctx, queue).Model(&models.DeadLetter{}).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// Replay sends a dead-lettered message back to its queue through the outbox,
+// so the message is published even if the broker is unavailable right now
+func (r *DeadLetterRepo) Replay(ctx context.Context, id int) (*models.DeadLetter, error) {
+	var deadLetter models.DeadLetter
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&deadLetter, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		event := &models.OutboxEvent{
+			AggregateType: "dead_letter",
+			AggregateID:   strconv.Itoa(deadLetter.ID),
+			EventType:     deadLetter.MessageType,
+			Payload:       []byte(deadLetter.Body),
+			Queue:         deadLetter.Queue,
+			NextAttemptAt: time.Now(),
+		}
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		deadLetter.ReplayedAt = &now
+		return tx.Model(&deadLetter).Update("replayed_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetter, nil
+}
+
+// Purge deletes dead-lettered messages, optionally of a single queue
+func (r *DeadLetterRepo) Purge(ctx context.Context, queue string) (int64, error) {
+	result := r.byQueue(ctx, queue).Where("1 = 1").Delete(&models.DeadLetter{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// byQueue scopes a query to a queue, or to all queues when it is empty
+func (r *DeadLetterRepo) byQueue(ctx context.Context, queue string) *gorm.DB {
+	db := conn(ctx, r.db)
+	if queue != "" {
+		db = db.Where("queue = ?", queue)
+	}
+	return db
+}
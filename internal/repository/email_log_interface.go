@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// EmailLogRepository defines the interface for the log of emails sent to customers
+type EmailLogRepository interface {
+	// Create records an attempt to send an email
+	Create(ctx context.Context, log *models.EmailLog) error
+
+	// WasSent reports whether the template was already sent successfully for the worker message
+	WasSent(ctx context.Context, messageID, template string) (bool, error)
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// EmailLogRepo implements the EmailLogRepository interface using PostgreSQL/GORM
+type EmailLogRepo struct {
+	db *gorm.DB
+}
+
+// NewEmailLogRepo creates a new email log repository
+func NewEmailLogRepo(db *gorm.DB) *EmailLogRepo {
+	return &EmailLogRepo{
+		db: db,
+	}
+}
+
+// Create records an attempt to send an email
+func (r *EmailLogRepo) Create(ctx context.Context, log *models.EmailLog) error {
+	return conn(ctx, r.db).Create(log).Error
+}
+
+// WasSent reports whether the template was already sent successfully for the worker message
+func (r *EmailLogRepo) WasSent(ctx context.Context, messageID, template string) (bool, error) {
+	var count int64
+	result := conn(ctx, r.db).Model(&models.EmailLog{}).
+		Where("message_id = ? AND template = ? AND status = ?", messageID, template, models.EmailStatusSent).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
@@ -1,9 +1,28 @@
 package repository
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Common repository errors
 var (
-	ErrNotFound = errors.New("resource not found")
-	ErrConflict = errors.New("resource already exists")
+	ErrNotFound          = errors.New("resource not found")
+	ErrConflict          = errors.New("resource already exists")
+	ErrInsufficientStock = errors.New("insufficient stock")
 )
+
+// DatabaseNotFoundError is returned when the configured database doesn't exist,
+// so that callers may provision it or report how to
+type DatabaseNotFoundError struct {
+	Name string
+	Err  error
+}
+
+func (e *DatabaseNotFoundError) Error() string {
+	return fmt.Sprintf("database %q does not exist, create it or enable database.create_if_missing", e.Name)
+}
+
+func (e *DatabaseNotFoundError) Unwrap() error {
+	return e.Err
+}
@@ -1,20 +1,42 @@
 package repository
 
 import (
+	"context"
 	"fmt"
 
+	"ecom-go/internal/cache"
 	"ecom-go/internal/config"
+	"ecom-go/pkg/logger"
 
 	"gorm.io/gorm"
 )
 
 // Factory provides access to all repositories
 type Factory struct {
-	db   *gorm.DB
-	User UserRepository
+	db          *gorm.DB
+	resolver    *Resolver
+	cache       cache.Cache
+	stopReports context.CancelFunc
+	User        UserRepository
 	// Add other repositories here as you implement them
-	Product ProductRepository
-	Order   OrderRepository
+	Product                ProductRepository
+	Order                  OrderRepository
+	Return                 ReturnRepository
+	Stock                  StockMovementRepository
+	Reservation            ReservationRepository
+	Warehouse              WarehouseRepository
+	Outbox                 OutboxRepository
+	DeadLetter             DeadLetterRepository
+	JobRun                 JobRunRepository
+	EmailLog               EmailLogRepository
+	Notification           NotificationRepository
+	NotificationPreference NotificationPreferenceRepository
+	Webhook                WebhookRepository
+	FeatureFlag            FeatureFlagRepository
+	// TxManager runs the repositories' calls of a service in a single transaction
+	TxManager TxManager
+	// ProductCache is the caching decorator of Product, nil when caching is disabled
+	ProductCache *CachedProductRepo
 }
 
 // NewFactory creates a new repository factory
@@ -25,23 +47,88 @@ func NewFactory(cfg *config.Config) (*Factory, error) {
 		return nil, fmt.Errorf("failed to initialize database: %w", err)
 	}
 
-	// Auto-migrate database schema if needed
-	if err := AutoMigrate(db); err != nil {
-		return nil, fmt.Errorf("failed to auto-migrate database: %w", err)
+	// Migrate the database schema if enabled, production migrates before deploying instead
+	if cfg.Database.AutoMigrate {
+		if err := Migrate(db); err != nil {
+			return nil, fmt.Errorf("failed to migrate database: %w", err)
+		}
+	} else if err := checkMigrations(db); err != nil {
+		return nil, err
+	}
+
+	// Connect to the read replicas, they only serve reads that tolerate replication lag
+	var replicas []*gorm.DB
+	for _, dsn := range cfg.Database.Replicas {
+		replica, err := NewReplica(dsn, &cfg.Database)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize replica: %w", err)
+		}
+		replicas = append(replicas, replica)
+	}
+	resolver := NewResolver(db, replicas, cfg.Database.ReplicaCheckInterval)
+	if len(replicas) > 0 {
+		logger.Info("Routing catalog reads to replicas", "replicas", len(replicas))
+	}
+
+	readCache, err := cache.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
 	}
 
 	// Create repository instances
-	return &Factory{
-		db:      db,
-		User:    NewUserRepo(db),
-		Product: NewProductRepo(db),
-		Order:   NewOrderRepo(db),
+	factory := &Factory{
+		db:                     db,
+		resolver:               resolver,
+		cache:                  readCache,
+		User:                   NewUserRepo(db),
+		Product:                NewProductRepo(resolver),
+		Order:                  NewOrderRepo(db),
+		Return:                 NewReturnRepo(db),
+		Stock:                  NewStockMovementRepo(db),
+		Reservation:            NewReservationRepo(db),
+		Warehouse:              NewWarehouseRepo(db),
+		Outbox:                 NewOutboxRepo(db),
+		DeadLetter:             NewDeadLetterRepo(db),
+		JobRun:                 NewJobRunRepo(db),
+		EmailLog:               NewEmailLogRepo(db),
+		Notification:           NewNotificationRepo(db),
+		NotificationPreference: NewNotificationPreferenceRepo(db),
+		Webhook:                NewWebhookRepo(db),
+		FeatureFlag:            NewFeatureFlagRepo(db),
+		TxManager:              NewTxManager(db, cfg.Database.TxMaxAttempts),
 		// Initialize other repositories here as you implement them
-	}, nil
+	}
+	if cfg.Database.Pool.StatsInterval > 0 {
+		var reportCtx context.Context
+		reportCtx, factory.stopReports = context.WithCancel(context.Background())
+		go reportPoolStats(reportCtx, db, cfg.Database.Pool.StatsInterval)
+	}
+	if readCache != nil {
+		factory.ProductCache = NewCachedProductRepo(factory.Product, readCache, cfg.Cache.TTL)
+		factory.Product = factory.ProductCache
+	}
+	return factory, nil
+}
+
+// DB returns the database connection shared by the repositories
+func (f *Factory) DB() *gorm.DB {
+	return f.db
 }
 
-// Close closes the database connection
+// Close closes the database connections and the cache
 func (f *Factory) Close() error {
+	if f.stopReports != nil {
+		f.stopReports()
+	}
+	if f.cache != nil {
+		if err := f.cache.Close(); err != nil {
+			return err
+		}
+	}
+	if err := f.resolver.Close(); err != nil {
+		return err
+	}
+
 	sqlDB, err := f.db.DB()
 	if err != nil {
 		return err
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// FeatureFlagRepository defines the interface for the feature flags set through the admin API
+type FeatureFlagRepository interface {
+	// List retrieves every stored flag
+	List(ctx context.Context) ([]*models.FeatureFlag, error)
+
+	// GetByKey retrieves a stored flag by key
+	GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error)
+
+	// Save creates the flag or replaces the stored one with the same key
+	Save(ctx context.Context, flag *models.FeatureFlag) error
+
+	// Delete removes a stored flag
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeatureFlagRepo implements the FeatureFlagRepository interface using PostgreSQL/GORM
+type FeatureFlagRepo struct {
+	db *gorm.DB
+}
+
+// NewFeatureFlagRepo creates a new feature flag repository
+func NewFeatureFlagRepo(db *gorm.DB) *FeatureFlagRepo {
+	return &FeatureFlagRepo{
+		db: db,
+	}
+}
+
+// List retrieves every stored flag
+func (r *FeatureFlagRepo) List(ctx context.Context) ([]*models.FeatureFlag, error) {
+	var flags []*models.FeatureFlag
+	result := conn(ctx, r.db).Order("key").Find(&flags)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return flags, nil
+}
+
+// GetByKey retrieves a stored flag by key
+func (r *FeatureFlagRepo) GetByKey(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	result := conn(ctx, r.db).Where("key = ?", key).First(&flag)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &flag, nil
+}
+
+// Save creates the flag or replaces the stored one with the same key
+func (r *FeatureFlagRepo) Save(ctx context.Context, flag *models.FeatureFlag) error {
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "enabled", "percentage", "rules", "updated_at"}),
+	}).Create(flag).Error
+}
+
+// Delete removes a stored flag
+func (r *FeatureFlagRepo) Delete(ctx context.Context, key string) error {
+	result := conn(ctx, r.db).Where("key = ?", key).Delete(&models.FeatureFlag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
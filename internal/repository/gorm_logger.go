@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecom-go/pkg/logger"
+
+	"gorm.io/gorm"
+	gormLogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// sqlLogger routes GORM's logs to the logger of the request or job that ran
+// the query, so that they carry its trace ID and fields
+type sqlLogger struct {
+	level         gormLogger.LogLevel
+	slowThreshold time.Duration
+}
+
+// newSQLLogger creates a GORM logger. level is "silent", "error", "warn" or
+// "info"; "info" logs every statement, "warn" only slow ones and "error" only
+// failing ones
+func newSQLLogger(level string, slowThreshold time.Duration) *sqlLogger {
+	return &sqlLogger{
+		level:         parseSQLLogLevel(level),
+		slowThreshold: slowThreshold,
+	}
+}
+
+// LogMode returns a copy of the logger with another level
+func (l *sqlLogger) LogMode(level gormLogger.LogLevel) gormLogger.Interface {
+	copied := *l
+	copied.level = level
+	return &copied
+}
+
+// Info logs a message of GORM itself
+func (l *sqlLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormLogger.Info {
+		logger.FromContext(ctx).Info(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Warn logs a warning of GORM itself
+func (l *sqlLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormLogger.Warn {
+		logger.FromContext(ctx).Warn(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Error logs an error of GORM itself
+func (l *sqlLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormLogger.Error {
+		logger.FromContext(ctx).Error(fmt.Sprintf(msg, data...))
+	}
+}
+
+// Trace logs a statement once it ran: as an error if it failed, as a warning
+// if it was slow, and otherwise only at the info level
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormLogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	failed := err != nil && !errors.Is(err, gorm.ErrRecordNotFound)
+	slow := l.slowThreshold > 0 && elapsed > l.slowThreshold
+	switch {
+	case failed && l.level >= gormLogger.Error:
+		sql, rows := fc()
+		logger.FromContext(ctx).Error("SQL query failed", append(queryArgs(sql, rows, elapsed, utils.FileWithLineNum()), "error", err)...)
+	case slow && l.level >= gormLogger.Warn:
+		sql, rows := fc()
+		logger.FromContext(ctx).Warn("Slow SQL query", append(queryArgs(sql, rows, elapsed, utils.FileWithLineNum()), "threshold", l.slowThreshold)...)
+	case l.level >= gormLogger.Info:
+		sql, rows := fc()
+		logger.FromContext(ctx).Info("SQL query", queryArgs(sql, rows, elapsed, utils.FileWithLineNum())...)
+	}
+}
+
+// ParamsFilter leaves the parameters out of logged statements, so that
+// passwords and personal data don't end up in the logs
+func (l *sqlLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// queryArgs returns the key-value pairs logged about a statement. source is
+// the code that ran it, utils.FileWithLineNum called by Trace itself: the
+// frames it skips are counted from its caller
+func queryArgs(sql string, rows int64, elapsed time.Duration, source string) []interface{} {
+	return []interface{}{
+		"sql", sql,
+		"rows", rows,
+		"elapsed", elapsed,
+		"source", source,
+	}
+}
+
+// parseSQLLogLevel converts a configured log level to GORM's, warnings by default
+func parseSQLLogLevel(level string) gormLogger.LogLevel {
+	switch level {
+	case "silent":
+		return gormLogger.Silent
+	case "error":
+		return gormLogger.Error
+	case "info":
+		return gormLogger.Info
+	default:
+		return gormLogger.Warn
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ecom-go/pkg/logger"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestSQLLoggerSource(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: newSQLLogger("info", time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logs bytes.Buffer
+	logger.SetOutput(&logs)
+	defer logger.SetOutput(os.Stdout)
+
+	mock.ExpectExec(`UPDATE products`).WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := db.WithContext(context.Background()).Exec("UPDATE products SET stock = stock - 1").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// The source is the code running the statement, not the logger
+	if !strings.Contains(logs.String(), "gorm_logger_test.go:") {
+		t.Errorf("logged source is not the caller: %s", logs.String())
+	}
+}
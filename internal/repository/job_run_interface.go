@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// JobRunRepository defines the interface for scheduled job runs
+type JobRunRepository interface {
+	// Lock takes a lock named after a job that is held across all replicas
+	// until release is called. acquired is false when another holder has it
+	Lock(ctx context.Context, name string) (release func(), acquired bool, err error)
+
+	// Start records the start of a run, returning ErrConflict when the run
+	// was already made for the same scheduled time
+	Start(ctx context.Context, run *models.JobRun) error
+
+	// Finish records the outcome of a run
+	Finish(ctx context.Context, run *models.JobRun) error
+
+	// List retrieves runs, newest first, optionally of a single job
+	List(ctx context.Context, job string, offset, limit int) ([]*models.JobRun, error)
+
+	// Count returns the number of runs, optionally of a single job
+	Count(ctx context.Context, job string) (int64, error)
+}
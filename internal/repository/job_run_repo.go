@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"hash/fnv"
+
+	"ecom-go/internal/models"
+	"ecom-go/pkg/logger"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// JobRunRepo implements the JobRunRepository interface using PostgreSQL/GORM
+type JobRunRepo struct {
+	db *gorm.DB
+}
+
+// NewJobRunRepo creates a new job run repository
+func NewJobRunRepo(db *gorm.DB) *JobRunRepo {
+	return &JobRunRepo{
+		db: db,
+	}
+}
+
+// Lock takes a session-level Postgres advisory lock on a connection reserved
+// for the holder. The lock is given up when released or when the connection
+// is lost, so a crashed replica never keeps a job locked.
+func (r *JobRunRepo) Lock(ctx context.Context, name string) (func(), bool, error) {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return nil, false, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := advisoryLockKey(name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	release := func() {
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			logger.Error("Failed to release advisory lock", "lock", name, "error", err)
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
+
+// Start records the start of a run
+func (r *JobRunRepo) Start(ctx context.Context, run *models.JobRun) error {
+	result := conn(ctx, r.db).Clauses(clause.OnConflict{DoNothing: true}).Create(run)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// Finish records the outcome of a run
+func (r *JobRunRepo) Finish(ctx context.Context, run *models.JobRun) error {
+	return conn(ctx, r.db).Model(run).Updates(map[string]interface{}{
+		"status":      run.Status,
+		"error":       run.Error,
+		"finished_at": run.FinishedAt,
+	}).Error
+}
+
+// List retrieves runs, newest first, optionally of a single job
+func (r *JobRunRepo) List(ctx context.Context, job string, offset, limit int) ([]*models.JobRun, error) {
+	var runs []*models.JobRun
+	result := r.byJob(ctx, job).Order("id DESC").Offset(offset).Limit(limit).Find(&runs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return runs, nil
+}
+
+// Count returns the number of runs, optionally of a single job
+func (r *JobRunRepo) Count(ctx context.Context, job string) (int64, error) {
+	var count int64
+	result := r.byJob(ctx, job).Model(&models.JobRun{}).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// byJob scopes a query to a job, or to all jobs when it is empty
+func (r *JobRunRepo) byJob(ctx context.Context, job string) *gorm.DB {
+	db := conn(ctx, r.db)
+	if job != "" {
+		db = db.Where("job = ?", job)
+	}
+	return db
+}
+
+// advisoryLockKey maps a lock name to a Postgres advisory lock key
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// NotificationRepository defines the interface for the in-app notification inbox
+type NotificationRepository interface {
+	// Create adds a notification to a user's inbox
+	Create(ctx context.Context, notification *models.Notification) error
+
+	// List retrieves a user's notifications, newest first, optionally only the unread ones
+	List(ctx context.Context, userID int, unreadOnly bool, offset, limit int) ([]*models.Notification, error)
+
+	// Count returns the number of a user's notifications, optionally only the unread ones
+	Count(ctx context.Context, userID int, unreadOnly bool) (int64, error)
+
+	// MarkRead marks one of a user's notifications as read
+	MarkRead(ctx context.Context, userID, id int) (*models.Notification, error)
+
+	// MarkAllRead marks all of a user's notifications as read and returns how many were unread
+	MarkAllRead(ctx context.Context, userID int) (int64, error)
+
+	// Exists reports whether a notification of the event type was already created for the worker message
+	Exists(ctx context.Context, messageID, eventType string) (bool, error)
+}
+
+// NotificationPreferenceRepository defines the interface for users' notification opt-outs
+type NotificationPreferenceRepository interface {
+	// List retrieves the preferences a user has set
+	List(ctx context.Context, userID int) ([]*models.NotificationPreference, error)
+
+	// Save creates or updates a user's preferences
+	Save(ctx context.Context, preferences []*models.NotificationPreference) error
+
+	// IsEnabled reports whether the user gets notified of the event type on the channel
+	IsEnabled(ctx context.Context, userID int, eventType, channel string) (bool, error)
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// NotificationRepo implements the NotificationRepository interface using PostgreSQL/GORM
+type NotificationRepo struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepo creates a new notification repository
+func NewNotificationRepo(db *gorm.DB) *NotificationRepo {
+	return &NotificationRepo{
+		db: db,
+	}
+}
+
+// Create adds a notification to a user's inbox
+func (r *NotificationRepo) Create(ctx context.Context, notification *models.Notification) error {
+	return conn(ctx, r.db).Create(notification).Error
+}
+
+// List retrieves a user's notifications, newest first, optionally only the unread ones
+func (r *NotificationRepo) List(ctx context.Context, userID int, unreadOnly bool, offset, limit int) ([]*models.Notification, error) {
+	var notifications []*models.Notification
+	result := r.byUser(ctx, userID, unreadOnly).Order("id DESC").Offset(offset).Limit(limit).Find(&notifications)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return notifications, nil
+}
+
+// Count returns the number of a user's notifications, optionally only the unread ones
+func (r *NotificationRepo) Count(ctx context.Context, userID int, unreadOnly bool) (int64, error) {
+	var count int64
+	result := r.byUser(ctx, userID, unreadOnly).Model(&models.Notification{}).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// MarkRead marks one of a user's notifications as read
+func (r *NotificationRepo) MarkRead(ctx context.Context, userID, id int) (*models.Notification, error) {
+	var notification models.Notification
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).First(&notification, id)
+		if result.Error != nil {
+			if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return result.Error
+		}
+		if notification.ReadAt != nil {
+			return nil
+		}
+
+		now := time.Now()
+		notification.ReadAt = &now
+		return tx.Model(&notification).Update("read_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks all of a user's notifications as read and returns how many were unread
+func (r *NotificationRepo) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	result := r.byUser(ctx, userID, true).Model(&models.Notification{}).Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// Exists reports whether a notification of the event type was already created for the worker message
+func (r *NotificationRepo) Exists(ctx context.Context, messageID, eventType string) (bool, error) {
+	var count int64
+	result := conn(ctx, r.db).Model(&models.Notification{}).
+		Where("message_id = ? AND event_type = ?", messageID, eventType).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+func (r *NotificationRepo) byUser(ctx context.Context, userID int, unreadOnly bool) *gorm.DB {
+	db := conn(ctx, r.db).Where("user_id = ?", userID)
+	if unreadOnly {
+		db = db.Where("read_at IS NULL")
+	}
+	return db
+}
+
+// NotificationPreferenceRepo implements the NotificationPreferenceRepository interface using PostgreSQL/GORM
+type NotificationPreferenceRepo struct {
+	db *gorm.DB
+}
+
+// NewNotificationPreferenceRepo creates a new notification preference repository
+func NewNotificationPreferenceRepo(db *gorm.DB) *NotificationPreferenceRepo {
+	return &NotificationPreferenceRepo{
+		db: db,
+	}
+}
+
+// List retrieves the preferences a user has set
+func (r *NotificationPreferenceRepo) List(ctx context.Context, userID int) ([]*models.NotificationPreference, error) {
+	var preferences []*models.NotificationPreference
+	result := conn(ctx, r.db).Where("user_id = ?", userID).Order("event_type, channel").Find(&preferences)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return preferences, nil
+}
+
+// Save creates or updates a user's preferences
+func (r *NotificationPreferenceRepo) Save(ctx context.Context, preferences []*models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(preferences).Error
+}
+
+// IsEnabled reports whether the user gets notified of the event type on the channel
+func (r *NotificationPreferenceRepo) IsEnabled(ctx context.Context, userID int, eventType, channel string) (bool, error) {
+	var preference models.NotificationPreference
+	result := conn(ctx, r.db).
+		Where("user_id = ? AND event_type = ? AND channel = ?", userID, eventType, channel).
+		Limit(1).Find(&preference)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return true, nil
+	}
+	return preference.Enabled, nil
+}
@@ -2,18 +2,35 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"ecom-go/internal/models"
 )
 
 // OrderRepository defines the interface for order data access
 type OrderRepository interface {
-	// Create adds a new order to the database
+	// Create adds a new order to the database and holds stock for it until its payment is due
 	Create(ctx context.Context, order *models.Order) error
 
 	// GetByID retrieves an order by ID
 	GetByID(ctx context.Context, id int) (*models.Order, error)
 
-	// List retrieves orders with pagination
-	List(ctx context.Context, offset, limit int) ([]*models.Order, error)
+	// GetByIDForUpdate retrieves an order by ID and locks it until the
+	// transaction in progress ends
+	GetByIDForUpdate(ctx context.Context, id int) (*models.Order, error)
+
+	// List retrieves the orders of a user with pagination, newest first, those
+	// of every user when userID is 0
+	List(ctx context.Context, userID, offset, limit int) ([]*models.Order, error)
+
+	// Update updates an existing order without touching its items, queuing
+	// an event when its status changed
+	Update(ctx context.Context, order *models.Order) error
+
+	// SalesByProduct sums the quantities of the products sold since the given time
+	SalesByProduct(ctx context.Context, since time.Time, productIDs ...int) (map[int]int, error)
+
+	// RollupDailySales recomputes the per-product sales totals of the day
+	// starting at the given time and returns the number of products sold
+	RollupDailySales(ctx context.Context, day time.Time) (int64, error)
 }
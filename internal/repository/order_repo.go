@@ -3,10 +3,15 @@ package repository
 import (
 	"context"
 	"errors"
+	"math"
+	"strconv"
+	"time"
 
+	"ecom-go/internal/events"
 	"ecom-go/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepo struct {
@@ -20,19 +25,107 @@ func NewOrderRepo(db *gorm.DB) *OrderRepo {
 	}
 }
 
-// Create adds a new order to the database
+// Create adds a new order to the database and holds stock for it until its
+// payment is due. Stock held for the cart the order was checked out from is
+// handed over to the order in the same transaction, which also queues the
+// order.created event.
 func (r *OrderRepo) Create(ctx context.Context, order *models.Order) error {
-	result := r.db.WithContext(ctx).Create(order)
-	if result.Error != nil {
-		return result.Error
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if order.CartID != "" {
+			if err := releaseReservations(tx, models.ReservationReferenceCart, order.CartID); err != nil {
+				return err
+			}
+		}
+
+		if err := priceItems(tx, order); err != nil {
+			return err
+		}
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		reservations := make([]*models.StockReservation, 0, len(order.Products))
+		for _, item := range order.Products {
+			reservations = append(reservations, &models.StockReservation{
+				ProductID:     item.ProductID,
+				WarehouseID:   item.WarehouseID,
+				Quantity:      item.Quantity,
+				ReferenceType: models.ReservationReferenceOrder,
+				ReferenceID:   strconv.Itoa(order.OrderID),
+				UserID:        &order.UserID,
+				ExpiresAt:     *order.PaymentDueAt,
+			})
+		}
+		if err := reserveStock(tx, reservations); err != nil {
+			return err
+		}
+
+		event := events.OrderCreatedEvent{
+			OrderID:    order.OrderID,
+			UserID:     order.UserID,
+			TotalPrice: order.TotalPrice,
+		}
+		for _, item := range order.Products {
+			event.Items = append(event.Items, events.OrderItem{
+				ProductID:   item.ProductID,
+				Quantity:    item.Quantity,
+				WarehouseID: item.WarehouseID,
+				UnitPrice:   item.UnitPrice,
+			})
+		}
+		return enqueueEvent(tx, events.AggregateOrder, order.OrderID, events.OrderCreated, event)
+	})
+}
+
+// priceItems sets the unit price of the order's items to the current price of
+// their products, which is what the customer pays and is refunded, and the
+// order's total from them
+func priceItems(tx *gorm.DB, order *models.Order) error {
+	productIDs := make([]int, 0, len(order.Products))
+	for _, item := range order.Products {
+		productIDs = append(productIDs, item.ProductID)
 	}
+
+	var products []models.Product
+	if err := tx.Select("id", "price").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return err
+	}
+	prices := make(map[int]float64, len(products))
+	for _, product := range products {
+		prices[product.ID] = product.Price
+	}
+
+	total := 0.0
+	for i := range order.Products {
+		price, ok := prices[order.Products[i].ProductID]
+		if !ok {
+			return ErrNotFound
+		}
+		order.Products[i].UnitPrice = price
+		total += price * float64(order.Products[i].Quantity)
+	}
+	order.TotalPrice = math.Round(total*100) / 100
 	return nil
 }
 
 // GetByID retrieves an order by ID
 func (r *OrderRepo) GetByID(ctx context.Context, id int) (*models.Order, error) {
 	var order models.Order
-	result := r.db.WithContext(ctx).First(&order, id)
+	result := conn(ctx, r.db).Preload("Products").First(&order, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &order, nil
+}
+
+// GetByIDForUpdate retrieves an order by ID and locks it until the transaction
+// in progress ends
+func (r *OrderRepo) GetByIDForUpdate(ctx context.Context, id int) (*models.Order, error) {
+	var order models.Order
+	result := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Products").First(&order, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
@@ -42,12 +135,99 @@ func (r *OrderRepo) GetByID(ctx context.Context, id int) (*models.Order, error)
 	return &order, nil
 }
 
-// List retrieves orders with pagination
-func (r *OrderRepo) List(ctx context.Context, offset, limit int) ([]*models.Order, error) {
+// List retrieves the orders of a user with pagination, newest first, those of
+// every user when userID is 0
+func (r *OrderRepo) List(ctx context.Context, userID, offset, limit int) ([]*models.Order, error) {
 	var orders []*models.Order
-	result := r.db.WithContext(ctx).Offset(offset).Limit(limit).Preload("Products").Find(&orders)
+	query := conn(ctx, r.db)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	result := query.Order("order_id DESC").Offset(offset).Limit(limit).Preload("Products").Find(&orders)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return orders, nil
 }
+
+// Update updates an existing order without touching its items. A change of
+// status queues an "order.<status>" event in the same transaction.
+func (r *OrderRepo) Update(ctx context.Context, order *models.Order) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var previousStatus string
+		err := tx.Model(&models.Order{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", order.OrderID).Pluck("status", &previousStatus).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Save(order).Error; err != nil {
+			return err
+		}
+
+		if previousStatus == order.Status {
+			return nil
+		}
+		return enqueueEvent(tx, events.AggregateOrder, order.OrderID, events.OrderStatusEvent(order.Status), events.OrderStatusChangedEvent{
+			OrderID:        order.OrderID,
+			UserID:         order.UserID,
+			PreviousStatus: previousStatus,
+			Status:         order.Status,
+		})
+	})
+}
+
+// SalesByProduct sums the quantities of the products sold since the given time.
+// Unpaid and canceled orders do not count as sales.
+func (r *OrderRepo) SalesByProduct(ctx context.Context, since time.Time, productIDs ...int) (map[int]int, error) {
+	sales := make(map[int]int, len(productIDs))
+	if len(productIDs) == 0 {
+		return sales, nil
+	}
+
+	var rows []struct {
+		ProductID int
+		Sold      int
+	}
+	result := conn(ctx, r.db).Model(&models.OrderItem{}).
+		Select("order_items.product_id, SUM(order_items.quantity) AS sold").
+		Joins("JOIN orders ON orders.order_id = order_items.related_order_id").
+		Where("orders.created_at >= ? AND orders.status NOT IN ? AND order_items.product_id IN ?",
+			since, []string{models.OrderStatusPending, models.OrderStatusCanceled}, productIDs).
+		Group("order_items.product_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	for _, row := range rows {
+		sales[row.ProductID] = row.Sold
+	}
+	return sales, nil
+}
+
+// RollupDailySales recomputes the per-product sales totals of the day
+// starting at the given time. Orders count towards the day they were paid
+// on, unless they were canceled since.
+func (r *OrderRepo) RollupDailySales(ctx context.Context, day time.Time) (int64, error) {
+	var rows int64
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("day = ?", day).Delete(&models.DailySales{}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Exec(`INSERT INTO daily_sales (day, product_id, quantity, orders, updated_at)
+			SELECT ?::date, order_items.product_id, SUM(order_items.quantity), COUNT(DISTINCT orders.order_id), NOW()
+			FROM order_items
+			JOIN orders ON orders.order_id = order_items.related_order_id
+			WHERE orders.paid_at >= ? AND orders.paid_at < ? AND orders.status <> ?
+			GROUP BY order_items.product_id`,
+			day, day, day.AddDate(0, 0, 1), models.OrderStatusCanceled)
+		if result.Error != nil {
+			return result.Error
+		}
+		rows = result.RowsAffected
+		return nil
+	})
+	return rows, err
+}
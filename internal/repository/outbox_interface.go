@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"ecom-go/internal/models"
+)
+
+// OutboxRepository defines the interface for publishing outbox events
+type OutboxRepository interface {
+	// Dispatch hands due events to publish, at most one per aggregate and
+	// oldest first, and records the outcome of each. An aggregate's later
+	// events wait until its earlier ones are published. Only one caller
+	// dispatches at a time, the others return immediately with no events.
+	Dispatch(ctx context.Context, limit int, publish func(event *models.OutboxEvent) error, backoff func(attempts int) time.Duration) (int, error)
+
+	// DeletePublished removes events published before the given time
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// outboxLockKey is the advisory lock held while dispatching outbox events
+const outboxLockKey = 7_230_001
+
+// OutboxRepo implements the OutboxRepository interface using PostgreSQL/GORM
+type OutboxRepo struct {
+	db *gorm.DB
+}
+
+// NewOutboxRepo creates a new outbox repository
+func NewOutboxRepo(db *gorm.DB) *OutboxRepo {
+	return &OutboxRepo{
+		db: db,
+	}
+}
+
+// Dispatch hands due events to publish and records the outcome of each
+func (r *OutboxRepo) Dispatch(ctx context.Context, limit int, publish func(event *models.OutboxEvent) error, backoff func(attempts int) time.Duration) (int, error) {
+	published := 0
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", outboxLockKey).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			// Another relay is dispatching
+			return nil
+		}
+
+		// The oldest unpublished event of each aggregate, if it is due
+		var events []*models.OutboxEvent
+		err := tx.Raw(`SELECT * FROM (
+				SELECT DISTINCT ON (aggregate_type, aggregate_id) * FROM outbox_events
+				WHERE published_at IS NULL
+				ORDER BY aggregate_type, aggregate_id, id
+			) heads
+			WHERE next_attempt_at <= ?
+			ORDER BY id
+			LIMIT ?`, time.Now(), limit).Scan(&events).Error
+		if err != nil {
+			return err
+		}
+
+		for _, event := range events {
+			now := time.Now()
+			updates := map[string]interface{}{"attempts": event.Attempts + 1}
+			if err := publish(event); err != nil {
+				updates["last_error"] = err.Error()
+				updates["next_attempt_at"] = now.Add(backoff(event.Attempts + 1))
+			} else {
+				updates["last_error"] = ""
+				updates["published_at"] = now
+				published++
+			}
+			if err := tx.Model(&models.OutboxEvent{}).Where("id = ?", event.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return published, err
+}
+
+// DeletePublished removes events published before the given time
+func (r *OutboxRepo) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("published_at < ?", before).Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// enqueueEvent writes an event to the outbox. It must be called inside the
+// transaction making the change the event describes.
+func enqueueEvent(tx *gorm.DB, aggregateType string, aggregateID interface{}, eventType string, payload interface{}) error {
+	return enqueueToQueue(tx, "", aggregateType, aggregateID, eventType, payload)
+}
+
+// enqueueToQueue writes an event to the outbox that is sent straight to a
+// single queue rather than to the queues bound to its type
+func enqueueToQueue(tx *gorm.DB, queue, aggregateType string, aggregateID interface{}, eventType string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding %s event: %w", eventType, err)
+	}
+	return tx.Create(&models.OutboxEvent{
+		AggregateType: aggregateType,
+		AggregateID:   fmt.Sprint(aggregateID),
+		EventType:     eventType,
+		Payload:       body,
+		Queue:         queue,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"ecom-go/internal/cache"
+	"ecom-go/internal/models"
+	"ecom-go/pkg/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache keys of product reads. List results are stored under the current list
+// generation, which is bumped to invalidate every cached list at once
+const (
+	productKeyPrefix      = "product:"
+	productListGeneration = "products:list:generation"
+	productListKeyPrefix  = "products:list:"
+)
+
+// CachedProductRepo decorates a ProductRepository, caching product reads.
+// Writes through the decorator invalidate what they change once committed,
+// stock changes made elsewhere must be reported with Invalidate. Reads within
+// a transaction, which may see its uncommitted changes, bypass the cache.
+// Cache failures fall back to the database
+type CachedProductRepo struct {
+	ProductRepository
+	cache cache.Cache
+	// ttl holds the time.Duration cached values live for, changed by SetTTL
+	ttl atomic.Int64
+	// group collapses concurrent misses of the same key into one database read
+	group singleflight.Group
+}
+
+// NewCachedProductRepo creates a new caching product repository
+func NewCachedProductRepo(repo ProductRepository, c cache.Cache, ttl time.Duration) *CachedProductRepo {
+	r := &CachedProductRepo{
+		ProductRepository: repo,
+		cache:             c,
+	}
+	r.SetTTL(ttl)
+	return r
+}
+
+// SetTTL changes how long values cached from now on live
+func (r *CachedProductRepo) SetTTL(ttl time.Duration) {
+	r.ttl.Store(int64(ttl))
+}
+
+// Create adds a new product to the database and invalidates the cached lists
+func (r *CachedProductRepo) Create(ctx context.Context, product *models.Product) error {
+	if err := r.ProductRepository.Create(ctx, product); err != nil {
+		return err
+	}
+	afterCommit(ctx, func() { r.invalidateLists(ctx) })
+	return nil
+}
+
+// GetByID retrieves a product by ID, from the cache when possible
+func (r *CachedProductRepo) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	if inTx(ctx) {
+		return r.ProductRepository.GetByID(ctx, id)
+	}
+
+	var product *models.Product
+	err := r.read(ctx, productKeyPrefix+strconv.Itoa(id), &product, func(ctx context.Context) (interface{}, error) {
+		return r.ProductRepository.GetByID(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+// List retrieves all products, from the cache when possible
+func (r *CachedProductRepo) List(ctx context.Context) ([]*models.Product, error) {
+	if inTx(ctx) {
+		return r.ProductRepository.List(ctx)
+	}
+
+	generation := "0"
+	if value, err := r.cache.Get(ctx, productListGeneration); err == nil {
+		generation = string(value)
+	} else if !errors.Is(err, cache.ErrMiss) {
+		logger.FromContext(ctx).Warn("Failed to read product list generation from cache", "error", err)
+		return r.ProductRepository.List(ctx)
+	}
+
+	var products []*models.Product
+	err := r.read(ctx, productListKeyPrefix+generation+":all", &products, func(ctx context.Context) (interface{}, error) {
+		return r.ProductRepository.List(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// Update updates an existing product and invalidates its cached copies
+func (r *CachedProductRepo) Update(ctx context.Context, product *models.Product) error {
+	if err := r.ProductRepository.Update(ctx, product); err != nil {
+		return err
+	}
+	afterCommit(ctx, func() { r.Invalidate(ctx, product.ID) })
+	return nil
+}
+
+// Delete removes a product from the database and invalidates its cached copies
+func (r *CachedProductRepo) Delete(ctx context.Context, id int) error {
+	if err := r.ProductRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	afterCommit(ctx, func() { r.Invalidate(ctx, id) })
+	return nil
+}
+
+// MarkLowStock records when a product's stock fell to its reorder threshold
+func (r *CachedProductRepo) MarkLowStock(ctx context.Context, id int, since time.Time) error {
+	if err := r.ProductRepository.MarkLowStock(ctx, id, since); err != nil {
+		return err
+	}
+	afterCommit(ctx, func() { r.Invalidate(ctx, id) })
+	return nil
+}
+
+// ClearRecoveredLowStock resets the low-stock marker of products that were
+// restocked and invalidates their cached copies
+func (r *CachedProductRepo) ClearRecoveredLowStock(ctx context.Context) ([]int, error) {
+	ids, err := r.ProductRepository.ClearRecoveredLowStock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > 0 {
+		afterCommit(ctx, func() { r.Invalidate(ctx, ids...) })
+	}
+	return ids, nil
+}
+
+// Invalidate drops the cached copies of products that changed
+func (r *CachedProductRepo) Invalidate(ctx context.Context, ids ...int) {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, productKeyPrefix+strconv.Itoa(id))
+	}
+	if err := r.cache.Delete(ctx, keys...); err != nil {
+		logger.FromContext(ctx).Warn("Failed to invalidate cached products", "product_ids", ids, "error", err)
+	}
+	r.invalidateLists(ctx)
+}
+
+func (r *CachedProductRepo) invalidateLists(ctx context.Context) {
+	if _, err := r.cache.Incr(ctx, productListGeneration); err != nil {
+		logger.FromContext(ctx).Warn("Failed to invalidate cached product lists", "error", err)
+	}
+}
+
+// read decodes the value cached under the key into dest, loading and caching
+// it on a miss. Values are loaded from the primary, so that a replica lagging
+// behind the write that invalidated them is not cached. Concurrent misses of a
+// key share a single load, which is detached from the caller's cancellation: a
+// caller giving up returns without failing the others
+func (r *CachedProductRepo) read(ctx context.Context, key string, dest interface{}, load func(ctx context.Context) (interface{}, error)) error {
+	value, err := r.cache.Get(ctx, key)
+	if err == nil {
+		if err := json.Unmarshal(value, dest); err == nil {
+			return nil
+		}
+		logger.FromContext(ctx).Warn("Discarding undecodable cached value", "key", key)
+	} else if !errors.Is(err, cache.ErrMiss) {
+		logger.FromContext(ctx).Warn("Failed to read from cache", "key", key, "error", err)
+	}
+
+	loadCtx := WithPrimary(context.WithoutCancel(ctx))
+	results := r.group.DoChan(key, func() (interface{}, error) {
+		loaded, err := load(loadCtx)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.Marshal(loaded)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.cache.Set(loadCtx, key, encoded, time.Duration(r.ttl.Load())); err != nil {
+			logger.FromContext(loadCtx).Warn("Failed to write to cache", "key", key, "error", err)
+		}
+		return encoded, nil
+	})
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return result.Err
+		}
+		// Every caller decodes its own copy, so callers can modify what they get
+		return json.Unmarshal(result.Val.([]byte), dest)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
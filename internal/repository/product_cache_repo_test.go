@@ -0,0 +1,281 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"ecom-go/internal/cache"
+	"ecom-go/internal/models"
+)
+
+// countingProductRepo is a ProductRepository over a map, counting the reads
+// that reach it. Reads wait for release when it is set
+type countingProductRepo struct {
+	ProductRepository
+	mu       sync.Mutex
+	products map[int]models.Product
+	gets     atomic.Int32
+	replica  atomic.Int32 // Reads that could have gone to a replica
+	lists    atomic.Int32
+	release  chan struct{}
+}
+
+func newCountingProductRepo(products ...models.Product) *countingProductRepo {
+	r := &countingProductRepo{products: make(map[int]models.Product)}
+	for _, product := range products {
+		r.products[product.ID] = product
+	}
+	return r
+}
+
+func (r *countingProductRepo) GetByID(ctx context.Context, id int) (*models.Product, error) {
+	r.gets.Add(1)
+	if !ReadsFromPrimary(ctx) {
+		r.replica.Add(1)
+	}
+	if r.release != nil {
+		<-r.release
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	product, ok := r.products[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return &product, nil
+}
+
+func (r *countingProductRepo) List(ctx context.Context) ([]*models.Product, error) {
+	r.lists.Add(1)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	products := make([]*models.Product, 0, len(r.products))
+	for _, product := range r.products {
+		products = append(products, &product)
+	}
+	return products, nil
+}
+
+func (r *countingProductRepo) Update(ctx context.Context, product *models.Product) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.products[product.ID] = *product
+	return nil
+}
+
+func (r *countingProductRepo) ClearRecoveredLowStock(ctx context.Context) ([]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []int
+	for id, product := range r.products {
+		if product.LowStockSince != nil && product.Stock > product.ReorderThreshold {
+			product.LowStockSince = nil
+			r.products[id] = product
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func TestCachedProductRepoReads(t *testing.T) {
+	ctx := context.Background()
+	repo := newCountingProductRepo(models.Product{ID: 1, Name: "Kettle", Price: 30})
+	cached := NewCachedProductRepo(repo, cache.NewMemoryCache(), time.Minute)
+
+	for range 3 {
+		product, err := cached.GetByID(ctx, 1)
+		if err != nil || product.Name != "Kettle" {
+			t.Fatalf("GetByID = %+v, %v", product, err)
+		}
+		// Callers get their own copy
+		product.Name = "changed"
+	}
+	if n := repo.gets.Load(); n != 1 {
+		t.Errorf("%d database reads for 3 GetByID, want 1", n)
+	}
+	if n := repo.replica.Load(); n != 0 {
+		t.Errorf("%d cached reads from a replica, want them from the primary", n)
+	}
+
+	if _, err := cached.GetByID(ctx, 2); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetByID of a missing product = %v, want ErrNotFound", err)
+	}
+
+	for range 2 {
+		if products, err := cached.List(ctx); err != nil || len(products) != 1 {
+			t.Fatalf("List = %v, %v", products, err)
+		}
+	}
+	if n := repo.lists.Load(); n != 1 {
+		t.Errorf("%d database reads for 2 List, want 1", n)
+	}
+}
+
+func TestCachedProductRepoInvalidation(t *testing.T) {
+	ctx := context.Background()
+	since := time.Now()
+	repo := newCountingProductRepo(
+		models.Product{ID: 1, Name: "Kettle", Stock: 10, ReorderThreshold: 5, LowStockSince: &since},
+		models.Product{ID: 2, Name: "Mug", Stock: 1, ReorderThreshold: 5, LowStockSince: &since},
+	)
+	cached := NewCachedProductRepo(repo, cache.NewMemoryCache(), time.Minute)
+
+	tests := []struct {
+		name  string
+		write func() error
+		check func(t *testing.T)
+	}{
+		{
+			name: "update",
+			write: func() error {
+				return cached.Update(ctx, &models.Product{ID: 1, Name: "Tea kettle", Stock: 10, ReorderThreshold: 5, LowStockSince: &since})
+			},
+			check: func(t *testing.T) {
+				if product, _ := cached.GetByID(ctx, 1); product.Name != "Tea kettle" {
+					t.Errorf("GetByID after Update = %q, want the new name", product.Name)
+				}
+			},
+		},
+		{
+			name: "clear recovered low stock",
+			write: func() error {
+				ids, err := cached.ClearRecoveredLowStock(ctx)
+				if len(ids) != 1 || ids[0] != 1 {
+					t.Errorf("ClearRecoveredLowStock = %v, want [1]", ids)
+				}
+				return err
+			},
+			check: func(t *testing.T) {
+				if product, _ := cached.GetByID(ctx, 1); product.LowStockSince != nil {
+					t.Error("restocked product still cached as low on stock")
+				}
+				if product, _ := cached.GetByID(ctx, 2); product.LowStockSince == nil {
+					t.Error("product still low on stock lost its marker")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Cache the product and the list before the write
+			if _, err := cached.GetByID(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cached.GetByID(ctx, 2); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := cached.List(ctx); err != nil {
+				t.Fatal(err)
+			}
+			lists := repo.lists.Load()
+
+			if err := tt.write(); err != nil {
+				t.Fatal(err)
+			}
+			tt.check(t)
+			if _, err := cached.List(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if repo.lists.Load() == lists {
+				t.Error("List served from the cache after a write")
+			}
+		})
+	}
+}
+
+func TestCachedProductRepoSharedLoad(t *testing.T) {
+	repo := newCountingProductRepo(models.Product{ID: 1, Name: "Kettle"})
+	repo.release = make(chan struct{})
+	cached := NewCachedProductRepo(repo, cache.NewMemoryCache(), time.Minute)
+
+	// The first caller starts the load and gives up waiting for it
+	canceled, cancel := context.WithCancel(context.Background())
+	firstDone := make(chan error)
+	go func() {
+		_, err := cached.GetByID(canceled, 1)
+		firstDone <- err
+	}()
+	for repo.gets.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	const waiting = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, waiting)
+	for range waiting {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			product, err := cached.GetByID(context.Background(), 1)
+			if err == nil && product.Name != "Kettle" {
+				err = errors.New("wrong product " + product.Name)
+			}
+			errs <- err
+		}()
+	}
+
+	cancel()
+	if err := <-firstDone; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller got %v, want context.Canceled", err)
+	}
+
+	// Let the waiting callers join the load before it finishes
+	time.Sleep(20 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("caller sharing the load of a canceled one: %v", err)
+		}
+	}
+	if n := repo.gets.Load(); n != 1 {
+		t.Errorf("%d database reads, want 1 shared by every caller", n)
+	}
+}
+
+func TestCachedProductRepoWithinTx(t *testing.T) {
+	ctx := context.Background()
+	repo := newCountingProductRepo(models.Product{ID: 1, Name: "Kettle"})
+	c := cache.NewMemoryCache()
+	cached := NewCachedProductRepo(repo, c, time.Minute)
+	m, mock := newMockTxManager(t, 1)
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	if _, err := cached.GetByID(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	err := m.WithinTx(ctx, func(ctx context.Context) error {
+		if err := cached.Update(ctx, &models.Product{ID: 1, Name: "Tea kettle"}); err != nil {
+			return err
+		}
+		// The transaction reads its own changes, which stay out of the cache
+		product, err := cached.GetByID(ctx, 1)
+		if err != nil || product.Name != "Tea kettle" {
+			t.Errorf("GetByID within the transaction = %+v, %v, want the update", product, err)
+		}
+		if value, err := c.Get(ctx, productKeyPrefix+"1"); err != nil || !strings.Contains(string(value), `"Kettle"`) {
+			t.Errorf("cached %s, %v before the commit, want the committed product", value, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get(ctx, productKeyPrefix+"1"); !errors.Is(err, cache.ErrMiss) {
+		t.Errorf("cached product after the commit: %v, want it invalidated", err)
+	}
+	if product, _ := cached.GetByID(ctx, 1); product.Name != "Tea kettle" {
+		t.Errorf("GetByID after the commit = %q, want the update", product.Name)
+	}
+}
@@ -2,13 +2,14 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"ecom-go/internal/models"
 )
 
 // ProductRepository defines the interface for product data access
 type ProductRepository interface {
-	// Create adds a new product to the database
+	// Create adds a new product to the database and publishes product.created
 	Create(ctx context.Context, product *models.Product) error
 
 	// GetByID retrieves a product by ID
@@ -17,15 +18,26 @@ type ProductRepository interface {
 	// List retrieves all products
 	List(ctx context.Context) ([]*models.Product, error)
 
-	// Update updates an existing product
+	// Update updates an existing product. Stock is only changed through the stock ledger
+	// and the low-stock marker only by MarkLowStock and ClearRecoveredLowStock. Publishes product.updated
 	Update(ctx context.Context, product *models.Product) error
 
-	// Delete removes a product from the database
+	// Delete removes a product from the database and publishes product.deleted
 	Delete(ctx context.Context, id int) error
 
 	// Count returns the total number of products
 	Count(ctx context.Context) (int64, error)
 
+	// ListLowStock retrieves the products whose stock is at or below their reorder threshold
+	ListLowStock(ctx context.Context) ([]*models.Product, error)
+
+	// MarkLowStock records when a product's stock fell to its reorder threshold
+	MarkLowStock(ctx context.Context, id int, since time.Time) error
+
+	// ClearRecoveredLowStock resets the low-stock marker of products that were
+	// restocked and returns their IDs
+	ClearRecoveredLowStock(ctx context.Context) ([]int, error)
+
 	// // ListWithPagination retrieves products with pagination
 	// ListWithPagination(ctx context.Context, offset, limit int) ([]*models.Product, error)
 }
@@ -3,37 +3,61 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
+	"ecom-go/internal/events"
 	"ecom-go/internal/models"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // ProductRepository defines the interface for product data access
 type ProductRepo struct {
-	db *gorm.DB
+	db *Resolver
 }
 
-// NewProductRepo creates a new product repository
-func NewProductRepo(db *gorm.DB) *ProductRepo {
+// NewProductRepo creates a new product repository. GetByID, List and Count
+// read from the replicas, everything else uses the primary
+func NewProductRepo(db *Resolver) *ProductRepo {
 	return &ProductRepo{
 		db: db,
 	}
 }
 
-// Create adds a new product to the database
+// Create adds a new product to the database and records its initial stock in the ledger
 func (r *ProductRepo) Create(ctx context.Context, product *models.Product) error {
-	result := r.db.WithContext(ctx).Create(product)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
+	return r.db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		initialStock := product.Stock
+		product.Stock = 0
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+		if err := enqueueEvent(tx, events.AggregateProduct, product.ID, events.ProductCreated, productChangedEvent(product)); err != nil {
+			return err
+		}
+		if initialStock == 0 {
+			return nil
+		}
+
+		movement := &models.StockMovement{
+			ProductID: product.ID,
+			Quantity:  initialStock,
+			Reason:    models.StockReasonRestock,
+			Note:      "initial stock",
+		}
+		if err := applyStockMovement(tx, movement); err != nil {
+			return err
+		}
+		product.Stock = movement.BalanceAfter
+		return nil
+	})
 }
 
 // GetByID retrieves a product by ID
 func (r *ProductRepo) GetByID(ctx context.Context, id int) (*models.Product, error) {
 	var product models.Product
-	result := r.db.WithContext(ctx).First(&product, id)
+	result := r.db.Reader(ctx).First(&product, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
@@ -46,37 +70,94 @@ func (r *ProductRepo) GetByID(ctx context.Context, id int) (*models.Product, err
 // List retrieves all products
 func (r *ProductRepo) List(ctx context.Context) ([]*models.Product, error) {
 	var products []*models.Product
-	result := r.db.WithContext(ctx).Find(&products)
+	result := r.db.Reader(ctx).Find(&products)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return products, nil
 }
 
-// Update updates an existing product
+// Update updates an existing product. Stock is only changed through the stock ledger
+// and the low-stock marker only by MarkLowStock and ClearRecoveredLowStock.
 func (r *ProductRepo) Update(ctx context.Context, product *models.Product) error {
-	result := r.db.WithContext(ctx).Save(product)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
+	return r.db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("stock", "low_stock_since").Save(product).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, events.AggregateProduct, product.ID, events.ProductUpdated, productChangedEvent(product))
+	})
 }
 
 // Delete removes a product from the database
 func (r *ProductRepo) Delete(ctx context.Context, id int) error {
-	result := r.db.WithContext(ctx).Delete(&models.Product{}, id)
-	if result.Error != nil {
-		return result.Error
+	return r.db.Writer(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.Product{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return enqueueEvent(tx, events.AggregateProduct, id, events.ProductDeleted, events.ProductChangedEvent{ProductID: id})
+	})
+}
+
+// productChangedEvent returns the payload of the event published when the product changes
+func productChangedEvent(product *models.Product) events.ProductChangedEvent {
+	return events.ProductChangedEvent{
+		ProductID:        product.ID,
+		Name:             product.Name,
+		Description:      product.Description,
+		Price:            product.Price,
+		ReorderThreshold: product.ReorderThreshold,
 	}
-	return nil
 }
 
 // Count returns the total number of products
 func (r *ProductRepo) Count(ctx context.Context) (int64, error) {
 	var count int64
-	result := r.db.WithContext(ctx).Model(&models.Product{}).Count(&count)
+	result := r.db.Reader(ctx).Model(&models.Product{}).Count(&count)
 	if result.Error != nil {
 		return 0, result.Error
 	}
 	return count, nil
 }
+
+// ListLowStock retrieves the products whose stock is at or below their reorder threshold
+func (r *ProductRepo) ListLowStock(ctx context.Context) ([]*models.Product, error) {
+	// Alerts need the current stock, so this reads from the primary
+	var products []*models.Product
+	result := conn(ctx, r.db.Primary()).Where("reorder_threshold > 0 AND stock <= reorder_threshold").
+		Order("stock - reorder_threshold, id").Find(&products)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return products, nil
+}
+
+// MarkLowStock records when a product's stock fell to its reorder threshold
+func (r *ProductRepo) MarkLowStock(ctx context.Context, id int, since time.Time) error {
+	result := r.db.Writer(ctx).Model(&models.Product{}).Where("id = ?", id).UpdateColumn("low_stock_since", since)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// ClearRecoveredLowStock resets the low-stock marker of products that were
+// restocked and returns their IDs
+func (r *ProductRepo) ClearRecoveredLowStock(ctx context.Context) ([]int, error) {
+	var cleared []models.Product
+	result := r.db.Writer(ctx).Model(&cleared).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("low_stock_since IS NOT NULL AND (reorder_threshold = 0 OR stock > reorder_threshold)").
+		UpdateColumn("low_stock_since", nil)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	ids := make([]int, 0, len(cleared))
+	for _, product := range cleared {
+		ids = append(ids, product.ID)
+	}
+	return ids, nil
+}
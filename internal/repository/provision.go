@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"ecom-go/internal/config"
+	"ecom-go/pkg/logger"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+)
+
+// maintenanceDatabase is the database connected to while the application's one is created
+const maintenanceDatabase = "postgres"
+
+// Postgres error codes of a missing database and of creating one that already exists
+const (
+	codeInvalidCatalogName = "3D000"
+	codeDuplicateDatabase  = "42P04"
+)
+
+// ProvisionDatabase creates the configured database if it doesn't already exist.
+// It connects to the "postgres" maintenance database with the configured
+// credentials, so the user needs the CREATEDB privilege
+func ProvisionDatabase(ctx context.Context, cfg *config.DatabaseConfig) error {
+	maintenance := *cfg
+	maintenance.Name = maintenanceDatabase
+	logger.Info("Provisioning database", "database", cfg.Name, "dsn", maintenance.GetRedactedDSN())
+
+	db, err := sql.Open("postgres", maintenance.GetDSN())
+	if err != nil {
+		return fmt.Errorf("failed to connect to maintenance database: %w", err)
+	}
+	defer db.Close()
+
+	var exists bool
+	if err := db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM pg_database WHERE datname = $1)", cfg.Name,
+	).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check if database exists: %w", err)
+	}
+	if exists {
+		logger.Info("Database already exists", "database", cfg.Name)
+		return nil
+	}
+
+	// CREATE DATABASE takes no parameters, the name is quoted as an identifier instead
+	if _, err := db.ExecContext(ctx, "CREATE DATABASE "+pq.QuoteIdentifier(cfg.Name)); err != nil {
+		// Another replica may have created it since the check
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == codeDuplicateDatabase {
+			logger.Info("Database already exists", "database", cfg.Name)
+			return nil
+		}
+		return fmt.Errorf("failed to create database %q: %w", cfg.Name, err)
+	}
+
+	logger.Info("Database created", "database", cfg.Name)
+	return nil
+}
+
+// isMissingDatabase reports whether a connection failed because the database doesn't exist
+func isMissingDatabase(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == codeInvalidCatalogName
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"ecom-go/internal/models"
+)
+
+// ReservationRepository defines the interface for stock reservation data access
+type ReservationRepository interface {
+	// Reserve holds stock for a cart or order, replacing any holds it already has
+	Reserve(ctx context.Context, referenceType, referenceID string, reservations ...*models.StockReservation) error
+
+	// ListActive retrieves the active reservations of a cart or order
+	ListActive(ctx context.Context, referenceType, referenceID string) ([]*models.StockReservation, error)
+
+	// Release gives back the stock held for a cart or order
+	Release(ctx context.Context, referenceType, referenceID string) error
+
+	// Commit turns the reservations of a cart or order into stock deductions,
+	// recording a copy of movement for every reserved product
+	Commit(ctx context.Context, referenceType, referenceID string, movement models.StockMovement) error
+
+	// ReservedQuantities returns the currently held quantity per product
+	ReservedQuantities(ctx context.Context, productIDs ...int) (map[int]int, error)
+
+	// ExpireStale marks reservations that expired before now as expired
+	ExpireStale(ctx context.Context, now time.Time) (int64, error)
+
+	// RemindAbandonedCarts queues a cart.abandoned event for every cart of a
+	// known user whose reservations last expired within the given period, that
+	// was not checked out and whose user was not reminded of it before
+	RemindAbandonedCarts(ctx context.Context, expiredAfter, expiredBefore time.Time) (int, error)
+}
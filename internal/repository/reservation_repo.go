@@ -0,0 +1,345 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReservationRepo implements the ReservationRepository interface using PostgreSQL/GORM
+type ReservationRepo struct {
+	db *gorm.DB
+}
+
+// NewReservationRepo creates a new stock reservation repository
+func NewReservationRepo(db *gorm.DB) *ReservationRepo {
+	return &ReservationRepo{
+		db: db,
+	}
+}
+
+// Reserve holds stock for a cart or order, replacing any holds it already has
+func (r *ReservationRepo) Reserve(ctx context.Context, referenceType, referenceID string, reservations ...*models.StockReservation) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := releaseReservations(tx, referenceType, referenceID); err != nil {
+			return err
+		}
+		for _, reservation := range reservations {
+			reservation.ReferenceType = referenceType
+			reservation.ReferenceID = referenceID
+		}
+		return reserveStock(tx, reservations)
+	})
+}
+
+// ListActive retrieves the active reservations of a cart or order
+func (r *ReservationRepo) ListActive(ctx context.Context, referenceType, referenceID string) ([]*models.StockReservation, error) {
+	var reservations []*models.StockReservation
+	result := conn(ctx, r.db).
+		Where("reference_type = ? AND reference_id = ? AND status = ?", referenceType, referenceID, models.ReservationStatusActive).
+		Find(&reservations)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return reservations, nil
+}
+
+// Release gives back the stock held for a cart or order
+func (r *ReservationRepo) Release(ctx context.Context, referenceType, referenceID string) error {
+	return releaseReservations(conn(ctx, r.db), referenceType, referenceID)
+}
+
+// Commit turns the reservations of a cart or order into stock deductions,
+// recording a copy of movement for every reserved product. Reservations that
+// expired are still committed as long as the stock has not been promised to
+// someone else in the meantime.
+func (r *ReservationRepo) Commit(ctx context.Context, referenceType, referenceID string, movement models.StockMovement) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var reservations []*models.StockReservation
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("reference_type = ? AND reference_id = ? AND status IN ?", referenceType, referenceID,
+				[]string{models.ReservationStatusActive, models.ReservationStatusExpired}).
+			Order("product_id").
+			Find(&reservations)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(reservations) == 0 {
+			return ErrNotFound
+		}
+
+		// A product can be held more than once for an order, from different
+		// warehouses, so availability is checked for the order's total of it
+		ids := reservationIDs(reservations)
+		for _, demand := range stockDemands(reservations) {
+			if err := checkAvailability(tx, demand, ids); err != nil {
+				return err
+			}
+		}
+
+		for _, reservation := range reservations {
+			deduction := movement
+			deduction.ProductID = reservation.ProductID
+			deduction.WarehouseID = reservation.WarehouseID
+			deduction.Quantity = -reservation.Quantity
+			if err := applyStockMovement(tx, &deduction); err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&models.StockReservation{}).
+			Where("id IN ?", ids).
+			Update("status", models.ReservationStatusCommitted).Error
+	})
+}
+
+// ReservedQuantities returns the currently held quantity per product
+func (r *ReservationRepo) ReservedQuantities(ctx context.Context, productIDs ...int) (map[int]int, error) {
+	var rows []struct {
+		ProductID int
+		Reserved  int
+	}
+	result := conn(ctx, r.db).Model(&models.StockReservation{}).
+		Select("product_id, SUM(quantity) AS reserved").
+		Where("product_id IN ? AND status = ? AND expires_at > ?", productIDs, models.ReservationStatusActive, time.Now()).
+		Group("product_id").
+		Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	reserved := make(map[int]int, len(rows))
+	for _, row := range rows {
+		reserved[row.ProductID] = row.Reserved
+	}
+	return reserved, nil
+}
+
+// ExpireStale marks reservations that expired before now as expired
+func (r *ReservationRepo) ExpireStale(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).Model(&models.StockReservation{}).
+		Where("status = ? AND expires_at <= ?", models.ReservationStatusActive, now).
+		Update("status", models.ReservationStatusExpired)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// RemindAbandonedCarts queues a cart.abandoned event for every abandoned cart
+// not reminded of before. Each reminder and its event are written in their own
+// transaction so that a failure does not hold back the other carts.
+func (r *ReservationRepo) RemindAbandonedCarts(ctx context.Context, expiredAfter, expiredBefore time.Time) (int, error) {
+	var carts []struct {
+		CartID    string
+		UserID    int
+		ExpiredAt time.Time
+	}
+	err := conn(ctx, r.db).Raw(`SELECT reference_id AS cart_id, MAX(user_id) AS user_id,
+			MAX(expires_at) FILTER (WHERE status = ?) AS expired_at
+		FROM stock_reservations
+		WHERE reference_type = ? AND user_id IS NOT NULL
+		GROUP BY reference_id
+		HAVING NOT bool_or(status = ?)
+			AND MAX(expires_at) FILTER (WHERE status = ?) > ?
+			AND MAX(expires_at) FILTER (WHERE status = ?) <= ?
+			AND NOT EXISTS (SELECT 1 FROM orders WHERE orders.cart_id = reference_id)
+			AND NOT EXISTS (SELECT 1 FROM cart_reminders WHERE cart_reminders.cart_id = reference_id)`,
+		models.ReservationStatusExpired, models.ReservationReferenceCart, models.ReservationStatusActive,
+		models.ReservationStatusExpired, expiredAfter, models.ReservationStatusExpired, expiredBefore).
+		Scan(&carts).Error
+	if err != nil {
+		return 0, err
+	}
+
+	reminded := 0
+	for _, cart := range carts {
+		err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&models.CartReminder{CartID: cart.CartID, UserID: cart.UserID})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+
+			// The cart's contents are what it held when its last hold expired
+			var reservations []*models.StockReservation
+			err := tx.Where("reference_type = ? AND reference_id = ? AND status = ? AND expires_at = ?",
+				models.ReservationReferenceCart, cart.CartID, models.ReservationStatusExpired, cart.ExpiredAt).
+				Order("product_id").Find(&reservations).Error
+			if err != nil {
+				return err
+			}
+
+			event := events.CartAbandonedEvent{CartID: cart.CartID, UserID: cart.UserID}
+			for _, reservation := range reservations {
+				event.Items = append(event.Items, events.CartItem{
+					ProductID: reservation.ProductID,
+					Quantity:  reservation.Quantity,
+				})
+			}
+			if err := enqueueEvent(tx, events.AggregateCart, cart.CartID, events.CartAbandoned, event); err != nil {
+				return err
+			}
+			reminded++
+			return nil
+		})
+		if err != nil {
+			return reminded, err
+		}
+	}
+	return reminded, nil
+}
+
+// reserveStock holds stock for the reservations. Product rows are locked in ID
+// order so that concurrent reservations of the same product are serialized and
+// two buyers cannot both be promised the last unit.
+func reserveStock(tx *gorm.DB, reservations []*models.StockReservation) error {
+	sorted := make([]*models.StockReservation, len(reservations))
+	copy(sorted, reservations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ProductID < sorted[j].ProductID })
+
+	for _, reservation := range sorted {
+		// Reservations are created one at a time, so the next one of the
+		// same product counts this one as held
+		if err := checkAvailability(tx, stockDemands([]*models.StockReservation{reservation})[0], nil); err != nil {
+			return err
+		}
+
+		reservation.Status = models.ReservationStatusActive
+		if err := tx.Create(reservation).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stockDemand is the quantity of a product drawn from stock, of which
+// warehouses holds the quantities drawn from specific warehouses
+type stockDemand struct {
+	productID  int
+	quantity   int
+	warehouses map[int]int
+}
+
+// stockDemands totals the reserved quantities per product, in product ID order
+func stockDemands(reservations []*models.StockReservation) []stockDemand {
+	byProduct := map[int]*stockDemand{}
+	var productIDs []int
+	for _, reservation := range reservations {
+		demand, ok := byProduct[reservation.ProductID]
+		if !ok {
+			demand = &stockDemand{productID: reservation.ProductID, warehouses: map[int]int{}}
+			byProduct[reservation.ProductID] = demand
+			productIDs = append(productIDs, reservation.ProductID)
+		}
+		demand.quantity += reservation.Quantity
+		if reservation.WarehouseID != nil {
+			demand.warehouses[*reservation.WarehouseID] += reservation.Quantity
+		}
+	}
+
+	sort.Ints(productIDs)
+	demands := make([]stockDemand, 0, len(productIDs))
+	for _, productID := range productIDs {
+		demands = append(demands, *byProduct[productID])
+	}
+	return demands
+}
+
+// checkAvailability locks the stock the demand draws from and makes sure
+// enough of it is left once everything held by other reservations (apart from
+// those in excludeIDs) is taken out, both for the product as a whole and for
+// each of the demand's warehouses
+func checkAvailability(tx *gorm.DB, demand stockDemand, excludeIDs []int) error {
+	stock, err := lockProductStock(tx, demand.productID)
+	if err != nil {
+		return err
+	}
+	reserved, err := reservedQuantity(tx, demand.productID, nil, excludeIDs)
+	if err != nil {
+		return err
+	}
+	if stock-reserved < demand.quantity {
+		return ErrInsufficientStock
+	}
+
+	warehouseIDs := make([]int, 0, len(demand.warehouses))
+	for warehouseID := range demand.warehouses {
+		warehouseIDs = append(warehouseIDs, warehouseID)
+	}
+	sort.Ints(warehouseIDs)
+	for _, warehouseID := range warehouseIDs {
+		var levels []int
+		result := tx.Model(&models.WarehouseStock{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("warehouse_id = ? AND product_id = ?", warehouseID, demand.productID).
+			Pluck("quantity", &levels)
+		if result.Error != nil {
+			return result.Error
+		}
+		if len(levels) == 0 {
+			return ErrInsufficientStock
+		}
+		reserved, err := reservedQuantity(tx, demand.productID, &warehouseID, excludeIDs)
+		if err != nil {
+			return err
+		}
+		if levels[0]-reserved < demand.warehouses[warehouseID] {
+			return ErrInsufficientStock
+		}
+	}
+	return nil
+}
+
+// releaseReservations gives back the stock held for a cart or order
+func releaseReservations(tx *gorm.DB, referenceType, referenceID string) error {
+	return tx.Model(&models.StockReservation{}).
+		Where("reference_type = ? AND reference_id = ? AND status IN ?", referenceType, referenceID,
+			[]string{models.ReservationStatusActive, models.ReservationStatusExpired}).
+		Update("status", models.ReservationStatusReleased).Error
+}
+
+// lockProductStock locks the product row for the rest of the transaction and returns its stock
+func lockProductStock(tx *gorm.DB, productID int) (int, error) {
+	var product models.Product
+	result := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "stock").First(&product, productID)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return 0, ErrNotFound
+		}
+		return 0, result.Error
+	}
+	return product.Stock, nil
+}
+
+// reservedQuantity sums the stock currently held for a product, in a single
+// warehouse if warehouseID is set, ignoring the reservations in excludeIDs
+func reservedQuantity(tx *gorm.DB, productID int, warehouseID *int, excludeIDs []int) (int, error) {
+	var reserved int
+	query := tx.Model(&models.StockReservation{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND status = ? AND expires_at > ?",
+			productID, models.ReservationStatusActive, time.Now())
+	if len(excludeIDs) > 0 {
+		query = query.Where("id NOT IN ?", excludeIDs)
+	}
+	if warehouseID != nil {
+		query = query.Where("warehouse_id = ?", *warehouseID)
+	}
+	result := query.Scan(&reserved)
+	return reserved, result.Error
+}
+
+func reservationIDs(reservations []*models.StockReservation) []int {
+	ids := make([]int, 0, len(reservations))
+	for _, reservation := range reservations {
+		ids = append(ids, reservation.ID)
+	}
+	return ids
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"ecom-go/internal/models"
+)
+
+func TestStockDemands(t *testing.T) {
+	warehouse := func(id int) *int { return &id }
+
+	tests := []struct {
+		name         string
+		reservations []*models.StockReservation
+		want         []stockDemand
+	}{
+		{
+			name:         "single product",
+			reservations: []*models.StockReservation{{ProductID: 1, Quantity: 2}},
+			want:         []stockDemand{{productID: 1, quantity: 2, warehouses: map[int]int{}}},
+		},
+		{
+			name: "same product from two warehouses",
+			reservations: []*models.StockReservation{
+				{ProductID: 1, WarehouseID: warehouse(10), Quantity: 2},
+				{ProductID: 1, WarehouseID: warehouse(11), Quantity: 3},
+			},
+			want: []stockDemand{{productID: 1, quantity: 5, warehouses: map[int]int{10: 2, 11: 3}}},
+		},
+		{
+			name: "same product twice from one warehouse",
+			reservations: []*models.StockReservation{
+				{ProductID: 1, WarehouseID: warehouse(10), Quantity: 2},
+				{ProductID: 1, WarehouseID: warehouse(10), Quantity: 1},
+			},
+			want: []stockDemand{{productID: 1, quantity: 3, warehouses: map[int]int{10: 3}}},
+		},
+		{
+			name: "ordered by product",
+			reservations: []*models.StockReservation{
+				{ProductID: 3, Quantity: 1},
+				{ProductID: 1, WarehouseID: warehouse(10), Quantity: 1},
+				{ProductID: 2, Quantity: 4},
+			},
+			want: []stockDemand{
+				{productID: 1, quantity: 1, warehouses: map[int]int{10: 1}},
+				{productID: 2, quantity: 4, warehouses: map[int]int{}},
+				{productID: 3, quantity: 1, warehouses: map[int]int{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stockDemands(tt.reservations); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stockDemands() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"ecom-go/pkg/logger"
+
+	"gorm.io/gorm"
+)
+
+// Resolver routes queries to the primary database or to its read replicas.
+// Writes and transactions always use the primary; reads use a healthy replica
+// in turn, or the primary when there is none or the context is pinned to it
+type Resolver struct {
+	primary  *gorm.DB
+	replicas []*replica
+	next     atomic.Uint64
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+// replica is a read replica and whether it is currently in rotation
+type replica struct {
+	index   int
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+// NewResolver creates a resolver over the primary and its replicas, and checks
+// the replicas' health every interval to take failing ones out of rotation.
+// Every write to the primary, whichever repository makes it, sends the later
+// reads of its read-your-writes context to the primary
+func NewResolver(primary *gorm.DB, replicas []*gorm.DB, interval time.Duration) *Resolver {
+	registerWriteTracking(primary)
+
+	r := &Resolver{
+		primary: primary,
+		stop:    make(chan struct{}),
+	}
+	for i, db := range replicas {
+		r.replicas = append(r.replicas, &replica{index: i, db: db})
+	}
+	if len(r.replicas) == 0 {
+		return r
+	}
+
+	r.checkReplicas()
+	r.done.Add(1)
+	go func() {
+		defer r.done.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				r.checkReplicas()
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+	return r
+}
+
+// Writer returns the primary, or the transaction in progress in the context,
+// for writes. Reads made through a read-your-writes context go to the primary
+// from then on
+func (r *Resolver) Writer(ctx context.Context) *gorm.DB {
+	markWrote(ctx)
+	return conn(ctx, r.primary)
+}
+
+// Reader returns a healthy replica for reads, or the primary when there is no
+// healthy replica or the context is pinned to the primary. Reads within a
+// transaction use the transaction
+func (r *Resolver) Reader(ctx context.Context) *gorm.DB {
+	if len(r.replicas) == 0 || inTx(ctx) || ReadsFromPrimary(ctx) {
+		return conn(ctx, r.primary)
+	}
+
+	start := r.next.Add(1)
+	for i := range r.replicas {
+		replica := r.replicas[(start+uint64(i))%uint64(len(r.replicas))]
+		if replica.healthy.Load() {
+			return replica.db.WithContext(ctx)
+		}
+	}
+	return r.primary.WithContext(ctx)
+}
+
+// Primary returns the primary database
+func (r *Resolver) Primary() *gorm.DB {
+	return r.primary
+}
+
+// Close stops the health checks and closes the replicas' connections, the primary is left open
+func (r *Resolver) Close() error {
+	close(r.stop)
+	r.done.Wait()
+
+	for _, replica := range r.replicas {
+		sqlDB, err := replica.db.DB()
+		if err != nil {
+			return err
+		}
+		if err := sqlDB.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReplicas pings the replicas, taking those that fail out of rotation
+// until they answer again
+func (r *Resolver) checkReplicas() {
+	for _, replica := range r.replicas {
+		err := ping(replica.db)
+		healthy := err == nil
+		if replica.healthy.Swap(healthy) == healthy {
+			continue
+		}
+		if healthy {
+			logger.Info("Read replica back in rotation", "replica", replica.index)
+		} else {
+			logger.Warn("Read replica out of rotation", "replica", replica.index, "error", err)
+		}
+	}
+}
+
+// ping checks that a database answers within a few seconds
+func ping(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// sessionKey is the context key of the read-your-writes session
+type sessionKey struct{}
+
+// session records whether a write was made through a context, and whether
+// its reads go to the primary regardless
+type session struct {
+	wrote  atomic.Bool
+	pinned bool
+}
+
+// writeTrackingCallback is the name of the GORM callbacks marking the writes
+const writeTrackingCallback = "resolver:mark_wrote"
+
+// registerWriteTracking makes the creates, updates, deletes and raw statements
+// run on the database mark the session of their context as written
+func registerWriteTracking(db *gorm.DB) {
+	mark := func(tx *gorm.DB) {
+		if tx.Statement.Context != nil {
+			markWrote(tx.Statement.Context)
+		}
+	}
+	callbacks := db.Callback()
+	if callbacks.Create().Get(writeTrackingCallback) != nil {
+		return
+	}
+	callbacks.Create().Before("gorm:create").Register(writeTrackingCallback, mark)
+	callbacks.Update().Before("gorm:update").Register(writeTrackingCallback, mark)
+	callbacks.Delete().Before("gorm:delete").Register(writeTrackingCallback, mark)
+	callbacks.Raw().Before("gorm:raw").Register(writeTrackingCallback, mark)
+}
+
+// markWrote records that a write was made through the context
+func markWrote(ctx context.Context) {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+}
+
+// WithReadYourWrites returns a context whose reads go to the primary as soon as
+// a write was made through it, so they are not served by a lagging replica
+func WithReadYourWrites(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(sessionKey{}).(*session); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// WithPrimary returns a context whose reads always go to the primary
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{pinned: true})
+}
+
+// Wrote reports whether a write was made through a context of WithReadYourWrites or WithPrimary
+func Wrote(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
+
+// ReadsFromPrimary reports whether reads of the context go to the primary
+func ReadsFromPrimary(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && (s.pinned || s.wrote.Load())
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ecom-go/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+)
+
+func TestResolverTracksWritesToThePrimary(t *testing.T) {
+	tests := []struct {
+		name      string
+		expect    func(mock sqlmock.Sqlmock)
+		run       func(ctx context.Context, db *gorm.DB) error
+		wantWrote bool
+	}{
+		{
+			name: "read",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "orders"`).WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow(1))
+				mock.ExpectQuery(`SELECT \* FROM "order_items"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+			run: func(ctx context.Context, db *gorm.DB) error {
+				_, err := NewOrderRepo(db).GetByIDForUpdate(ctx, 1)
+				return err
+			},
+		},
+		{
+			name: "create",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`INSERT INTO "dead_letters"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+			run: func(ctx context.Context, db *gorm.DB) error {
+				return NewDeadLetterRepo(db).Create(ctx, &models.DeadLetter{Queue: "emails"})
+			},
+			wantWrote: true,
+		},
+		{
+			name: "statement in a transaction",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE products`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+			run: func(ctx context.Context, db *gorm.DB) error {
+				return NewTxManager(db, 1).WithinTx(ctx, func(ctx context.Context) error {
+					return exec(ctx, "UPDATE products SET stock = stock - 1")
+				})
+			},
+			wantWrote: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager, mock := newMockTxManager(t, 1)
+			resolver := NewResolver(manager.db, nil, time.Minute)
+			defer resolver.Close()
+			tt.expect(mock)
+
+			ctx := WithReadYourWrites(context.Background())
+			if err := tt.run(ctx, manager.db); err != nil {
+				t.Fatal(err)
+			}
+			if Wrote(ctx) != tt.wantWrote {
+				t.Errorf("wrote = %t, want %t", Wrote(ctx), tt.wantWrote)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// ReturnRepository defines the interface for return request data access
+type ReturnRepository interface {
+	// Create adds a new return request together with its items
+	Create(ctx context.Context, returnRequest *models.ReturnRequest) error
+
+	// GetByID retrieves a return request by ID
+	GetByID(ctx context.Context, id int) (*models.ReturnRequest, error)
+
+	// GetByIDForUpdate retrieves a return request by ID and locks it until the
+	// transaction in progress ends, so that its status can be checked and changed
+	// without a concurrent change in between
+	GetByIDForUpdate(ctx context.Context, id int) (*models.ReturnRequest, error)
+
+	// ListByOrder retrieves all return requests of an order
+	ListByOrder(ctx context.Context, orderID int) ([]*models.ReturnRequest, error)
+
+	// List retrieves the return requests of a user with pagination, those of
+	// every user when userID is 0
+	List(ctx context.Context, userID, offset, limit int) ([]*models.ReturnRequest, error)
+
+	// Update updates an existing return request without touching its items,
+	// queuing an event when its status changed
+	Update(ctx context.Context, returnRequest *models.ReturnRequest) error
+}
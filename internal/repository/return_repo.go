@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReturnRepo implements the ReturnRepository interface using PostgreSQL/GORM
+type ReturnRepo struct {
+	db *gorm.DB
+}
+
+// NewReturnRepo creates a new return request repository
+func NewReturnRepo(db *gorm.DB) *ReturnRepo {
+	return &ReturnRepo{
+		db: db,
+	}
+}
+
+// Create adds a new return request together with its items
+func (r *ReturnRepo) Create(ctx context.Context, returnRequest *models.ReturnRequest) error {
+	result := conn(ctx, r.db).Create(returnRequest)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// GetByID retrieves a return request by ID
+func (r *ReturnRepo) GetByID(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	var returnRequest models.ReturnRequest
+	result := conn(ctx, r.db).Preload("Items").First(&returnRequest, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &returnRequest, nil
+}
+
+// GetByIDForUpdate retrieves a return request by ID and locks it until the
+// transaction in progress ends
+func (r *ReturnRepo) GetByIDForUpdate(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	var returnRequest models.ReturnRequest
+	result := conn(ctx, r.db).Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Items").First(&returnRequest, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &returnRequest, nil
+}
+
+// ListByOrder retrieves all return requests of an order
+func (r *ReturnRepo) ListByOrder(ctx context.Context, orderID int) ([]*models.ReturnRequest, error) {
+	var returnRequests []*models.ReturnRequest
+	result := conn(ctx, r.db).Where("order_id = ?", orderID).Preload("Items").Find(&returnRequests)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return returnRequests, nil
+}
+
+// List retrieves the return requests of a user with pagination, those of
+// every user when userID is 0
+func (r *ReturnRepo) List(ctx context.Context, userID, offset, limit int) ([]*models.ReturnRequest, error) {
+	var returnRequests []*models.ReturnRequest
+	query := conn(ctx, r.db)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	result := query.Order("id DESC").Offset(offset).Limit(limit).Preload("Items").Find(&returnRequests)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return returnRequests, nil
+}
+
+// Update updates an existing return request without touching its items. A
+// change of status queues a "return.<status>" event in the same transaction.
+func (r *ReturnRepo) Update(ctx context.Context, returnRequest *models.ReturnRequest) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var previousStatus string
+		err := tx.Model(&models.ReturnRequest{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", returnRequest.ID).Pluck("status", &previousStatus).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Omit(clause.Associations).Save(returnRequest).Error; err != nil {
+			return err
+		}
+
+		if previousStatus == returnRequest.Status {
+			return nil
+		}
+		return enqueueEvent(tx, events.AggregateReturn, returnRequest.ID, events.ReturnStatusEvent(returnRequest.Status), events.ReturnStatusChangedEvent{
+			ReturnID:       returnRequest.ID,
+			OrderID:        returnRequest.OrderID,
+			UserID:         returnRequest.UserID,
+			PreviousStatus: previousStatus,
+			Status:         returnRequest.Status,
+			RefundAmount:   returnRequest.RefundAmount,
+		})
+	})
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// StockMovementRepository defines the interface for the stock ledger
+type StockMovementRepository interface {
+	// Record appends the movements to the ledger and applies them to the
+	// products' stock atomically
+	Record(ctx context.Context, movements ...*models.StockMovement) error
+
+	// ListByProduct retrieves a product's movements, newest first, with pagination
+	ListByProduct(ctx context.Context, productID, offset, limit int) ([]*models.StockMovement, error)
+
+	// CountByProduct returns the number of movements of a product
+	CountByProduct(ctx context.Context, productID int) (int64, error)
+}
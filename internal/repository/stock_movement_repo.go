@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StockMovementRepo implements the StockMovementRepository interface using PostgreSQL/GORM
+type StockMovementRepo struct {
+	db *gorm.DB
+}
+
+// NewStockMovementRepo creates a new stock movement repository
+func NewStockMovementRepo(db *gorm.DB) *StockMovementRepo {
+	return &StockMovementRepo{
+		db: db,
+	}
+}
+
+// Record appends the movements to the ledger and applies them to the
+// products' stock atomically
+func (r *StockMovementRepo) Record(ctx context.Context, movements ...*models.StockMovement) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		for _, movement := range movements {
+			if err := applyStockMovement(tx, movement); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListByProduct retrieves a product's movements, newest first, with pagination
+func (r *StockMovementRepo) ListByProduct(ctx context.Context, productID, offset, limit int) ([]*models.StockMovement, error) {
+	var movements []*models.StockMovement
+	result := conn(ctx, r.db).Where("product_id = ?", productID).
+		Order("id DESC").Offset(offset).Limit(limit).Find(&movements)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return movements, nil
+}
+
+// CountByProduct returns the number of movements of a product
+func (r *StockMovementRepo) CountByProduct(ctx context.Context, productID int) (int64, error) {
+	var count int64
+	result := conn(ctx, r.db).Model(&models.StockMovement{}).Where("product_id = ?", productID).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// applyStockMovement applies a movement to the product's materialized stock and,
+// when it names a warehouse, to the warehouse's stock level, then appends it to
+// the ledger and queues a stock.changed event. It must be called inside a
+// transaction so that the stock, the ledger and the event never diverge.
+func applyStockMovement(tx *gorm.DB, movement *models.StockMovement) error {
+	now := time.Now()
+
+	var balance []int
+	result := tx.Raw("UPDATE products SET stock = stock + ?, updated_at = ? WHERE id = ? AND stock + ? >= 0 RETURNING stock",
+		movement.Quantity, now, movement.ProductID, movement.Quantity).Scan(&balance)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if len(balance) == 0 {
+		var count int64
+		if err := tx.Model(&models.Product{}).Where("id = ?", movement.ProductID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return ErrNotFound
+		}
+		return ErrInsufficientStock
+	}
+
+	if movement.WarehouseID != nil {
+		if err := applyWarehouseStock(tx, *movement.WarehouseID, movement.ProductID, movement.Quantity, now); err != nil {
+			return err
+		}
+	}
+
+	movement.BalanceAfter = balance[0]
+	if err := tx.Create(movement).Error; err != nil {
+		return err
+	}
+
+	return enqueueEvent(tx, events.AggregateProduct, movement.ProductID, events.StockChanged, events.StockChangedEvent{
+		ProductID:    movement.ProductID,
+		WarehouseID:  movement.WarehouseID,
+		Quantity:     movement.Quantity,
+		Reason:       movement.Reason,
+		BalanceAfter: movement.BalanceAfter,
+	})
+}
+
+// applyWarehouseStock adds delta to a product's stock level in a warehouse
+func applyWarehouseStock(tx *gorm.DB, warehouseID, productID, delta int, now time.Time) error {
+	if delta >= 0 {
+		return tx.Exec(`INSERT INTO warehouse_stocks (warehouse_id, product_id, quantity, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (warehouse_id, product_id) DO UPDATE
+			SET quantity = warehouse_stocks.quantity + EXCLUDED.quantity, updated_at = EXCLUDED.updated_at`,
+			warehouseID, productID, delta, now).Error
+	}
+
+	result := tx.Model(&models.WarehouseStock{}).
+		Where("warehouse_id = ? AND product_id = ? AND quantity + ? >= 0", warehouseID, productID, delta).
+		Updates(map[string]interface{}{
+			"quantity":   gorm.Expr("quantity + ?", delta),
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes of transactions that lost a race and may succeed when retried
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// TxManager runs functions in a database transaction. Repositories called with
+// the context passed to the function take part in the transaction
+type TxManager interface {
+	// WithinTx runs fn in a transaction, committed if fn returns nil and rolled
+	// back otherwise. Called within another transaction, fn runs in a savepoint
+	// of it, so that its failure only rolls back its own changes
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// GormTxManager is the TxManager of the primary database
+type GormTxManager struct {
+	db          *gorm.DB
+	maxAttempts int
+}
+
+// NewTxManager creates a transaction manager that runs a transaction up to
+// maxAttempts times when it fails on a serialization failure or deadlock
+func NewTxManager(db *gorm.DB, maxAttempts int) *GormTxManager {
+	return &GormTxManager{
+		db:          db,
+		maxAttempts: max(maxAttempts, 1),
+	}
+}
+
+// WithinTx runs fn in a transaction, retrying the whole transaction when it
+// lost a race with another one. fn may then run more than once, so it should
+// not have side effects outside the database
+func (m *GormTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	run := func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	}
+
+	// Nested transactions run in a savepoint, only the outermost one is retried
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.Transaction(run)
+	}
+
+	backoff := 10 * time.Millisecond
+	for attempt := 1; ; attempt++ {
+		hooks := &txHooks{}
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(context.WithValue(ctx, txKey{}, tx), txHooksKey{}, hooks))
+		})
+		if err == nil {
+			hooks.run()
+			return nil
+		}
+		if attempt >= m.maxAttempts || !isRetryable(err) {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return err
+		}
+	}
+}
+
+// txKey is the context key of the transaction in progress
+type txKey struct{}
+
+// txHooksKey is the context key of the functions to run once the transaction
+// in progress commits
+type txHooksKey struct{}
+
+// txHooks holds the functions to run once a transaction commits
+type txHooks struct {
+	mu  sync.Mutex
+	fns []func()
+}
+
+// run runs the functions in the order they were added
+func (h *txHooks) run() {
+	h.mu.Lock()
+	fns := h.fns
+	h.mu.Unlock()
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// afterCommit runs fn once the transaction in progress in the context has
+// committed, or right away outside of a transaction. Functions added in a
+// savepoint run when the whole transaction commits, even if the savepoint
+// rolled back
+func afterCommit(ctx context.Context, fn func()) {
+	hooks, ok := ctx.Value(txHooksKey{}).(*txHooks)
+	if !ok {
+		fn()
+		return
+	}
+	hooks.mu.Lock()
+	defer hooks.mu.Unlock()
+	hooks.fns = append(hooks.fns, fn)
+}
+
+// conn returns the transaction in progress in the context, or else the database
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
+
+// inTx reports whether a transaction is in progress in the context
+func inTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+// isRetryable reports whether a transaction failed because it lost a race with another one
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == codeSerializationFailure || pgErr.Code == codeDeadlockDetected)
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeTxManager is a TxManager for service unit tests: it runs functions
+// directly, without a database, and counts how they ended
+type FakeTxManager struct {
+	mu sync.Mutex
+	// Err, if set, is returned instead of running the function, as if the transaction failed to begin
+	Err error
+	// Commits and Rollbacks count the functions that returned nil and an error
+	Commits   int
+	Rollbacks int
+}
+
+// WithinTx runs fn with the context unchanged
+func (m *FakeTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if m.Err != nil {
+		return m.Err
+	}
+
+	err := fn(ctx)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		m.Rollbacks++
+	} else {
+		m.Commits++
+	}
+	return err
+}
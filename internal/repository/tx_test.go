@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newMockTxManager returns a transaction manager over a mocked database
+func newMockTxManager(t *testing.T, maxAttempts int) (*GormTxManager, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		sqlDB.Close()
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewTxManager(db, maxAttempts), mock
+}
+
+// exec runs a statement on the transaction of the context
+func exec(ctx context.Context, statement string) error {
+	return conn(ctx, nil).Exec(statement).Error
+}
+
+func TestTxManagerRetry(t *testing.T) {
+	serializationFailure := &pgconn.PgError{Code: codeSerializationFailure}
+	deadlock := &pgconn.PgError{Code: codeDeadlockDetected}
+	uniqueViolation := &pgconn.PgError{Code: "23505"}
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		failures    []error // Errors of the attempts' statement, then it succeeds
+		wantErr     error
+		wantRuns    int
+	}{
+		{name: "commit", maxAttempts: 3, wantRuns: 1},
+		{name: "retried serialization failure", maxAttempts: 3, failures: []error{serializationFailure}, wantRuns: 2},
+		{name: "retried deadlock", maxAttempts: 3, failures: []error{deadlock, serializationFailure}, wantRuns: 3},
+		{name: "attempts exhausted", maxAttempts: 2, failures: []error{deadlock, deadlock}, wantErr: deadlock, wantRuns: 2},
+		{name: "not retried", maxAttempts: 3, failures: []error{uniqueViolation}, wantErr: uniqueViolation, wantRuns: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, mock := newMockTxManager(t, tt.maxAttempts)
+			for i := 0; i < tt.wantRuns; i++ {
+				mock.ExpectBegin()
+				if i < len(tt.failures) {
+					mock.ExpectExec("UPDATE products").WillReturnError(tt.failures[i])
+					mock.ExpectRollback()
+				} else {
+					mock.ExpectExec("UPDATE products").WillReturnResult(sqlmock.NewResult(0, 1))
+					mock.ExpectCommit()
+				}
+			}
+
+			runs, committed := 0, 0
+			err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+				runs++
+				// Only the committed attempt's functions run
+				afterCommit(ctx, func() { committed++ })
+				return exec(ctx, "UPDATE products SET stock = 1")
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if runs != tt.wantRuns {
+				t.Errorf("%d runs, want %d", runs, tt.wantRuns)
+			}
+			if wantCommitted := map[bool]int{true: 1, false: 0}[tt.wantErr == nil]; committed != wantCommitted {
+				t.Errorf("after-commit functions ran %d times, want %d", committed, wantCommitted)
+			}
+		})
+	}
+}
+
+func TestTxManagerNested(t *testing.T) {
+	m, mock := newMockTxManager(t, 3)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE orders").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE products").WillReturnError(&pgconn.PgError{Code: codeSerializationFailure})
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE stock_movements").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var order []string
+	outerRuns := 0
+	err := m.WithinTx(context.Background(), func(ctx context.Context) error {
+		outerRuns++
+		if !inTx(ctx) {
+			t.Error("no transaction in the context")
+		}
+		if err := exec(ctx, "UPDATE orders SET status = 'paid'"); err != nil {
+			return err
+		}
+
+		// A failing nested transaction only rolls back its savepoint and is
+		// not retried on its own
+		nestedErr := m.WithinTx(ctx, func(ctx context.Context) error {
+			afterCommit(ctx, func() { order = append(order, "failed savepoint") })
+			return exec(ctx, "UPDATE products SET stock = 1")
+		})
+		if nestedErr == nil {
+			t.Error("failed nested transaction succeeded")
+		}
+
+		err := m.WithinTx(ctx, func(ctx context.Context) error {
+			afterCommit(ctx, func() { order = append(order, "savepoint") })
+			return exec(ctx, "UPDATE stock_movements SET quantity = 1")
+		})
+		if len(order) != 0 {
+			t.Error("after-commit functions ran before the transaction committed")
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if outerRuns != 1 {
+		t.Errorf("%d runs of the outer transaction, want 1", outerRuns)
+	}
+	if len(order) != 2 || order[0] != "failed savepoint" || order[1] != "savepoint" {
+		t.Errorf("after-commit functions ran as %v, want in the order they were added", order)
+	}
+}
+
+func TestAfterCommitOutsideTx(t *testing.T) {
+	ran := false
+	afterCommit(context.Background(), func() { ran = true })
+	if !ran {
+		t.Error("function not run right away outside of a transaction")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 
+	"ecom-go/internal/events"
 	"ecom-go/internal/models"
 	"gorm.io/gorm"
 )
@@ -20,15 +21,24 @@ func NewUserRepo(db *gorm.DB) *UserRepo {
 	}
 }
 
-// Create adds a new user to the database
+// Create adds a new user to the database and queues the user.created event
 func (r *UserRepo) Create(ctx context.Context, user *models.User) error {
-	result := r.db.WithContext(ctx).Create(user)
-	if result.Error != nil {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, events.AggregateUser, user.ID, events.UserCreated, events.UserCreatedEvent{
+			UserID:    user.ID,
+			Email:     user.Email,
+			FirstName: user.FirstName,
+		})
+	})
+	if err != nil {
 		// Check for unique constraint violation
-		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
 			return ErrConflict
 		}
-		return result.Error
+		return err
 	}
 	return nil
 }
@@ -36,7 +46,7 @@ func (r *UserRepo) Create(ctx context.Context, user *models.User) error {
 // GetByID retrieves a user by ID
 func (r *UserRepo) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	result := r.db.WithContext(ctx).First(&user, id)
+	result := conn(ctx, r.db).First(&user, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
@@ -49,7 +59,7 @@ func (r *UserRepo) GetByID(ctx context.Context, id uint) (*models.User, error) {
 // GetByEmail retrieves a user by email
 func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	result := r.db.WithContext(ctx).Where("email = ?", email).First(&user)
+	result := conn(ctx, r.db).Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
@@ -61,7 +71,7 @@ func (r *UserRepo) GetByEmail(ctx context.Context, email string) (*models.User,
 
 // Update updates an existing user
 func (r *UserRepo) Update(ctx context.Context, user *models.User) error {
-	result := r.db.WithContext(ctx).Save(user)
+	result := conn(ctx, r.db).Save(user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return ErrNotFound
@@ -73,7 +83,7 @@ func (r *UserRepo) Update(ctx context.Context, user *models.User) error {
 
 // Delete removes a user from the database
 func (r *UserRepo) Delete(ctx context.Context, id uint) error {
-	result := r.db.WithContext(ctx).Delete(&models.User{}, id)
+	result := conn(ctx, r.db).Delete(&models.User{}, id)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -86,7 +96,7 @@ func (r *UserRepo) Delete(ctx context.Context, id uint) error {
 // List retrieves users with pagination
 func (r *UserRepo) List(ctx context.Context, offset, limit int) ([]*models.User, error) {
 	var users []*models.User
-	result := r.db.WithContext(ctx).Offset(offset).Limit(limit).Find(&users)
+	result := conn(ctx, r.db).Offset(offset).Limit(limit).Find(&users)
 	if result.Error != nil {
 		return nil, result.Error
 	}
@@ -96,7 +106,7 @@ func (r *UserRepo) List(ctx context.Context, offset, limit int) ([]*models.User,
 // Count returns the total number of users
 func (r *UserRepo) Count(ctx context.Context) (int64, error) {
 	var count int64
-	result := r.db.WithContext(ctx).Model(&models.User{}).Count(&count)
+	result := conn(ctx, r.db).Model(&models.User{}).Count(&count)
 	if result.Error != nil {
 		return 0, result.Error
 	}
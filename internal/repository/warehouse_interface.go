@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// WarehouseAvailability is the stock of a product in a warehouse that is not held by reservations
+type WarehouseAvailability struct {
+	Warehouse models.Warehouse
+	ProductID int
+	Available int
+}
+
+// WarehouseRepository defines the interface for warehouse data access
+type WarehouseRepository interface {
+	// Create adds a new warehouse to the database
+	Create(ctx context.Context, warehouse *models.Warehouse) error
+
+	// GetByID retrieves a warehouse by ID
+	GetByID(ctx context.Context, id int) (*models.Warehouse, error)
+
+	// List retrieves all warehouses
+	List(ctx context.Context) ([]*models.Warehouse, error)
+
+	// Update updates an existing warehouse
+	Update(ctx context.Context, warehouse *models.Warehouse) error
+
+	// StockLevels retrieves the stock levels of all products in a warehouse
+	StockLevels(ctx context.Context, warehouseID int) ([]*models.WarehouseStock, error)
+
+	// Availability retrieves the unreserved stock of the products in every active warehouse that stocks them
+	Availability(ctx context.Context, productIDs ...int) ([]WarehouseAvailability, error)
+
+	// Transfer moves unreserved stock of a product from one warehouse to another,
+	// recording the outgoing and incoming movements based on movement
+	Transfer(ctx context.Context, fromWarehouseID, toWarehouseID int, movement models.StockMovement) error
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// WarehouseRepo implements the WarehouseRepository interface using PostgreSQL/GORM
+type WarehouseRepo struct {
+	db *gorm.DB
+}
+
+// NewWarehouseRepo creates a new warehouse repository
+func NewWarehouseRepo(db *gorm.DB) *WarehouseRepo {
+	return &WarehouseRepo{
+		db: db,
+	}
+}
+
+// Create adds a new warehouse to the database
+func (r *WarehouseRepo) Create(ctx context.Context, warehouse *models.Warehouse) error {
+	result := conn(ctx, r.db).Create(warehouse)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		return result.Error
+	}
+	return nil
+}
+
+// GetByID retrieves a warehouse by ID
+func (r *WarehouseRepo) GetByID(ctx context.Context, id int) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	result := conn(ctx, r.db).First(&warehouse, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &warehouse, nil
+}
+
+// List retrieves all warehouses
+func (r *WarehouseRepo) List(ctx context.Context) ([]*models.Warehouse, error) {
+	var warehouses []*models.Warehouse
+	result := conn(ctx, r.db).Order("id").Find(&warehouses)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return warehouses, nil
+}
+
+// Update updates an existing warehouse
+func (r *WarehouseRepo) Update(ctx context.Context, warehouse *models.Warehouse) error {
+	result := conn(ctx, r.db).Save(warehouse)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return ErrConflict
+		}
+		return result.Error
+	}
+	return nil
+}
+
+// StockLevels retrieves the stock levels of all products in a warehouse
+func (r *WarehouseRepo) StockLevels(ctx context.Context, warehouseID int) ([]*models.WarehouseStock, error) {
+	var levels []*models.WarehouseStock
+	result := conn(ctx, r.db).Where("warehouse_id = ?", warehouseID).Order("product_id").Find(&levels)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return levels, nil
+}
+
+// Availability retrieves the unreserved stock of the products in every active warehouse that stocks them
+func (r *WarehouseRepo) Availability(ctx context.Context, productIDs ...int) ([]WarehouseAvailability, error) {
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	var rows []struct {
+		models.Warehouse
+		ProductID int
+		Available int
+	}
+	result := conn(ctx, r.db).Raw(`
+		SELECT w.*, ws.product_id, ws.quantity - COALESCE(held.quantity, 0) AS available
+		FROM warehouse_stocks ws
+		JOIN warehouses w ON w.id = ws.warehouse_id AND w.active
+		LEFT JOIN (
+			SELECT warehouse_id, product_id, SUM(quantity) AS quantity
+			FROM stock_reservations
+			WHERE status = ? AND expires_at > ? AND warehouse_id IS NOT NULL
+			GROUP BY warehouse_id, product_id
+		) held ON held.warehouse_id = ws.warehouse_id AND held.product_id = ws.product_id
+		WHERE ws.product_id IN ?
+		ORDER BY ws.product_id, w.id`,
+		models.ReservationStatusActive, time.Now(), productIDs).Scan(&rows)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	availability := make([]WarehouseAvailability, 0, len(rows))
+	for _, row := range rows {
+		availability = append(availability, WarehouseAvailability{
+			Warehouse: row.Warehouse,
+			ProductID: row.ProductID,
+			Available: row.Available,
+		})
+	}
+	return availability, nil
+}
+
+// Transfer moves unreserved stock of a product from one warehouse to another,
+// recording the outgoing and incoming movements based on movement
+func (r *WarehouseRepo) Transfer(ctx context.Context, fromWarehouseID, toWarehouseID int, movement models.StockMovement) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Stock held for customers in the source warehouse must stay there
+		demand := stockDemand{
+			productID:  movement.ProductID,
+			quantity:   movement.Quantity,
+			warehouses: map[int]int{fromWarehouseID: movement.Quantity},
+		}
+		if err := checkAvailability(tx, demand, nil); err != nil {
+			return err
+		}
+
+		outgoing := movement
+		outgoing.WarehouseID = &fromWarehouseID
+		outgoing.Quantity = -movement.Quantity
+		if err := applyStockMovement(tx, &outgoing); err != nil {
+			return err
+		}
+
+		incoming := movement
+		incoming.WarehouseID = &toWarehouseID
+		return applyStockMovement(tx, &incoming)
+	})
+}
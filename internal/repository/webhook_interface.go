@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+)
+
+// WebhookRepository defines the interface for webhook subscriptions and their delivery log
+type WebhookRepository interface {
+	// Create adds a new webhook subscription
+	Create(ctx context.Context, subscription *models.WebhookSubscription) error
+
+	// GetByID retrieves a webhook subscription by ID
+	GetByID(ctx context.Context, id int) (*models.WebhookSubscription, error)
+
+	// List retrieves webhook subscriptions with pagination
+	List(ctx context.Context, offset, limit int) ([]*models.WebhookSubscription, error)
+
+	// Count returns the number of webhook subscriptions
+	Count(ctx context.Context) (int64, error)
+
+	// ListActive retrieves the webhook subscriptions that are not disabled
+	ListActive(ctx context.Context) ([]*models.WebhookSubscription, error)
+
+	// Update updates an existing webhook subscription
+	Update(ctx context.Context, subscription *models.WebhookSubscription) error
+
+	// Delete removes a webhook subscription and its delivery log
+	Delete(ctx context.Context, id int) error
+
+	// RecordDelivery logs a delivery attempt and keeps count of the endpoint's
+	// failures in a row, disabling it once they reach disableAfter
+	RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery, disableAfter int) (*models.WebhookSubscription, error)
+
+	// GetDelivery retrieves a logged delivery attempt by ID
+	GetDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error)
+
+	// ListDeliveries retrieves a subscription's delivery attempts, newest first
+	ListDeliveries(ctx context.Context, subscriptionID, offset, limit int) ([]*models.WebhookDelivery, error)
+
+	// CountDeliveries returns the number of a subscription's delivery attempts
+	CountDeliveries(ctx context.Context, subscriptionID int) (int64, error)
+
+	// Redeliver sends the event of a logged delivery to its endpoint again through the outbox
+	Redeliver(ctx context.Context, id int, queue string) (*models.WebhookDelivery, error)
+}
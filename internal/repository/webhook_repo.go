@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WebhookRepo implements the WebhookRepository interface using PostgreSQL/GORM
+type WebhookRepo struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepo creates a new webhook repository
+func NewWebhookRepo(db *gorm.DB) *WebhookRepo {
+	return &WebhookRepo{
+		db: db,
+	}
+}
+
+// Create adds a new webhook subscription
+func (r *WebhookRepo) Create(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return conn(ctx, r.db).Create(subscription).Error
+}
+
+// GetByID retrieves a webhook subscription by ID
+func (r *WebhookRepo) GetByID(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	result := conn(ctx, r.db).First(&subscription, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &subscription, nil
+}
+
+// List retrieves webhook subscriptions with pagination
+func (r *WebhookRepo) List(ctx context.Context, offset, limit int) ([]*models.WebhookSubscription, error) {
+	var subscriptions []*models.WebhookSubscription
+	result := conn(ctx, r.db).Order("id").Offset(offset).Limit(limit).Find(&subscriptions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subscriptions, nil
+}
+
+// Count returns the number of webhook subscriptions
+func (r *WebhookRepo) Count(ctx context.Context) (int64, error) {
+	var count int64
+	result := conn(ctx, r.db).Model(&models.WebhookSubscription{}).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// ListActive retrieves the webhook subscriptions that are not disabled
+func (r *WebhookRepo) ListActive(ctx context.Context) ([]*models.WebhookSubscription, error) {
+	var subscriptions []*models.WebhookSubscription
+	result := conn(ctx, r.db).Where("active").Order("id").Find(&subscriptions)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return subscriptions, nil
+}
+
+// Update updates an existing webhook subscription
+func (r *WebhookRepo) Update(ctx context.Context, subscription *models.WebhookSubscription) error {
+	result := conn(ctx, r.db).Save(subscription)
+	if result.Error != nil {
+		return result.Error
+	}
+	return nil
+}
+
+// Delete removes a webhook subscription and its delivery log
+func (r *WebhookRepo) Delete(ctx context.Context, id int) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.WebhookSubscription{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// RecordDelivery logs a delivery attempt and keeps count of the endpoint's
+// failures in a row, disabling it once they reach disableAfter
+func (r *WebhookRepo) RecordDelivery(ctx context.Context, delivery *models.WebhookDelivery, disableAfter int) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subscription, delivery.SubscriptionID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		var previous int64
+		err := tx.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND event_id = ?", delivery.SubscriptionID, delivery.EventID).
+			Count(&previous).Error
+		if err != nil {
+			return err
+		}
+		delivery.Attempt = int(previous) + 1
+		if err := tx.Create(delivery).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		if delivery.Success {
+			if subscription.ConsecutiveFailures == 0 {
+				return nil
+			}
+			subscription.ConsecutiveFailures = 0
+			updates["consecutive_failures"] = 0
+		} else {
+			subscription.ConsecutiveFailures++
+			updates["consecutive_failures"] = subscription.ConsecutiveFailures
+			if subscription.Active && disableAfter > 0 && subscription.ConsecutiveFailures >= disableAfter {
+				now := time.Now()
+				subscription.Active = false
+				subscription.DisabledAt = &now
+				subscription.DisabledReason = fmt.Sprintf("%d failed deliveries in a row", subscription.ConsecutiveFailures)
+				updates["active"] = false
+				updates["disabled_at"] = now
+				updates["disabled_reason"] = subscription.DisabledReason
+			}
+		}
+		return tx.Model(&subscription).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+// GetDelivery retrieves a logged delivery attempt by ID
+func (r *WebhookRepo) GetDelivery(ctx context.Context, id int) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	result := conn(ctx, r.db).First(&delivery, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, result.Error
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries retrieves a subscription's delivery attempts, newest first
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, subscriptionID, offset, limit int) ([]*models.WebhookDelivery, error) {
+	var deliveries []*models.WebhookDelivery
+	result := conn(ctx, r.db).Where("subscription_id = ?", subscriptionID).
+		Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return deliveries, nil
+}
+
+// CountDeliveries returns the number of a subscription's delivery attempts
+func (r *WebhookRepo) CountDeliveries(ctx context.Context, subscriptionID int) (int64, error) {
+	var count int64
+	result := conn(ctx, r.db).Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID).Count(&count)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return count, nil
+}
+
+// Redeliver sends the event of a logged delivery to its endpoint again through the outbox
+func (r *WebhookRepo) Redeliver(ctx context.Context, id int, queue string) (*models.WebhookDelivery, error) {
+	delivery, err := r.GetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = enqueueToQueue(conn(ctx, r.db), queue, events.AggregateWebhook, delivery.SubscriptionID, events.WebhookDeliveryRequested, events.WebhookDeliveryRequestedEvent{
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		OccurredAt:     delivery.OccurredAt,
+		Payload:        delivery.Payload,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	"ecom-go/pkg/logger"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Job is a recurring task run by the scheduler
+type Job func(ctx context.Context) error
+
+// Scheduler runs jobs on cron schedules. Every worker replica runs a
+// scheduler, so each run takes a Postgres advisory lock named after the job
+// and is recorded against its scheduled time: a job never runs on two
+// replicas at once, and each scheduled run happens only once.
+type Scheduler struct {
+	cron *cron.Cron
+	runs repository.JobRunRepository
+	node string
+	ctx  context.Context
+}
+
+// New creates a new scheduler, schedules are evaluated in UTC
+func New(runs repository.JobRunRepository) *Scheduler {
+	node, _ := os.Hostname()
+	return &Scheduler{
+		cron: cron.New(cron.WithLocation(time.UTC)),
+		runs: runs,
+		node: node,
+		ctx:  context.Background(),
+	}
+}
+
+// Add schedules a job, an empty spec leaves the job disabled
+func (s *Scheduler) Add(name, spec string, job Job) error {
+	if spec == "" {
+		logger.Info("Scheduled job disabled", "job", name)
+		return nil
+	}
+
+	schedule, err := parseSchedule(spec)
+	if err != nil {
+		return fmt.Errorf("invalid schedule %q for job %s: %w", spec, name, err)
+	}
+	var id cron.EntryID
+	id = s.cron.Schedule(schedule, cron.FuncJob(func() {
+		s.run(name, s.cron.Entry(id).Prev, job)
+	}))
+	logger.Info("Scheduled job", "job", name, "schedule", spec)
+	return nil
+}
+
+// parseSchedule parses a cron spec. "@every" schedules are aligned on the
+// clock, runs happening at multiples of the period since the Unix epoch, so
+// that every replica schedules them at the same times
+func parseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return alignedSchedule{period: every.Delay}, nil
+	}
+	return schedule, nil
+}
+
+// alignedSchedule runs at every multiple of the period
+type alignedSchedule struct {
+	period time.Duration
+}
+
+// Next returns the first multiple of the period after t
+func (s alignedSchedule) Next(t time.Time) time.Time {
+	return time.Unix(0, 0).Add(t.Sub(time.Unix(0, 0)).Truncate(s.period) + s.period).In(t.Location())
+}
+
+// Start runs the scheduled jobs until Stop is called. Jobs are handed the context
+func (s *Scheduler) Start(ctx context.Context) {
+	s.ctx = ctx
+	s.cron.Start()
+}
+
+// Stop stops scheduling jobs and waits for the running ones to finish
+func (s *Scheduler) Stop() {
+	<-s.cron.Stop().Done()
+}
+
+// run runs a job unless another replica is running it or already ran it for the same schedule
+func (s *Scheduler) run(name string, scheduledAt time.Time, job Job) {
+	ctx := s.ctx
+
+	release, acquired, err := s.runs.Lock(ctx, "job:"+name)
+	if err != nil {
+		logger.Error("Failed to lock scheduled job", "job", name, "error", err)
+		return
+	}
+	if !acquired {
+		logger.Info("Scheduled job is running on another replica", "job", name)
+		return
+	}
+	defer release()
+
+	run := &models.JobRun{
+		Job:         name,
+		ScheduledAt: scheduledAt,
+		Node:        s.node,
+		Status:      models.JobRunStatusRunning,
+		StartedAt:   time.Now(),
+	}
+	if err := s.runs.Start(ctx, run); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			logger.Info("Scheduled job already ran on another replica", "job", name, "scheduled_at", scheduledAt)
+			return
+		}
+		logger.Error("Failed to record job run", "job", name, "error", err)
+		return
+	}
+
+	err = safeRun(ctx, job)
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	run.Status = models.JobRunStatusSucceeded
+	if err != nil {
+		run.Status = models.JobRunStatusFailed
+		run.Error = err.Error()
+		logger.Error("Scheduled job failed", "job", name, "duration", run.Duration(), "error", err)
+	} else {
+		logger.Info("Scheduled job finished", "job", name, "duration", run.Duration())
+	}
+
+	// Record the outcome even when the job was interrupted by a shutdown
+	if err := s.runs.Finish(context.WithoutCancel(ctx), run); err != nil {
+		logger.Error("Failed to record job outcome", "job", name, "error", err)
+	}
+}
+
+// safeRun runs the job, turning a panic into an error
+func safeRun(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+	return job(ctx)
+}
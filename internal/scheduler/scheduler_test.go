@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"@every 5m", "2026-03-01T10:02:17.5Z", "2026-03-01T10:05:00Z"},
+		{"@every 5m", "2026-03-01T10:05:00Z", "2026-03-01T10:10:00Z"},
+		{"@every 1m", "2026-03-01T10:02:59.999Z", "2026-03-01T10:03:00Z"},
+		{"@every 1h", "2026-03-01T23:30:00Z", "2026-03-02T00:00:00Z"},
+		{"@every 90s", "2026-03-01T00:00:01Z", "2026-03-01T00:01:30Z"},
+		{"0 * * * *", "2026-03-01T10:02:17Z", "2026-03-01T11:00:00Z"},
+	}
+	for _, tt := range tests {
+		schedule, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("parseSchedule(%q): %v", tt.spec, err)
+		}
+		if got := schedule.Next(at(tt.from)); !got.Equal(at(tt.want)) {
+			t.Errorf("%q after %s = %s, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestParseScheduleSameOnEveryReplica(t *testing.T) {
+	schedule, err := parseSchedule("@every 5m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Replicas started at different times schedule the same runs
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	want := schedule.Next(start.Add(time.Second))
+	for _, offset := range []time.Duration{2 * time.Second, 73 * time.Second, 4*time.Minute + 59*time.Second} {
+		if got := schedule.Next(start.Add(offset)); !got.Equal(want) {
+			t.Errorf("started %s after the hour, next run %s, want %s", offset, got, want)
+		}
+	}
+}
+
+func TestParseScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"@every", "every 5m", "61 * * * *"} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("parseSchedule(%q) succeeded", spec)
+		}
+	}
+}
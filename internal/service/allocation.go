@@ -0,0 +1,168 @@
+package service
+
+import (
+	"sort"
+
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+)
+
+// Allocation strategies
+const (
+	AllocationNearest      = "nearest"
+	AllocationFewestSplits = "fewest_splits"
+)
+
+// Location is a point orders are shipped to
+type Location struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// StockAllocator decides which warehouses the items of an order ship from.
+// Items whose product is not stocked in any warehouse are left unassigned.
+type StockAllocator interface {
+	Allocate(items []models.OrderItem, availability []repository.WarehouseAvailability, destination *Location) ([]models.OrderItem, error)
+}
+
+// NewStockAllocator creates the allocator for the given strategy
+func NewStockAllocator(strategy string) StockAllocator {
+	if strategy == AllocationNearest {
+		return &nearestAllocator{fallback: &fewestSplitsAllocator{}}
+	}
+	return &fewestSplitsAllocator{}
+}
+
+// nearestAllocator ships every product from the warehouses closest to the destination
+type nearestAllocator struct {
+	fallback StockAllocator
+}
+
+// Allocate assigns the items to warehouses by distance to the destination
+func (a *nearestAllocator) Allocate(items []models.OrderItem, availability []repository.WarehouseAvailability, destination *Location) ([]models.OrderItem, error) {
+	if destination == nil {
+		return a.fallback.Allocate(items, availability, destination)
+	}
+
+	remaining, order := orderedQuantities(items)
+	stock := stockByProduct(availability)
+
+	var allocated []models.OrderItem
+	for _, productID := range order {
+		levels, managed := stock[productID]
+		if !managed {
+			allocated = append(allocated, models.OrderItem{ProductID: productID, Quantity: remaining[productID]})
+			continue
+		}
+
+		sort.SliceStable(levels, func(i, j int) bool {
+			return levels[i].Warehouse.DistanceTo(destination.Latitude, destination.Longitude) <
+				levels[j].Warehouse.DistanceTo(destination.Latitude, destination.Longitude)
+		})
+		for _, level := range levels {
+			if remaining[productID] == 0 {
+				break
+			}
+			quantity := min(level.Available, remaining[productID])
+			if quantity <= 0 {
+				continue
+			}
+			allocated = append(allocated, allocatedItem(productID, level.Warehouse.ID, quantity))
+			remaining[productID] -= quantity
+		}
+		if remaining[productID] > 0 {
+			return nil, repository.ErrInsufficientStock
+		}
+	}
+	return allocated, nil
+}
+
+// fewestSplitsAllocator ships the order from as few warehouses as possible
+type fewestSplitsAllocator struct{}
+
+// Allocate repeatedly picks the warehouse that can ship most of what is still
+// unallocated until everything is allocated
+func (a *fewestSplitsAllocator) Allocate(items []models.OrderItem, availability []repository.WarehouseAvailability, destination *Location) ([]models.OrderItem, error) {
+	remaining, order := orderedQuantities(items)
+	stock := stockByProduct(availability)
+
+	var allocated []models.OrderItem
+	for _, productID := range order {
+		if _, managed := stock[productID]; !managed {
+			allocated = append(allocated, models.OrderItem{ProductID: productID, Quantity: remaining[productID]})
+			delete(remaining, productID)
+		}
+	}
+
+	// available[warehouseID][productID] is what is left to allocate from
+	available := map[int]map[int]int{}
+	var warehouseIDs []int
+	for _, level := range availability {
+		if _, ok := available[level.Warehouse.ID]; !ok {
+			available[level.Warehouse.ID] = map[int]int{}
+			warehouseIDs = append(warehouseIDs, level.Warehouse.ID)
+		}
+		available[level.Warehouse.ID][level.ProductID] = level.Available
+	}
+	sort.Ints(warehouseIDs)
+
+	for len(remaining) > 0 {
+		best, bestUnits := 0, 0
+		for _, warehouseID := range warehouseIDs {
+			units := 0
+			for productID, quantity := range remaining {
+				units += max(0, min(available[warehouseID][productID], quantity))
+			}
+			if units > bestUnits {
+				best, bestUnits = warehouseID, units
+			}
+		}
+		if bestUnits == 0 {
+			return nil, repository.ErrInsufficientStock
+		}
+
+		for _, productID := range order {
+			quantity := min(available[best][productID], remaining[productID])
+			if quantity <= 0 {
+				continue
+			}
+			allocated = append(allocated, allocatedItem(productID, best, quantity))
+			available[best][productID] -= quantity
+			remaining[productID] -= quantity
+			if remaining[productID] == 0 {
+				delete(remaining, productID)
+			}
+		}
+	}
+	return allocated, nil
+}
+
+// orderedQuantities sums the ordered quantity per product, keeping the order products first appear in
+func orderedQuantities(items []models.OrderItem) (map[int]int, []int) {
+	quantities := map[int]int{}
+	var order []int
+	for _, item := range items {
+		if _, ok := quantities[item.ProductID]; !ok {
+			order = append(order, item.ProductID)
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	return quantities, order
+}
+
+// stockByProduct groups the warehouse availability by product
+func stockByProduct(availability []repository.WarehouseAvailability) map[int][]repository.WarehouseAvailability {
+	stock := map[int][]repository.WarehouseAvailability{}
+	for _, level := range availability {
+		stock[level.ProductID] = append(stock[level.ProductID], level)
+	}
+	return stock
+}
+
+func allocatedItem(productID, warehouseID, quantity int) models.OrderItem {
+	return models.OrderItem{
+		ProductID:   productID,
+		Quantity:    quantity,
+		WarehouseID: &warehouseID,
+	}
+}
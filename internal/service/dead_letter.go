@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// DeadLetterService handles business logic related to dead-lettered worker messages
+type DeadLetterService struct {
+	repo repository.DeadLetterRepository
+}
+
+// NewDeadLetterService creates a new dead letter service
+func NewDeadLetterService(repo repository.DeadLetterRepository) *DeadLetterService {
+	return &DeadLetterService{
+		repo: repo,
+	}
+}
+
+// ListDeadLetters retrieves dead-lettered messages with pagination, optionally of a single queue
+func (s *DeadLetterService) ListDeadLetters(ctx context.Context, queue string, page, pageSize int) ([]*models.DeadLetter, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	deadLetters, err := s.repo.List(ctx, queue, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error listing dead letters", err)
+	}
+
+	total, err := s.repo.Count(ctx, queue)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error counting dead letters", err)
+	}
+
+	return deadLetters, total, nil
+}
+
+// GetDeadLetter retrieves a dead-lettered message by ID
+func (s *DeadLetterService) GetDeadLetter(ctx context.Context, id int) (*models.DeadLetter, error) {
+	deadLetter, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("dead letter not found")
+		}
+		return nil, appError.NewServerError("error retrieving dead letter", err)
+	}
+	return deadLetter, nil
+}
+
+// ReplayDeadLetter sends a dead-lettered message back to its queue for a fresh set of attempts
+func (s *DeadLetterService) ReplayDeadLetter(ctx context.Context, id int) (*models.DeadLetter, error) {
+	deadLetter, err := s.GetDeadLetter(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid([]byte(deadLetter.Body)) {
+		return nil, appError.NewBadRequestError("message body is not valid JSON and cannot be replayed")
+	}
+
+	deadLetter, err = s.repo.Replay(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("dead letter not found")
+		}
+		return nil, appError.NewServerError("error replaying dead letter", err)
+	}
+	return deadLetter, nil
+}
+
+// PurgeDeadLetters deletes dead-lettered messages, optionally of a single queue
+func (s *DeadLetterService) PurgeDeadLetters(ctx context.Context, queue string) (int64, error) {
+	deleted, err := s.repo.Purge(ctx, queue)
+	if err != nil {
+		return 0, appError.NewServerError("error purging dead letters", err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"ecom-go/internal/models"
+	"ecom-go/internal/notification"
+	"ecom-go/internal/repository"
+)
+
+// fakeOrderRepo keeps orders in a map and hands out copies, like a database
+type fakeOrderRepo struct {
+	repository.OrderRepository
+	orders  map[int]models.Order
+	updates int
+	locks   int
+}
+
+func newFakeOrderRepo(orders ...models.Order) *fakeOrderRepo {
+	r := &fakeOrderRepo{orders: make(map[int]models.Order)}
+	for _, order := range orders {
+		r.orders[order.OrderID] = order
+	}
+	return r
+}
+
+func (r *fakeOrderRepo) GetByID(ctx context.Context, id int) (*models.Order, error) {
+	order, ok := r.orders[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &order, nil
+}
+
+func (r *fakeOrderRepo) GetByIDForUpdate(ctx context.Context, id int) (*models.Order, error) {
+	r.locks++
+	return r.GetByID(ctx, id)
+}
+
+func (r *fakeOrderRepo) List(ctx context.Context, userID, offset, limit int) ([]*models.Order, error) {
+	var orders []*models.Order
+	for _, order := range r.orders {
+		if userID == 0 || order.UserID == userID {
+			order := order
+			orders = append(orders, &order)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].OrderID > orders[j].OrderID })
+	if offset >= len(orders) {
+		return nil, nil
+	}
+	return orders[offset:min(offset+limit, len(orders))], nil
+}
+
+func (r *fakeOrderRepo) Update(ctx context.Context, order *models.Order) error {
+	r.updates++
+	r.orders[order.OrderID] = *order
+	return nil
+}
+
+// fakeReturnRepo keeps return requests in a map and hands out copies
+type fakeReturnRepo struct {
+	repository.ReturnRepository
+	returns map[int]models.ReturnRequest
+	locks   int
+}
+
+func newFakeReturnRepo(returnRequests ...models.ReturnRequest) *fakeReturnRepo {
+	r := &fakeReturnRepo{returns: make(map[int]models.ReturnRequest)}
+	for _, returnRequest := range returnRequests {
+		r.returns[returnRequest.ID] = returnRequest
+	}
+	return r
+}
+
+func (r *fakeReturnRepo) Create(ctx context.Context, returnRequest *models.ReturnRequest) error {
+	returnRequest.ID = len(r.returns) + 1
+	r.returns[returnRequest.ID] = *returnRequest
+	return nil
+}
+
+func (r *fakeReturnRepo) GetByID(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	returnRequest, ok := r.returns[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &returnRequest, nil
+}
+
+func (r *fakeReturnRepo) GetByIDForUpdate(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	r.locks++
+	return r.GetByID(ctx, id)
+}
+
+func (r *fakeReturnRepo) ListByOrder(ctx context.Context, orderID int) ([]*models.ReturnRequest, error) {
+	var returnRequests []*models.ReturnRequest
+	for _, returnRequest := range r.returns {
+		if returnRequest.OrderID == orderID {
+			returnRequests = append(returnRequests, &returnRequest)
+		}
+	}
+	sort.Slice(returnRequests, func(i, j int) bool { return returnRequests[i].ID < returnRequests[j].ID })
+	return returnRequests, nil
+}
+
+func (r *fakeReturnRepo) Update(ctx context.Context, returnRequest *models.ReturnRequest) error {
+	r.returns[returnRequest.ID] = *returnRequest
+	return nil
+}
+
+// fakeStockRepo records the stock movements, failing with err when set
+type fakeStockRepo struct {
+	repository.StockMovementRepository
+	movements []*models.StockMovement
+	err       error
+}
+
+func (r *fakeStockRepo) Record(ctx context.Context, movements ...*models.StockMovement) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.movements = append(r.movements, movements...)
+	return nil
+}
+
+// fakeReservationRepo records the references committed and released,
+// failing with err when set
+type fakeReservationRepo struct {
+	repository.ReservationRepository
+	committed []string
+	released  []string
+	err       error
+}
+
+func (r *fakeReservationRepo) Commit(ctx context.Context, referenceType, referenceID string, movement models.StockMovement) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.committed = append(r.committed, referenceType+":"+referenceID)
+	return nil
+}
+
+func (r *fakeReservationRepo) Release(ctx context.Context, referenceType, referenceID string) error {
+	if r.err != nil {
+		return r.err
+	}
+	r.released = append(r.released, referenceType+":"+referenceID)
+	return nil
+}
+
+// fakeUserRepo keeps users in a map and hands out copies
+type fakeUserRepo struct {
+	repository.UserRepository
+	users map[uint]models.User
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &user, nil
+}
+
+// fakeEmailLogRepo keeps the email log in memory
+type fakeEmailLogRepo struct {
+	repository.EmailLogRepository
+	logs []*models.EmailLog
+}
+
+func (r *fakeEmailLogRepo) Create(ctx context.Context, log *models.EmailLog) error {
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func (r *fakeEmailLogRepo) WasSent(ctx context.Context, messageID, template string) (bool, error) {
+	for _, log := range r.logs {
+		if log.MessageID == messageID && log.Template == template && log.Status == models.EmailStatusSent {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeNotificationRepo keeps in-app notifications in memory
+type fakeNotificationRepo struct {
+	repository.NotificationRepository
+	notifications []*models.Notification
+}
+
+func (r *fakeNotificationRepo) Create(ctx context.Context, notification *models.Notification) error {
+	r.notifications = append(r.notifications, notification)
+	return nil
+}
+
+func (r *fakeNotificationRepo) Exists(ctx context.Context, messageID, eventType string) (bool, error) {
+	for _, notification := range r.notifications {
+		if notification.MessageID == messageID && notification.EventType == eventType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakePreferenceRepo enables every channel but those opted out of, keyed by
+// event type and channel as "type/channel"
+type fakePreferenceRepo struct {
+	repository.NotificationPreferenceRepository
+	optedOut map[string]bool
+}
+
+func (r *fakePreferenceRepo) IsEnabled(ctx context.Context, userID int, eventType, channel string) (bool, error) {
+	return !r.optedOut[eventType+"/"+channel], nil
+}
+
+// recordingSender records the emails it is asked to send, failing with err
+type recordingSender struct {
+	emails []notification.Email
+	err    error
+}
+
+func (s *recordingSender) Send(ctx context.Context, email notification.Email) error {
+	s.emails = append(s.emails, email)
+	return s.err
+}
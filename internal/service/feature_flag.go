@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/featureflags"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// featureFlagKey is the format of feature flag keys, lowercase as the configuration's keys are
+var featureFlagKey = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// FeatureFlagService handles business logic related to toggling feature flags at runtime
+type FeatureFlagService struct {
+	repo  repository.FeatureFlagRepository
+	flags *featureflags.Evaluator
+}
+
+// NewFeatureFlagService creates a new feature flag service
+func NewFeatureFlagService(repo repository.FeatureFlagRepository, flags *featureflags.Evaluator) *FeatureFlagService {
+	return &FeatureFlagService{
+		repo:  repo,
+		flags: flags,
+	}
+}
+
+// ListFlags retrieves every flag, from the configuration and the database
+func (s *FeatureFlagService) ListFlags(ctx context.Context) ([]*models.FeatureFlag, error) {
+	if err := s.flags.Refresh(ctx); err != nil {
+		return nil, appError.NewServerError("error refreshing feature flags", err)
+	}
+	return s.flags.Flags(), nil
+}
+
+// GetFlag retrieves a flag by key
+func (s *FeatureFlagService) GetFlag(ctx context.Context, key string) (*models.FeatureFlag, error) {
+	if err := s.flags.Refresh(ctx); err != nil {
+		return nil, appError.NewServerError("error refreshing feature flags", err)
+	}
+	flag, ok := s.flags.Flag(key)
+	if !ok {
+		return nil, appError.NewNotFoundError("feature flag not found")
+	}
+	return flag, nil
+}
+
+// SetFlag stores a flag, overriding its definition in the configuration. A
+// flag not stored yet starts from that definition, or disabled when it has none
+func (s *FeatureFlagService) SetFlag(ctx context.Context, key string, setFeatureFlagDTO dtos.SetFeatureFlagDTO) (*models.FeatureFlag, error) {
+	if !featureFlagKey.MatchString(key) {
+		return nil, appError.NewValidationError("key", "must be up to 64 lowercase letters, digits, '_', '.' or '-'")
+	}
+
+	flag, err := s.repo.GetByKey(ctx, key)
+	if errors.Is(err, repository.ErrNotFound) {
+		flag = &models.FeatureFlag{Key: key, Percentage: 100}
+		if configured, ok := s.flags.Flag(key); ok {
+			copied := *configured
+			flag = &copied
+		}
+	} else if err != nil {
+		return nil, appError.NewServerError("error getting feature flag", err)
+	}
+
+	if setFeatureFlagDTO.Description != nil {
+		flag.Description = *setFeatureFlagDTO.Description
+	}
+	if setFeatureFlagDTO.Enabled != nil {
+		flag.Enabled = *setFeatureFlagDTO.Enabled
+	}
+	if setFeatureFlagDTO.Percentage != nil {
+		flag.Percentage = *setFeatureFlagDTO.Percentage
+	}
+	if setFeatureFlagDTO.Rules != nil {
+		flag.Rules = make([]models.FeatureFlagRule, 0, len(*setFeatureFlagDTO.Rules))
+		for _, rule := range *setFeatureFlagDTO.Rules {
+			flag.Rules = append(flag.Rules, models.FeatureFlagRule{Attribute: rule.Attribute, Values: rule.Values})
+		}
+	}
+	if flag.Rules == nil {
+		flag.Rules = []models.FeatureFlagRule{}
+	}
+	flag.Source = models.FeatureFlagSourceDatabase
+
+	if err := s.repo.Save(ctx, flag); err != nil {
+		return nil, appError.NewServerError("error saving feature flag", err)
+	}
+	if err := s.flags.Refresh(ctx); err != nil {
+		return nil, appError.NewServerError("error refreshing feature flags", err)
+	}
+
+	return flag, nil
+}
+
+// DeleteFlag removes a stored flag, reverting to its definition in the configuration if it has one
+func (s *FeatureFlagService) DeleteFlag(ctx context.Context, key string) error {
+	if err := s.repo.Delete(ctx, key); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return appError.NewNotFoundError("feature flag not set through the admin API", err)
+		}
+		return appError.NewServerError("error deleting feature flag", err)
+	}
+	if err := s.flags.Refresh(ctx); err != nil {
+		return appError.NewServerError("error refreshing feature flags", err)
+	}
+	return nil
+}
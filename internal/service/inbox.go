@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// notifiableEvents are the event types users can opt out of being notified of
+var notifiableEvents = []string{
+	events.OrderCreated,
+	events.OrderShipped,
+	events.ReturnRefunded,
+}
+
+// notificationChannels are the channels users are notified on
+var notificationChannels = []string{
+	models.NotificationChannelEmail,
+	models.NotificationChannelInApp,
+}
+
+func isNotifiableEvent(eventType string) bool {
+	for _, notifiable := range notifiableEvents {
+		if notifiable == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// InboxService handles business logic related to users' in-app notifications and notification preferences
+type InboxService struct {
+	notificationRepo repository.NotificationRepository
+	preferenceRepo   repository.NotificationPreferenceRepository
+}
+
+// NewInboxService creates a new inbox service
+func NewInboxService(notificationRepo repository.NotificationRepository, preferenceRepo repository.NotificationPreferenceRepository) *InboxService {
+	return &InboxService{
+		notificationRepo: notificationRepo,
+		preferenceRepo:   preferenceRepo,
+	}
+}
+
+// ListNotifications retrieves a user's notifications with pagination, along with how many are unread
+func (s *InboxService) ListNotifications(ctx context.Context, userID int, unreadOnly bool, page, pageSize int) ([]*models.Notification, int64, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	notifications, err := s.notificationRepo.List(ctx, userID, unreadOnly, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, 0, appError.NewServerError("error listing notifications", err)
+	}
+
+	total, err := s.notificationRepo.Count(ctx, userID, unreadOnly)
+	if err != nil {
+		return nil, 0, 0, appError.NewServerError("error counting notifications", err)
+	}
+
+	unread := total
+	if !unreadOnly {
+		if unread, err = s.notificationRepo.Count(ctx, userID, true); err != nil {
+			return nil, 0, 0, appError.NewServerError("error counting unread notifications", err)
+		}
+	}
+
+	return notifications, total, unread, nil
+}
+
+// MarkRead marks one of a user's notifications as read
+func (s *InboxService) MarkRead(ctx context.Context, userID, id int) (*models.Notification, error) {
+	notification, err := s.notificationRepo.MarkRead(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("notification not found", err)
+		}
+		return nil, appError.NewServerError("error marking notification as read", err)
+	}
+	return notification, nil
+}
+
+// MarkAllRead marks all of a user's notifications as read and returns how many were unread
+func (s *InboxService) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	count, err := s.notificationRepo.MarkAllRead(ctx, userID)
+	if err != nil {
+		return 0, appError.NewServerError("error marking notifications as read", err)
+	}
+	return count, nil
+}
+
+// GetPreferences returns whether the user gets notified of each event type on each channel
+func (s *InboxService) GetPreferences(ctx context.Context, userID int) ([]*models.NotificationPreference, error) {
+	saved, err := s.preferenceRepo.List(ctx, userID)
+	if err != nil {
+		return nil, appError.NewServerError("error getting notification preferences", err)
+	}
+
+	preferences := make([]*models.NotificationPreference, 0, len(notifiableEvents)*len(notificationChannels))
+	for _, eventType := range notifiableEvents {
+		for _, channel := range notificationChannels {
+			preference := &models.NotificationPreference{UserID: userID, EventType: eventType, Channel: channel, Enabled: true}
+			for _, p := range saved {
+				if p.EventType == eventType && p.Channel == channel {
+					preference = p
+					break
+				}
+			}
+			preferences = append(preferences, preference)
+		}
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences opts a user in or out of notifications and returns all their preferences
+func (s *InboxService) UpdatePreferences(ctx context.Context, userID int, updateDTO dtos.UpdateNotificationPreferencesDTO) ([]*models.NotificationPreference, error) {
+	preferences := make([]*models.NotificationPreference, 0, len(updateDTO.Preferences))
+	// A preference listed twice takes its last value
+	index := make(map[[2]string]int)
+	for _, p := range updateDTO.Preferences {
+		if !isNotifiableEvent(p.EventType) {
+			return nil, appError.NewValidationError("event_type", "unknown event type "+p.EventType)
+		}
+		preference := &models.NotificationPreference{
+			UserID:    userID,
+			EventType: p.EventType,
+			Channel:   p.Channel,
+			Enabled:   *p.Enabled,
+		}
+		key := [2]string{p.EventType, p.Channel}
+		if i, ok := index[key]; ok {
+			preferences[i] = preference
+			continue
+		}
+		index[key] = len(preferences)
+		preferences = append(preferences, preference)
+	}
+
+	if err := s.preferenceRepo.Save(ctx, preferences); err != nil {
+		return nil, appError.NewServerError("error saving notification preferences", err)
+	}
+	return s.GetPreferences(ctx, userID)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/models"
+	"ecom-go/internal/notification"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+	"ecom-go/pkg/logger"
+)
+
+// InventoryService handles business logic related to stock
+type InventoryService struct {
+	stockRepo     repository.StockMovementRepository
+	productRepo   repository.ProductRepository
+	warehouseRepo repository.WarehouseRepository
+	orderRepo     repository.OrderRepository
+	cfg           config.InventoryConfig
+}
+
+// NewInventoryService creates a new inventory service
+func NewInventoryService(stockRepo repository.StockMovementRepository, productRepo repository.ProductRepository, warehouseRepo repository.WarehouseRepository, orderRepo repository.OrderRepository, cfg config.InventoryConfig) *InventoryService {
+	return &InventoryService{
+		stockRepo:     stockRepo,
+		productRepo:   productRepo,
+		warehouseRepo: warehouseRepo,
+		orderRepo:     orderRepo,
+		cfg:           cfg,
+	}
+}
+
+// AdjustStock manually changes a product's stock and records why
+func (s *InventoryService) AdjustStock(ctx context.Context, productID int, adjustmentDTO dtos.StockAdjustmentDTO) (*models.StockMovement, error) {
+	if adjustmentDTO.WarehouseID != nil {
+		if _, err := s.warehouseRepo.GetByID(ctx, *adjustmentDTO.WarehouseID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, appError.NewBadRequestError("warehouse not found")
+			}
+			return nil, appError.NewServerError("error retrieving warehouse", err)
+		}
+	}
+
+	movement := &models.StockMovement{
+		ProductID:   productID,
+		WarehouseID: adjustmentDTO.WarehouseID,
+		Quantity:    adjustmentDTO.Quantity,
+		Reason:      adjustmentDTO.Reason,
+		ActorID:     adjustmentDTO.ActorID,
+		Note:        adjustmentDTO.Note,
+	}
+
+	if err := s.stockRepo.Record(ctx, movement); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("product not found")
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, appError.NewBadRequestError("stock cannot go below zero")
+		}
+		return nil, appError.NewServerError("error adjusting stock", err)
+	}
+
+	return movement, nil
+}
+
+// StockHistory retrieves a product's stock movements with pagination
+func (s *InventoryService) StockHistory(ctx context.Context, productID, page, pageSize int) ([]*models.StockMovement, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	if _, err := s.productRepo.GetByID(ctx, productID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, 0, appError.NewNotFoundError("product not found")
+		}
+		return nil, 0, appError.NewServerError("error retrieving product", err)
+	}
+
+	movements, err := s.stockRepo.ListByProduct(ctx, productID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error retrieving stock history", err)
+	}
+
+	total, err := s.stockRepo.CountByProduct(ctx, productID)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error counting stock movements", err)
+	}
+
+	return movements, total, nil
+}
+
+// LowStockReport lists the products at or below their reorder threshold and
+// suggests how much to reorder from their recent sales velocity
+func (s *InventoryService) LowStockReport(ctx context.Context) ([]dtos.LowStockItemDTO, error) {
+	products, err := s.productRepo.ListLowStock(ctx)
+	if err != nil {
+		return nil, appError.NewServerError("error listing low-stock products", err)
+	}
+
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	windowDays := max(s.cfg.SalesWindowDays, 1)
+	since := time.Now().AddDate(0, 0, -windowDays)
+	sales, err := s.orderRepo.SalesByProduct(ctx, since, ids...)
+	if err != nil {
+		return nil, appError.NewServerError("error computing sales velocity", err)
+	}
+
+	items := make([]dtos.LowStockItemDTO, 0, len(products))
+	for _, product := range products {
+		dailySales := float64(sales[product.ID]) / float64(windowDays)
+
+		// Bring stock back to the threshold plus what is expected to sell over the cover period
+		target := product.ReorderThreshold + int(math.Ceil(dailySales*float64(s.cfg.ReorderCoverDays)))
+		items = append(items, dtos.LowStockItemDTO{
+			Product:          product,
+			DailySales:       math.Round(dailySales*100) / 100,
+			SuggestedReorder: max(target-product.Stock, 0),
+		})
+	}
+	return items, nil
+}
+
+// CheckLowStock alerts about products whose stock fell to their reorder
+// threshold since the last check and forgets the ones that were restocked
+func (s *InventoryService) CheckLowStock(ctx context.Context, alerts notification.Notifier) error {
+	if _, err := s.productRepo.ClearRecoveredLowStock(ctx); err != nil {
+		return appError.NewServerError("error clearing restocked products", err)
+	}
+
+	items, err := s.LowStockReport(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		if item.Product.LowStockSince != nil {
+			// Already alerted about this crossing
+			continue
+		}
+
+		alert := notification.Alert{
+			Subject: fmt.Sprintf("Low stock: %s", item.Product.Name),
+			Message: fmt.Sprintf("Stock of %s is down to %d (reorder threshold %d). Suggested reorder: %d units.",
+				item.Product.Name, item.Product.Stock, item.Product.ReorderThreshold, item.SuggestedReorder),
+			Fields: map[string]interface{}{
+				"product_id":        item.Product.ID,
+				"stock":             item.Product.Stock,
+				"reorder_threshold": item.Product.ReorderThreshold,
+				"daily_sales":       item.DailySales,
+				"suggested_reorder": item.SuggestedReorder,
+			},
+		}
+		if err := alerts.Notify(ctx, alert); err != nil {
+			// Leave the product unmarked so the alert is retried on the next check
+			logger.FromContext(ctx).Error("Failed to send low-stock alert", "product_id", item.Product.ID, "error", err)
+			continue
+		}
+
+		if err := s.productRepo.MarkLowStock(ctx, item.Product.ID, time.Now()); err != nil {
+			return appError.NewServerError("error marking low-stock product", err)
+		}
+	}
+	return nil
+}
+
+// RollupDailySales totals the sales per product of the UTC day containing the given time
+func (s *InventoryService) RollupDailySales(ctx context.Context, day time.Time) (int64, error) {
+	day = day.UTC().Truncate(24 * time.Hour)
+	count, err := s.orderRepo.RollupDailySales(ctx, day)
+	if err != nil {
+		return 0, appError.NewServerError("error rolling up daily sales", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// JobService handles business logic related to scheduled worker jobs
+type JobService struct {
+	repo repository.JobRunRepository
+}
+
+// NewJobService creates a new job service
+func NewJobService(repo repository.JobRunRepository) *JobService {
+	return &JobService{
+		repo: repo,
+	}
+}
+
+// ListRuns retrieves the run history of scheduled jobs with pagination, optionally of a single job
+func (s *JobService) ListRuns(ctx context.Context, job string, page, pageSize int) ([]*models.JobRun, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	runs, err := s.repo.List(ctx, job, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error listing job runs", err)
+	}
+
+	total, err := s.repo.Count(ctx, job)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error counting job runs", err)
+	}
+
+	return runs, total, nil
+}
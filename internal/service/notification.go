@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+	"ecom-go/internal/notification"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+	"ecom-go/pkg/logger"
+)
+
+// NotificationService notifies customers of what happens to their account
+// and orders, by email and in their in-app inbox, as their preferences allow
+type NotificationService struct {
+	templates        *notification.Templates
+	sender           notification.EmailSender
+	logRepo          repository.EmailLogRepository
+	notificationRepo repository.NotificationRepository
+	preferenceRepo   repository.NotificationPreferenceRepository
+	userRepo         repository.UserRepository
+	orderRepo        repository.OrderRepository
+	productRepo      repository.ProductRepository
+	returnRepo       repository.ReturnRepository
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(templates *notification.Templates, sender notification.EmailSender, logRepo repository.EmailLogRepository, notificationRepo repository.NotificationRepository, preferenceRepo repository.NotificationPreferenceRepository, userRepo repository.UserRepository, orderRepo repository.OrderRepository, productRepo repository.ProductRepository, returnRepo repository.ReturnRepository) *NotificationService {
+	return &NotificationService{
+		templates:        templates,
+		sender:           sender,
+		logRepo:          logRepo,
+		notificationRepo: notificationRepo,
+		preferenceRepo:   preferenceRepo,
+		userRepo:         userRepo,
+		orderRepo:        orderRepo,
+		productRepo:      productRepo,
+		returnRepo:       returnRepo,
+	}
+}
+
+// orderConfirmationItem is an order line as shown in the confirmation email
+type orderConfirmationItem struct {
+	Name     string
+	Quantity int
+}
+
+// SendWelcome welcomes a user who signed up. messageID identifies the event
+// the user is notified of, a notification already sent for it is not sent again
+func (s *NotificationService) SendWelcome(ctx context.Context, messageID string, userID uint) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.notify(ctx, messageID, user, events.UserCreated, notification.TemplateWelcome, map[string]interface{}{
+		"FirstName": user.FirstName,
+	})
+}
+
+// SendOrderConfirmation confirms an order that was placed
+func (s *NotificationService) SendOrderConfirmation(ctx context.Context, messageID string, orderID int) error {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	user, err := s.getUser(ctx, uint(order.UserID))
+	if err != nil {
+		return err
+	}
+
+	items := make([]orderConfirmationItem, 0, len(order.Products))
+	for _, item := range order.Products {
+		name := "Product #" + strconv.Itoa(item.ProductID)
+		product, err := s.productRepo.GetByID(ctx, item.ProductID)
+		if err == nil {
+			name = product.Name
+		} else if !errors.Is(err, repository.ErrNotFound) {
+			return appError.NewServerError("error getting product", err)
+		}
+		items = append(items, orderConfirmationItem{Name: name, Quantity: item.Quantity})
+	}
+
+	paymentDueAt := ""
+	if order.PaymentDueAt != nil {
+		paymentDueAt = order.PaymentDueAt.UTC().Format(time.RFC1123)
+	}
+
+	return s.notify(ctx, messageID, user, events.OrderCreated, notification.TemplateOrderConfirmation, map[string]interface{}{
+		"FirstName":    user.FirstName,
+		"OrderID":      order.OrderID,
+		"Items":        items,
+		"Total":        order.TotalPrice,
+		"PaymentDueAt": paymentDueAt,
+	})
+}
+
+// SendOrderShipped tells the customer their order has shipped
+func (s *NotificationService) SendOrderShipped(ctx context.Context, messageID string, orderID int) error {
+	order, err := s.getOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+	user, err := s.getUser(ctx, uint(order.UserID))
+	if err != nil {
+		return err
+	}
+	return s.notify(ctx, messageID, user, events.OrderShipped, notification.TemplateOrderShipped, map[string]interface{}{
+		"FirstName": user.FirstName,
+		"OrderID":   order.OrderID,
+	})
+}
+
+// SendRefund tells the customer the items they returned have been refunded
+func (s *NotificationService) SendRefund(ctx context.Context, messageID string, returnID int) error {
+	returnRequest, err := s.returnRepo.GetByID(ctx, returnID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return appError.NewNotFoundError("return request not found", err)
+		}
+		return appError.NewServerError("error getting return request", err)
+	}
+	user, err := s.getUser(ctx, uint(returnRequest.UserID))
+	if err != nil {
+		return err
+	}
+	return s.notify(ctx, messageID, user, events.ReturnRefunded, notification.TemplateRefund, map[string]interface{}{
+		"FirstName": user.FirstName,
+		"OrderID":   returnRequest.OrderID,
+		"Amount":    returnRequest.RefundAmount,
+	})
+}
+
+// notify renders the template and delivers it on every channel the user
+// has not opted out of for the event type
+func (s *NotificationService) notify(ctx context.Context, messageID string, user *models.User, eventType, template string, data interface{}) error {
+	rendered, err := s.templates.Render(template, data)
+	if err != nil {
+		return appError.NewServerError("error rendering notification", err)
+	}
+
+	enabled, err := s.channelEnabled(ctx, user, eventType, models.NotificationChannelInApp)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		if err := s.addToInbox(ctx, messageID, user, eventType, rendered); err != nil {
+			return err
+		}
+	}
+
+	enabled, err = s.channelEnabled(ctx, user, eventType, models.NotificationChannelEmail)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return s.sendEmail(ctx, messageID, user, rendered)
+	}
+	return nil
+}
+
+// channelEnabled reports whether the user gets notified of the event type on the channel
+func (s *NotificationService) channelEnabled(ctx context.Context, user *models.User, eventType, channel string) (bool, error) {
+	if !isNotifiableEvent(eventType) {
+		// Users cannot opt out of the notifications that are not listed
+		return true, nil
+	}
+	enabled, err := s.preferenceRepo.IsEnabled(ctx, int(user.ID), eventType, channel)
+	if err != nil {
+		return false, appError.NewServerError("error getting notification preferences", err)
+	}
+	if !enabled {
+		logger.FromContext(ctx).Info("User opted out of notification", "user_id", user.ID, "event_type", eventType, "channel", channel)
+	}
+	return enabled, nil
+}
+
+// addToInbox adds the rendered notification to the user's in-app inbox
+func (s *NotificationService) addToInbox(ctx context.Context, messageID string, user *models.User, eventType string, rendered *notification.Rendered) error {
+	exists, err := s.notificationRepo.Exists(ctx, messageID, eventType)
+	if err != nil {
+		return appError.NewServerError("error checking notifications", err)
+	}
+	if exists {
+		return nil
+	}
+
+	err = s.notificationRepo.Create(ctx, &models.Notification{
+		UserID:    int(user.ID),
+		EventType: eventType,
+		Title:     rendered.Subject,
+		Body:      strings.TrimSpace(rendered.Text),
+		MessageID: messageID,
+	})
+	if err != nil {
+		return appError.NewServerError("error creating notification", err)
+	}
+	return nil
+}
+
+// sendEmail emails the rendered notification to the user, logging the outcome
+func (s *NotificationService) sendEmail(ctx context.Context, messageID string, user *models.User, rendered *notification.Rendered) error {
+	template := rendered.Template
+	sent, err := s.logRepo.WasSent(ctx, messageID, template)
+	if err != nil {
+		return appError.NewServerError("error checking email log", err)
+	}
+	if sent {
+		logger.FromContext(ctx).Info("Email already sent", "template", template, "message_id", messageID)
+		return nil
+	}
+
+	log := &models.EmailLog{
+		UserID:          int(user.ID),
+		Recipient:       user.Email,
+		Template:        rendered.Template,
+		TemplateVersion: rendered.Version,
+		Subject:         rendered.Subject,
+		MessageID:       messageID,
+	}
+	sendErr := s.sender.Send(ctx, notification.Email{
+		To:      user.Email,
+		Subject: rendered.Subject,
+		Text:    rendered.Text,
+		HTML:    rendered.HTML,
+	})
+	if sendErr != nil {
+		log.Status = models.EmailStatusFailed
+		log.Error = sendErr.Error()
+	} else {
+		now := time.Now()
+		log.Status = models.EmailStatusSent
+		log.SentAt = &now
+	}
+
+	if err := s.logRepo.Create(ctx, log); err != nil {
+		if sendErr == nil {
+			// The email went out, failing now would send it again on retry
+			logger.FromContext(ctx).Error("Failed to log sent email", "template", template, "message_id", messageID, "error", err)
+			return nil
+		}
+		logger.FromContext(ctx).Error("Failed to log email", "template", template, "message_id", messageID, "error", err)
+	}
+	if sendErr != nil {
+		return appError.NewServerError("error sending email", sendErr)
+	}
+	return nil
+}
+
+func (s *NotificationService) getUser(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("user not found", err)
+		}
+		return nil, appError.NewServerError("error getting user", err)
+	}
+	return user, nil
+}
+
+func (s *NotificationService) getOrder(ctx context.Context, id int) (*models.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("order not found", err)
+		}
+		return nil, appError.NewServerError("error getting order", err)
+	}
+	return order, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+	"ecom-go/internal/notification"
+	appError "ecom-go/pkg/errors"
+)
+
+// notificationFixture is a notification service over fakes, knowing user 7
+// and their delivered order 1
+type notificationFixture struct {
+	service       *NotificationService
+	sender        *recordingSender
+	logs          *fakeEmailLogRepo
+	notifications *fakeNotificationRepo
+	preferences   *fakePreferenceRepo
+}
+
+func newNotificationFixture(t *testing.T) *notificationFixture {
+	t.Helper()
+	templates, err := notification.NewTemplates(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &notificationFixture{
+		sender:        &recordingSender{},
+		logs:          &fakeEmailLogRepo{},
+		notifications: &fakeNotificationRepo{},
+		preferences:   &fakePreferenceRepo{optedOut: map[string]bool{}},
+	}
+	users := &fakeUserRepo{users: map[uint]models.User{7: {ID: 7, Email: "buyer@example.com", FirstName: "Ada"}}}
+	orders := newFakeOrderRepo(deliveredOrder(time.Now()))
+	f.service = NewNotificationService(templates, f.sender, f.logs, f.notifications, f.preferences, users, orders, nil, newFakeReturnRepo())
+	return f
+}
+
+func TestNotificationServicePreferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		optedOut   []string
+		wantEmails int
+		wantInbox  int
+	}{
+		{name: "every channel", wantEmails: 1, wantInbox: 1},
+		{name: "email opted out", optedOut: []string{events.OrderShipped + "/" + models.NotificationChannelEmail}, wantInbox: 1},
+		{name: "inbox opted out", optedOut: []string{events.OrderShipped + "/" + models.NotificationChannelInApp}, wantEmails: 1},
+		{name: "other event opted out", optedOut: []string{events.OrderCreated + "/" + models.NotificationChannelEmail}, wantEmails: 1, wantInbox: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newNotificationFixture(t)
+			for _, key := range tt.optedOut {
+				f.preferences.optedOut[key] = true
+			}
+
+			if err := f.service.SendOrderShipped(context.Background(), "msg-1", 1); err != nil {
+				t.Fatal(err)
+			}
+			if len(f.sender.emails) != tt.wantEmails {
+				t.Errorf("sent %d emails, want %d", len(f.sender.emails), tt.wantEmails)
+			}
+			if len(f.notifications.notifications) != tt.wantInbox {
+				t.Errorf("added %d inbox notifications, want %d", len(f.notifications.notifications), tt.wantInbox)
+			}
+		})
+	}
+}
+
+func TestNotificationServiceDeduplicates(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+
+	// A message handled again after it was delivered is not delivered twice
+	for i := 0; i < 2; i++ {
+		if err := f.service.SendOrderShipped(ctx, "msg-1", 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(f.sender.emails) != 1 || len(f.notifications.notifications) != 1 {
+		t.Fatalf("sent %d emails and %d inbox notifications for one message, want one each", len(f.sender.emails), len(f.notifications.notifications))
+	}
+
+	// Another message about the same order is
+	if err := f.service.SendOrderShipped(ctx, "msg-2", 1); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.sender.emails) != 2 {
+		t.Errorf("sent %d emails for two messages, want 2", len(f.sender.emails))
+	}
+}
+
+func TestNotificationServiceRetriesFailedEmails(t *testing.T) {
+	f := newNotificationFixture(t)
+	ctx := context.Background()
+
+	f.sender.err = errors.New("connection refused")
+	if err := f.service.SendWelcome(ctx, "msg-1", 7); errorType(err) != appError.ErrorTypeServer {
+		t.Fatalf("error = %v, want a server error so the message is retried", err)
+	}
+	if len(f.logs.logs) != 1 || f.logs.logs[0].Status != models.EmailStatusFailed {
+		t.Fatalf("email log = %+v, want the failure logged", f.logs.logs)
+	}
+
+	// The failure does not count as sent, the retry sends the email
+	f.sender.err = nil
+	if err := f.service.SendWelcome(ctx, "msg-1", 7); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.sender.emails) != 2 || f.logs.logs[1].Status != models.EmailStatusSent {
+		t.Errorf("sent %d emails, log %+v, want the retry sent", len(f.sender.emails), f.logs.logs[1])
+	}
+}
+
+func TestNotificationServiceNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		send func(s *NotificationService) error
+	}{
+		{name: "user", send: func(s *NotificationService) error { return s.SendWelcome(context.Background(), "msg-1", 8) }},
+		{name: "order", send: func(s *NotificationService) error { return s.SendOrderShipped(context.Background(), "msg-1", 2) }},
+		{name: "return", send: func(s *NotificationService) error { return s.SendRefund(context.Background(), "msg-1", 1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newNotificationFixture(t)
+			// Reported as not found, which the worker does not retry
+			if err := tt.send(f.service); errorType(err) != appError.ErrorTypeNotFound {
+				t.Errorf("error = %v, want not found", err)
+			}
+			if len(f.sender.emails) != 0 {
+				t.Errorf("sent %d emails", len(f.sender.emails))
+			}
+		})
+	}
+}
@@ -2,37 +2,108 @@ package service
 
 import (
 	"context"
+	"ecom-go/internal/config"
 	"ecom-go/internal/dtos"
 	"ecom-go/internal/models"
 	"ecom-go/internal/repository"
 	appError "ecom-go/pkg/errors"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
 )
 
 type OrderService struct {
-	repo repository.OrderRepository
+	repo            repository.OrderRepository
+	stockRepo       repository.StockMovementRepository
+	reservationRepo repository.ReservationRepository
+	warehouseRepo   repository.WarehouseRepository
+	txManager       repository.TxManager
+	allocator       StockAllocator
+	paymentWindow   time.Duration
 }
 
-func NewOrderService(repo repository.OrderRepository) *OrderService {
-	return &OrderService{repo: repo}
+func NewOrderService(repo repository.OrderRepository, stockRepo repository.StockMovementRepository, reservationRepo repository.ReservationRepository, warehouseRepo repository.WarehouseRepository, txManager repository.TxManager, cfg *config.Config) *OrderService {
+	return &OrderService{
+		repo:            repo,
+		stockRepo:       stockRepo,
+		reservationRepo: reservationRepo,
+		warehouseRepo:   warehouseRepo,
+		txManager:       txManager,
+		allocator:       NewStockAllocator(cfg.Inventory.AllocationStrategy),
+		paymentWindow:   cfg.Reservations.OrderTTL,
+	}
 }
 
 // CreateOrder creates a new order
 func (s *OrderService) CreateOrder(ctx context.Context, createOrderDTO *dtos.CreateOrderDTO) (*models.Order, error) {
+	items, err := s.allocate(ctx, createOrderDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	paymentDueAt := time.Now().Add(s.paymentWindow)
 	order := &models.Order{
-		Products: createOrderDTO.Products,
-		UserID:   createOrderDTO.UserID,
+		Products:     items,
+		UserID:       createOrderDTO.UserID,
+		CartID:       createOrderDTO.CartID,
+		PaymentDueAt: &paymentDueAt,
 	}
 
 	if err := s.repo.Create(ctx, order); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, appError.NewBadRequestError("insufficient stock for ordered products")
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewBadRequestError("ordered product not found")
+		}
 		return nil, appError.NewServerError("Failed to create order", err)
 	}
 
 	return order, nil
 }
 
-// ListOrders retrieves a list of orders with pagination
-func (s *OrderService) ListOrders(ctx context.Context, offset, limit int) ([]*models.Order, error) {
-	orders, err := s.repo.List(ctx, offset, limit)
+// allocate splits the ordered items over the warehouses they will ship from
+func (s *OrderService) allocate(ctx context.Context, createOrderDTO *dtos.CreateOrderDTO) ([]models.OrderItem, error) {
+	productIDs := make([]int, 0, len(createOrderDTO.Products))
+	for _, item := range createOrderDTO.Products {
+		if item.Quantity < 1 {
+			return nil, appError.NewValidationError("products", "quantity must be at least 1")
+		}
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	availability, err := s.warehouseRepo.Availability(ctx, productIDs...)
+	if err != nil {
+		return nil, appError.NewServerError("Failed to retrieve warehouse stock", err)
+	}
+
+	var destination *Location
+	if createOrderDTO.ShippingLatitude != nil && createOrderDTO.ShippingLongitude != nil {
+		destination = &Location{Latitude: *createOrderDTO.ShippingLatitude, Longitude: *createOrderDTO.ShippingLongitude}
+	}
+
+	items, err := s.allocator.Allocate(createOrderDTO.Products, availability, destination)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, appError.NewBadRequestError("insufficient stock for ordered products")
+		}
+		return nil, appError.NewServerError("Failed to allocate order", err)
+	}
+	return items, nil
+}
+
+// ListOrders retrieves the orders of a user with pagination, those of every
+// user when userID is 0
+func (s *OrderService) ListOrders(ctx context.Context, userID, page, pageSize int) ([]*models.Order, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	orders, err := s.repo.List(ctx, userID, (page-1)*pageSize, pageSize)
 	if err != nil {
 		return nil, appError.NewServerError("Failed to list orders", err)
 	}
@@ -44,8 +115,134 @@ func (s *OrderService) ListOrders(ctx context.Context, offset, limit int) ([]*mo
 func (s *OrderService) GetOrder(ctx context.Context, id int) (*models.Order, error) {
 	order, err := s.repo.GetByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("order not found")
+		}
 		return nil, appError.NewServerError("Failed to get order", err)
 	}
 
 	return order, nil
 }
+
+// getOrderForUpdate retrieves an order by ID and locks it until the
+// transaction in ctx ends
+func (s *OrderService) getOrderForUpdate(ctx context.Context, id int) (*models.Order, error) {
+	order, err := s.repo.GetByIDForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("order not found")
+		}
+		return nil, appError.NewServerError("Failed to get order", err)
+	}
+
+	return order, nil
+}
+
+// UpdateOrderStatus moves an order to a new status if the transition is allowed.
+// The status and the stock it commits or gives back change together
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, id int, status string) (*models.Order, error) {
+	var order *models.Order
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = s.GetOrder(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if !order.CanTransitionTo(status) {
+			return appError.NewBadRequestError(fmt.Sprintf("cannot change order status from %s to %s", order.Status, status))
+		}
+
+		previousStatus := order.Status
+		now := time.Now()
+		switch status {
+		case models.OrderStatusPaid:
+			// Payment turns the stock held for the order into a real deduction
+			if err := s.commitReservations(ctx, order); err != nil {
+				return err
+			}
+			order.PaidAt = &now
+		case models.OrderStatusShipped:
+			order.ShippedAt = &now
+		case models.OrderStatusCompleted:
+			if order.CompletedAt == nil {
+				order.CompletedAt = &now
+			}
+		}
+		order.Status = status
+
+		if err := s.repo.Update(ctx, order); err != nil {
+			return appError.NewServerError("Failed to update order", err)
+		}
+
+		if status == models.OrderStatusCanceled {
+			return s.releaseStock(ctx, order, previousStatus)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// PayOrder records the payment of a pending order placed by the user. Other
+// users' orders are reported as not found
+func (s *OrderService) PayOrder(ctx context.Context, id, userID int) (*models.Order, error) {
+	order, err := s.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, appError.NewNotFoundError("order not found")
+	}
+	return s.UpdateOrderStatus(ctx, id, models.OrderStatusPaid)
+}
+
+// commitReservations deducts the stock held for an order
+func (s *OrderService) commitReservations(ctx context.Context, order *models.Order) error {
+	movement := models.StockMovement{
+		Reason:        models.StockReasonOrder,
+		ReferenceType: "order",
+		ReferenceID:   &order.OrderID,
+	}
+
+	err := s.reservationRepo.Commit(ctx, models.ReservationReferenceOrder, strconv.Itoa(order.OrderID), movement)
+	if err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return appError.NewBadRequestError("stock reservation expired and the products are no longer available")
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return appError.NewBadRequestError("order has no stock reservation")
+		}
+		return appError.NewServerError("Failed to commit stock reservation", err)
+	}
+	return nil
+}
+
+// releaseStock gives back the stock of a canceled order. Unpaid orders only
+// held stock, paid orders had it deducted.
+func (s *OrderService) releaseStock(ctx context.Context, order *models.Order, previousStatus string) error {
+	if previousStatus == models.OrderStatusPending {
+		if err := s.reservationRepo.Release(ctx, models.ReservationReferenceOrder, strconv.Itoa(order.OrderID)); err != nil {
+			return appError.NewServerError("Failed to release stock reservation", err)
+		}
+		return nil
+	}
+
+	movements := make([]*models.StockMovement, 0, len(order.Products))
+	for _, item := range order.Products {
+		movements = append(movements, &models.StockMovement{
+			ProductID:     item.ProductID,
+			WarehouseID:   item.WarehouseID,
+			Quantity:      item.Quantity,
+			Reason:        models.StockReasonCancel,
+			ReferenceType: "order",
+			ReferenceID:   &order.OrderID,
+		})
+	}
+	if err := s.stockRepo.Record(ctx, movements...); err != nil {
+		return appError.NewServerError("Failed to restock canceled order", err)
+	}
+	return nil
+}
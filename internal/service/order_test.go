@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// errorType returns the application error type of err, empty for other errors
+func errorType(err error) appError.ErrorType {
+	var appErr appError.BaseError
+	if errors.As(err, &appErr) {
+		return appErr.Type()
+	}
+	return ""
+}
+
+func newTestOrderService(orders *fakeOrderRepo, stock *fakeStockRepo, reservations *fakeReservationRepo, tx repository.TxManager) *OrderService {
+	return NewOrderService(orders, stock, reservations, nil, tx, &config.Config{})
+}
+
+func TestOrderServiceUpdateOrderStatus(t *testing.T) {
+	warehouse := 3
+	tests := []struct {
+		name           string
+		status         string
+		to             string
+		reservationErr error
+		wantErr        appError.ErrorType
+		wantCommitted  int
+		wantReleased   int
+		wantMovements  int
+	}{
+		{name: "pay", status: models.OrderStatusPending, to: models.OrderStatusPaid, wantCommitted: 1},
+		{name: "pay with expired reservation", status: models.OrderStatusPending, to: models.OrderStatusPaid, reservationErr: repository.ErrInsufficientStock, wantErr: appError.ErrorTypeBadRequest},
+		{name: "cancel unpaid", status: models.OrderStatusPending, to: models.OrderStatusCanceled, wantReleased: 1},
+		{name: "cancel paid", status: models.OrderStatusPaid, to: models.OrderStatusCanceled, wantMovements: 2},
+		{name: "not allowed", status: models.OrderStatusShipped, to: models.OrderStatusPending, wantErr: appError.ErrorTypeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orders := newFakeOrderRepo(models.Order{
+				OrderID: 1,
+				UserID:  7,
+				Status:  tt.status,
+				Products: []models.OrderItem{
+					{ProductID: 10, Quantity: 2, WarehouseID: &warehouse},
+					{ProductID: 11, Quantity: 1},
+				},
+			})
+			stock := &fakeStockRepo{}
+			reservations := &fakeReservationRepo{err: tt.reservationErr}
+			tx := &repository.FakeTxManager{}
+			service := newTestOrderService(orders, stock, reservations, tx)
+
+			order, err := service.UpdateOrderStatus(context.Background(), 1, tt.to)
+			if tt.wantErr != "" {
+				if errorType(err) != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+				if tx.Rollbacks != 1 || tx.Commits != 0 {
+					t.Errorf("commits = %d, rollbacks = %d, want the transaction rolled back", tx.Commits, tx.Rollbacks)
+				}
+				if orders.updates != 0 {
+					t.Error("order updated although its status could not change")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tx.Commits != 1 || tx.Rollbacks != 0 {
+				t.Errorf("commits = %d, rollbacks = %d, want one commit", tx.Commits, tx.Rollbacks)
+			}
+			if order.Status != tt.to || orders.orders[1].Status != tt.to {
+				t.Errorf("status = %s, stored %s, want %s", order.Status, orders.orders[1].Status, tt.to)
+			}
+			if len(reservations.committed) != tt.wantCommitted || len(reservations.released) != tt.wantReleased {
+				t.Errorf("committed %v, released %v", reservations.committed, reservations.released)
+			}
+			if len(stock.movements) != tt.wantMovements {
+				t.Fatalf("%d stock movements, want %d", len(stock.movements), tt.wantMovements)
+			}
+			for _, movement := range stock.movements {
+				if movement.Reason != models.StockReasonCancel || movement.WarehouseID != order.WarehouseOf(movement.ProductID) {
+					t.Errorf("movement %+v does not restock the canceled item", movement)
+				}
+			}
+		})
+	}
+}
+
+func TestOrderServiceTxFailure(t *testing.T) {
+	orders := newFakeOrderRepo(models.Order{OrderID: 1, Status: models.OrderStatusPending})
+	tx := &repository.FakeTxManager{Err: errors.New("connection refused")}
+	service := newTestOrderService(orders, &fakeStockRepo{}, &fakeReservationRepo{}, tx)
+
+	if _, err := service.UpdateOrderStatus(context.Background(), 1, models.OrderStatusPaid); err == nil {
+		t.Fatal("UpdateOrderStatus succeeded without a transaction")
+	}
+	if orders.updates != 0 {
+		t.Error("order updated without a transaction")
+	}
+}
+
+func TestOrderServicePayOrder(t *testing.T) {
+	orders := newFakeOrderRepo(models.Order{OrderID: 1, UserID: 7, Status: models.OrderStatusPending})
+	reservations := &fakeReservationRepo{}
+	service := newTestOrderService(orders, &fakeStockRepo{}, reservations, &repository.FakeTxManager{})
+
+	if _, err := service.PayOrder(context.Background(), 1, 8); errorType(err) != appError.ErrorTypeNotFound {
+		t.Errorf("paying another user's order = %v, want not found", err)
+	}
+	if _, err := service.PayOrder(context.Background(), 2, 7); errorType(err) != appError.ErrorTypeNotFound {
+		t.Errorf("paying a missing order = %v, want not found", err)
+	}
+	if len(reservations.committed) != 0 {
+		t.Fatal("stock committed for an order paid by someone else")
+	}
+
+	order, err := service.PayOrder(context.Background(), 1, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.OrderStatusPaid || order.PaidAt == nil || time.Since(*order.PaidAt) > time.Minute {
+		t.Errorf("order = %+v, want it paid now", order)
+	}
+}
+
+func TestOrderServiceListOrders(t *testing.T) {
+	orders := newFakeOrderRepo(
+		models.Order{OrderID: 1, UserID: 7},
+		models.Order{OrderID: 2, UserID: 8},
+		models.Order{OrderID: 3, UserID: 7},
+		models.Order{OrderID: 4, UserID: 7},
+	)
+	service := newTestOrderService(orders, &fakeStockRepo{}, &fakeReservationRepo{}, &repository.FakeTxManager{})
+
+	tests := []struct {
+		name     string
+		userID   int
+		page     int
+		pageSize int
+		want     []int
+	}{
+		{name: "own orders", userID: 7, page: 1, pageSize: 10, want: []int{4, 3, 1}},
+		{name: "second page", userID: 7, page: 2, pageSize: 2, want: []int{1}},
+		{name: "every user's orders", userID: 0, page: 1, pageSize: 10, want: []int{4, 3, 2, 1}},
+		{name: "defaults", userID: 8, page: 0, pageSize: 0, want: []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := service.ListOrders(context.Background(), tt.userID, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, order := range got {
+				ids = append(ids, order.OrderID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("orders = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
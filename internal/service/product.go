@@ -10,23 +10,26 @@ import (
 
 // ProductService handles business logic related to products
 type ProductService struct {
-	repo repository.ProductRepository
+	repo            repository.ProductRepository
+	reservationRepo repository.ReservationRepository
 }
 
 // NewProductService creates a new product service
-func NewProductService(repo repository.ProductRepository) *ProductService {
+func NewProductService(repo repository.ProductRepository, reservationRepo repository.ReservationRepository) *ProductService {
 	return &ProductService{
-		repo: repo,
+		repo:            repo,
+		reservationRepo: reservationRepo,
 	}
 }
 
 // CreateProduct creates a new product
 func (s *ProductService) CreateProduct(ctx context.Context, createProductDTO dtos.CreateProductDTO) (*models.Product, error) {
 	product := &models.Product{
-		Name:        createProductDTO.Name,
-		Description: createProductDTO.Description,
-		Price:       createProductDTO.Price,
-		Stock:       createProductDTO.Stock,
+		Name:             createProductDTO.Name,
+		Description:      createProductDTO.Description,
+		Price:            createProductDTO.Price,
+		Stock:            createProductDTO.Stock,
+		ReorderThreshold: createProductDTO.ReorderThreshold,
 	}
 
 	if err := s.repo.Create(ctx, product); err != nil {
@@ -43,6 +46,10 @@ func (s *ProductService) ListProducts(ctx context.Context) ([]*models.Product, e
 		return nil, appError.NewServerError("error listing products", err)
 	}
 
+	if err := s.fillAvailability(ctx, products...); err != nil {
+		return nil, err
+	}
+
 	return products, nil
 }
 
@@ -56,7 +63,9 @@ func (s *ProductService) UpdateProduct(ctx context.Context, id int, updateProduc
 	product.Name = updateProductDTO.Name
 	product.Description = updateProductDTO.Description
 	product.Price = updateProductDTO.Price
-	product.Stock = updateProductDTO.Stock
+	if updateProductDTO.ReorderThreshold != nil {
+		product.ReorderThreshold = *updateProductDTO.ReorderThreshold
+	}
 
 	if err := s.repo.Update(ctx, product); err != nil {
 		return nil, appError.NewServerError("error updating product", err)
@@ -81,5 +90,34 @@ func (s *ProductService) ViewProduct(ctx context.Context, id int) (*models.Produ
 		return nil, appError.NewNotFoundError("product not found")
 	}
 
+	if err := s.fillAvailability(ctx, product); err != nil {
+		return nil, err
+	}
+
 	return product, nil
 }
+
+// fillAvailability sets the available stock of the products from their active reservations
+func (s *ProductService) fillAvailability(ctx context.Context, products ...*models.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	ids := make([]int, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	reserved, err := s.reservationRepo.ReservedQuantities(ctx, ids...)
+	if err != nil {
+		return appError.NewServerError("error retrieving reserved stock", err)
+	}
+
+	for _, product := range products {
+		product.Available = product.Stock - reserved[product.ID]
+		if product.Available < 0 {
+			product.Available = 0
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// ReservationService handles business logic related to stock reservations
+type ReservationService struct {
+	repo    repository.ReservationRepository
+	cartTTL time.Duration
+}
+
+// NewReservationService creates a new reservation service
+func NewReservationService(repo repository.ReservationRepository, cfg config.ReservationsConfig) *ReservationService {
+	return &ReservationService{
+		repo:    repo,
+		cartTTL: cfg.CartTTL,
+	}
+}
+
+// ReserveCart holds stock for the items of a cart, replacing what the cart held before
+func (s *ReservationService) ReserveCart(ctx context.Context, cartID string, reserveCartDTO dtos.ReserveCartDTO) ([]*models.StockReservation, error) {
+	expiresAt := time.Now().Add(s.cartTTL)
+
+	var userID *int
+	if reserveCartDTO.UserID != 0 {
+		userID = &reserveCartDTO.UserID
+	}
+
+	reservations := make([]*models.StockReservation, 0, len(reserveCartDTO.Items))
+	for _, item := range reserveCartDTO.Items {
+		reservations = append(reservations, &models.StockReservation{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UserID:    userID,
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	if err := s.repo.Reserve(ctx, models.ReservationReferenceCart, cartID, reservations...); err != nil {
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return nil, appError.NewBadRequestError("insufficient stock for reserved products")
+		}
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewBadRequestError("reserved product not found")
+		}
+		return nil, appError.NewServerError("error reserving stock", err)
+	}
+
+	return reservations, nil
+}
+
+// GetCartReservations retrieves the stock currently held for a cart
+func (s *ReservationService) GetCartReservations(ctx context.Context, cartID string) ([]*models.StockReservation, error) {
+	reservations, err := s.repo.ListActive(ctx, models.ReservationReferenceCart, cartID)
+	if err != nil {
+		return nil, appError.NewServerError("error retrieving reservations", err)
+	}
+	return reservations, nil
+}
+
+// ReleaseCart gives back the stock held for a cart
+func (s *ReservationService) ReleaseCart(ctx context.Context, cartID string) error {
+	if err := s.repo.Release(ctx, models.ReservationReferenceCart, cartID); err != nil {
+		return appError.NewServerError("error releasing reservations", err)
+	}
+	return nil
+}
+
+// ExpireStale releases all reservations whose TTL has passed
+func (s *ReservationService) ExpireStale(ctx context.Context) (int64, error) {
+	count, err := s.repo.ExpireStale(ctx, time.Now())
+	if err != nil {
+		return 0, appError.NewServerError("error expiring reservations", err)
+	}
+	return count, nil
+}
+
+// RemindAbandonedCarts reminds users of the carts they left behind, once the
+// carts' reservations have been expired for the given time. Carts abandoned
+// more than a day before that are not reminded of anymore.
+func (s *ReservationService) RemindAbandonedCarts(ctx context.Context, after time.Duration) (int, error) {
+	expiredBefore := time.Now().Add(-after)
+	count, err := s.repo.RemindAbandonedCarts(ctx, expiredBefore.Add(-24*time.Hour), expiredBefore)
+	if err != nil {
+		return count, appError.NewServerError("error reminding abandoned carts", err)
+	}
+	return count, nil
+}
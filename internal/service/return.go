@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+	"ecom-go/pkg/logger"
+)
+
+// ReturnService handles business logic related to customer returns
+type ReturnService struct {
+	repo         repository.ReturnRepository
+	stockRepo    repository.StockMovementRepository
+	orderService *OrderService
+	txManager    repository.TxManager
+	window       time.Duration
+}
+
+// NewReturnService creates a new return service
+func NewReturnService(repo repository.ReturnRepository, stockRepo repository.StockMovementRepository, orderService *OrderService, txManager repository.TxManager, cfg config.ReturnsConfig) *ReturnService {
+	return &ReturnService{
+		repo:         repo,
+		stockRepo:    stockRepo,
+		orderService: orderService,
+		txManager:    txManager,
+		window:       time.Duration(cfg.WindowDays) * 24 * time.Hour,
+	}
+}
+
+// RequestReturn creates a return request for a delivered order of the user
+// in the request. Other users' orders are reported as not found
+func (s *ReturnService) RequestReturn(ctx context.Context, createReturnDTO dtos.CreateReturnDTO) (*models.ReturnRequest, error) {
+	var returnRequest *models.ReturnRequest
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		// The order stays locked so that concurrent requests cannot both fit
+		// within the quantities left to return
+		order, err := s.orderService.getOrderForUpdate(ctx, createReturnDTO.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if order.UserID != createReturnDTO.UserID {
+			return appError.NewNotFoundError("order not found")
+		}
+		if order.Status != models.OrderStatusCompleted && order.Status != models.OrderStatusPartiallyRefunded {
+			return appError.NewBadRequestError("only delivered orders can be returned")
+		}
+		if order.CompletedAt == nil || time.Since(*order.CompletedAt) > s.window {
+			return appError.NewBadRequestError("return window has expired")
+		}
+
+		// Make sure nobody returns more than they ordered across all of the order's returns
+		returned, err := s.returnedQuantities(ctx, order.OrderID)
+		if err != nil {
+			return err
+		}
+
+		returnRequest = &models.ReturnRequest{
+			OrderID: order.OrderID,
+			UserID:  createReturnDTO.UserID,
+			Status:  models.ReturnStatusRequested,
+			Note:    createReturnDTO.Note,
+		}
+		for _, item := range createReturnDTO.Items {
+			returned[item.ProductID] += item.Quantity
+			if returned[item.ProductID] > order.QuantityOf(item.ProductID) {
+				return appError.NewValidationError("items", fmt.Sprintf("return quantity for product %d exceeds ordered quantity", item.ProductID))
+			}
+			returnRequest.Items = append(returnRequest.Items, models.ReturnItem{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				Reason:    item.Reason,
+			})
+		}
+
+		if err := s.repo.Create(ctx, returnRequest); err != nil {
+			return appError.NewServerError("error creating return request", err)
+		}
+
+		_, err = s.orderService.UpdateOrderStatus(ctx, order.OrderID, models.OrderStatusReturnRequested)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return returnRequest, nil
+}
+
+// GetReturn retrieves a return request by ID
+func (s *ReturnService) GetReturn(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	returnRequest, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("return request not found")
+		}
+		return nil, appError.NewServerError("error retrieving return request", err)
+	}
+	return returnRequest, nil
+}
+
+// getReturnForUpdate retrieves a return request by ID and locks it until the
+// transaction in ctx ends, so that two callers cannot both act on its status
+func (s *ReturnService) getReturnForUpdate(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	returnRequest, err := s.repo.GetByIDForUpdate(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("return request not found")
+		}
+		return nil, appError.NewServerError("error retrieving return request", err)
+	}
+	return returnRequest, nil
+}
+
+// ListReturns retrieves the return requests of a user with pagination, those
+// of every user when userID is 0
+func (s *ReturnService) ListReturns(ctx context.Context, userID, page, pageSize int) ([]*models.ReturnRequest, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	returnRequests, err := s.repo.List(ctx, userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, appError.NewServerError("error listing return requests", err)
+	}
+	return returnRequests, nil
+}
+
+// ApproveReturn accepts a return request so the customer can ship the goods back
+func (s *ReturnService) ApproveReturn(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	var returnRequest *models.ReturnRequest
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		returnRequest, err = s.getReturnForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if returnRequest.Status != models.ReturnStatusRequested {
+			return appError.NewBadRequestError("only requested returns can be approved")
+		}
+
+		now := time.Now()
+		returnRequest.Status = models.ReturnStatusApproved
+		returnRequest.ApprovedAt = &now
+
+		if err := s.repo.Update(ctx, returnRequest); err != nil {
+			return appError.NewServerError("error approving return request", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return returnRequest, nil
+}
+
+// RejectReturn declines a return request and puts the order back in its previous state
+func (s *ReturnService) RejectReturn(ctx context.Context, id int, reason string) (*models.ReturnRequest, error) {
+	var returnRequest *models.ReturnRequest
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		returnRequest, err = s.getReturnForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if returnRequest.Status != models.ReturnStatusRequested && returnRequest.Status != models.ReturnStatusApproved {
+			return appError.NewBadRequestError("return request can no longer be rejected")
+		}
+
+		returnRequest.Status = models.ReturnStatusRejected
+		returnRequest.RejectionReason = reason
+
+		if err := s.repo.Update(ctx, returnRequest); err != nil {
+			return appError.NewServerError("error rejecting return request", err)
+		}
+
+		return s.restoreOrderStatus(ctx, returnRequest.OrderID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return returnRequest, nil
+}
+
+// ReceiveReturn records the arrival of the returned goods, puts them back into
+// stock and refunds the customer, all or nothing
+func (s *ReturnService) ReceiveReturn(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	var returnRequest *models.ReturnRequest
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		returnRequest, err = s.getReturnForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if returnRequest.Status != models.ReturnStatusApproved {
+			return appError.NewBadRequestError("only approved returns can be received")
+		}
+
+		// Returned goods go back to the warehouse they were shipped from. The
+		// order stays locked while its status is worked out from its returns
+		order, err := s.orderService.getOrderForUpdate(ctx, returnRequest.OrderID)
+		if err != nil {
+			return err
+		}
+
+		movements := make([]*models.StockMovement, 0, len(returnRequest.Items))
+		for _, item := range returnRequest.Items {
+			movements = append(movements, &models.StockMovement{
+				ProductID:     item.ProductID,
+				WarehouseID:   order.WarehouseOf(item.ProductID),
+				Quantity:      item.Quantity,
+				Reason:        models.StockReasonReturn,
+				ReferenceType: "return",
+				ReferenceID:   &returnRequest.ID,
+			})
+		}
+		if err := s.stockRepo.Record(ctx, movements...); err != nil {
+			return appError.NewServerError("error restocking returned products", err)
+		}
+
+		now := time.Now()
+		returnRequest.Status = models.ReturnStatusReceived
+		returnRequest.ReceivedAt = &now
+
+		if err := s.repo.Update(ctx, returnRequest); err != nil {
+			return appError.NewServerError("error receiving return request", err)
+		}
+
+		return s.refund(ctx, returnRequest, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return returnRequest, nil
+}
+
+// refund settles a received return at the prices paid for the order and
+// updates the order's status
+func (s *ReturnService) refund(ctx context.Context, returnRequest *models.ReturnRequest, order *models.Order) error {
+	amount := 0.0
+	for _, item := range returnRequest.Items {
+		amount += order.UnitPriceOf(item.ProductID) * float64(item.Quantity)
+	}
+
+	now := time.Now()
+	returnRequest.Status = models.ReturnStatusRefunded
+	returnRequest.RefundAmount = amount
+	returnRequest.RefundedAt = &now
+
+	if err := s.repo.Update(ctx, returnRequest); err != nil {
+		return appError.NewServerError("error refunding return request", err)
+	}
+	logger.FromContext(ctx).Info("Return refunded", "return_id", returnRequest.ID, "order_id", returnRequest.OrderID, "amount", amount)
+
+	return s.restoreOrderStatus(ctx, returnRequest.OrderID)
+}
+
+// restoreOrderStatus sets the order's status from the outcome of its returns
+func (s *ReturnService) restoreOrderStatus(ctx context.Context, orderID int) error {
+	order, err := s.orderService.GetOrder(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	returnRequests, err := s.repo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return appError.NewServerError("error listing return requests", err)
+	}
+
+	refunded := map[int]int{}
+	for _, returnRequest := range returnRequests {
+		if returnRequest.IsOpen() {
+			// Another return of this order is still in progress
+			return nil
+		}
+		if returnRequest.Status != models.ReturnStatusRefunded {
+			continue
+		}
+		for _, item := range returnRequest.Items {
+			refunded[item.ProductID] += item.Quantity
+		}
+	}
+
+	status := models.OrderStatusCompleted
+	if len(refunded) > 0 {
+		status = models.OrderStatusRefunded
+		for _, item := range order.Products {
+			if refunded[item.ProductID] < order.QuantityOf(item.ProductID) {
+				status = models.OrderStatusPartiallyRefunded
+				break
+			}
+		}
+	}
+
+	_, err = s.orderService.UpdateOrderStatus(ctx, orderID, status)
+	return err
+}
+
+// returnedQuantities sums the quantities per product that are already being
+// returned or have been refunded for an order
+func (s *ReturnService) returnedQuantities(ctx context.Context, orderID int) (map[int]int, error) {
+	returnRequests, err := s.repo.ListByOrder(ctx, orderID)
+	if err != nil {
+		return nil, appError.NewServerError("error listing return requests", err)
+	}
+
+	returned := map[int]int{}
+	for _, returnRequest := range returnRequests {
+		if returnRequest.Status == models.ReturnStatusRejected {
+			continue
+		}
+		for _, item := range returnRequest.Items {
+			returned[item.ProductID] += item.Quantity
+		}
+	}
+	return returned, nil
+}
@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ecom-go/internal/config"
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// deliveredOrder is a delivered order of user 7: two units of product 10 at
+// 12.50 shipped from warehouse 3, and one of product 11 at 4.00
+func deliveredOrder(completedAt time.Time) models.Order {
+	warehouse := 3
+	return models.Order{
+		OrderID:     1,
+		UserID:      7,
+		Status:      models.OrderStatusCompleted,
+		CompletedAt: &completedAt,
+		Products: []models.OrderItem{
+			{ProductID: 10, Quantity: 2, UnitPrice: 12.5, WarehouseID: &warehouse},
+			{ProductID: 11, Quantity: 1, UnitPrice: 4},
+		},
+	}
+}
+
+func newTestReturnService(orders *fakeOrderRepo, returns *fakeReturnRepo, stock *fakeStockRepo, tx repository.TxManager) *ReturnService {
+	orderService := newTestOrderService(orders, stock, &fakeReservationRepo{}, tx)
+	return NewReturnService(returns, stock, orderService, tx, config.ReturnsConfig{WindowDays: 30})
+}
+
+func TestReturnServiceRequestReturn(t *testing.T) {
+	item := func(productID, quantity int) dtos.CreateReturnItemDTO {
+		return dtos.CreateReturnItemDTO{ProductID: productID, Quantity: quantity, Reason: "damaged"}
+	}
+	tests := []struct {
+		name        string
+		userID      int
+		completedAt time.Time
+		previous    []models.ReturnRequest
+		items       []dtos.CreateReturnItemDTO
+		wantErr     appError.ErrorType
+	}{
+		{name: "own order", userID: 7, items: []dtos.CreateReturnItemDTO{item(10, 2)}},
+		{name: "other user's order", userID: 8, items: []dtos.CreateReturnItemDTO{item(10, 1)}, wantErr: appError.ErrorTypeNotFound},
+		{name: "window expired", userID: 7, completedAt: time.Now().Add(-31 * 24 * time.Hour), items: []dtos.CreateReturnItemDTO{item(10, 1)}, wantErr: appError.ErrorTypeBadRequest},
+		{name: "more than ordered", userID: 7, items: []dtos.CreateReturnItemDTO{item(10, 1), item(10, 2)}, wantErr: appError.ErrorTypeBadRequest},
+		{
+			name:   "more than left after a refund",
+			userID: 7,
+			previous: []models.ReturnRequest{
+				{ID: 1, OrderID: 1, Status: models.ReturnStatusRefunded, Items: []models.ReturnItem{{ProductID: 10, Quantity: 2}}},
+			},
+			items:   []dtos.CreateReturnItemDTO{item(10, 1)},
+			wantErr: appError.ErrorTypeBadRequest,
+		},
+		{
+			name:   "again after a rejection",
+			userID: 7,
+			previous: []models.ReturnRequest{
+				{ID: 1, OrderID: 1, Status: models.ReturnStatusRejected, Items: []models.ReturnItem{{ProductID: 10, Quantity: 2}}},
+			},
+			items: []dtos.CreateReturnItemDTO{item(10, 2)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completedAt := tt.completedAt
+			if completedAt.IsZero() {
+				completedAt = time.Now().Add(-24 * time.Hour)
+			}
+			orders := newFakeOrderRepo(deliveredOrder(completedAt))
+			returns := newFakeReturnRepo(tt.previous...)
+			tx := &repository.FakeTxManager{}
+			service := newTestReturnService(orders, returns, &fakeStockRepo{}, tx)
+
+			returnRequest, err := service.RequestReturn(context.Background(), dtos.CreateReturnDTO{OrderID: 1, UserID: tt.userID, Items: tt.items})
+			if tt.wantErr != "" {
+				if errorType(err) != tt.wantErr {
+					t.Fatalf("error = %v, want %s", err, tt.wantErr)
+				}
+				if len(returns.returns) != len(tt.previous) {
+					t.Error("return request created")
+				}
+				if tx.Rollbacks != 1 {
+					t.Errorf("rollbacks = %d, want the transaction rolled back", tx.Rollbacks)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The order is locked before the quantities already returned are summed
+			if orders.locks != 1 {
+				t.Errorf("order locked %d times, want once", orders.locks)
+			}
+			if returnRequest.UserID != tt.userID || returnRequest.Status != models.ReturnStatusRequested {
+				t.Errorf("return request = %+v", returnRequest)
+			}
+			if status := orders.orders[1].Status; status != models.OrderStatusReturnRequested {
+				t.Errorf("order status = %s, want %s", status, models.OrderStatusReturnRequested)
+			}
+		})
+	}
+}
+
+func TestReturnServiceReceiveReturn(t *testing.T) {
+	tests := []struct {
+		name       string
+		items      []models.ReturnItem
+		wantAmount float64
+		wantStatus string
+	}{
+		{
+			name:       "every item",
+			items:      []models.ReturnItem{{ProductID: 10, Quantity: 2}, {ProductID: 11, Quantity: 1}},
+			wantAmount: 29,
+			wantStatus: models.OrderStatusRefunded,
+		},
+		{
+			name:       "some items",
+			items:      []models.ReturnItem{{ProductID: 10, Quantity: 1}},
+			wantAmount: 12.5,
+			wantStatus: models.OrderStatusPartiallyRefunded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := deliveredOrder(time.Now())
+			order.Status = models.OrderStatusReturnRequested
+			orders := newFakeOrderRepo(order)
+			returns := newFakeReturnRepo(models.ReturnRequest{ID: 1, OrderID: 1, UserID: 7, Status: models.ReturnStatusApproved, Items: tt.items})
+			stock := &fakeStockRepo{}
+			tx := &repository.FakeTxManager{}
+			service := newTestReturnService(orders, returns, stock, tx)
+
+			returnRequest, err := service.ReceiveReturn(context.Background(), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Refunded at the prices paid, whatever the products cost now
+			if returnRequest.Status != models.ReturnStatusRefunded || returnRequest.RefundAmount != tt.wantAmount {
+				t.Errorf("return request %s refunding %.2f, want refunded %.2f", returnRequest.Status, returnRequest.RefundAmount, tt.wantAmount)
+			}
+			if status := orders.orders[1].Status; status != tt.wantStatus {
+				t.Errorf("order status = %s, want %s", status, tt.wantStatus)
+			}
+			if len(stock.movements) != len(tt.items) {
+				t.Fatalf("%d stock movements, want %d", len(stock.movements), len(tt.items))
+			}
+			for _, movement := range stock.movements {
+				if movement.Reason != models.StockReasonReturn || movement.WarehouseID != order.WarehouseOf(movement.ProductID) {
+					t.Errorf("movement %+v does not restock the shipping warehouse", movement)
+				}
+			}
+			// The order status changes in the return's transaction
+			if tx.Commits != 2 || tx.Rollbacks != 0 {
+				t.Errorf("commits = %d, rollbacks = %d, want the return and the nested order update committed", tx.Commits, tx.Rollbacks)
+			}
+		})
+	}
+}
+
+func TestReturnServiceReceiveReturnRollsBack(t *testing.T) {
+	orders := newFakeOrderRepo(deliveredOrder(time.Now()))
+	returns := newFakeReturnRepo(models.ReturnRequest{ID: 1, OrderID: 1, UserID: 7, Status: models.ReturnStatusApproved, Items: []models.ReturnItem{{ProductID: 10, Quantity: 1}}})
+	stock := &fakeStockRepo{err: errors.New("deadlock")}
+	tx := &repository.FakeTxManager{}
+	service := newTestReturnService(orders, returns, stock, tx)
+
+	if _, err := service.ReceiveReturn(context.Background(), 1); errorType(err) != appError.ErrorTypeServer {
+		t.Fatalf("error = %v, want a server error", err)
+	}
+	if tx.Rollbacks != 1 || tx.Commits != 0 {
+		t.Errorf("commits = %d, rollbacks = %d, want the transaction rolled back", tx.Commits, tx.Rollbacks)
+	}
+	if status := returns.returns[1].Status; status != models.ReturnStatusApproved {
+		t.Errorf("return status = %s, want it still approved", status)
+	}
+
+	if _, err := service.ReceiveReturn(context.Background(), 2); errorType(err) != appError.ErrorTypeNotFound {
+		t.Errorf("receiving a missing return = %v, want not found", err)
+	}
+}
+
+func TestReturnServiceStatusChangesLockTheReturn(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		change  func(*ReturnService) (*models.ReturnRequest, error)
+		want    string
+		wantErr appError.ErrorType
+	}{
+		{
+			name:   "approve requested",
+			status: models.ReturnStatusRequested,
+			change: func(s *ReturnService) (*models.ReturnRequest, error) { return s.ApproveReturn(context.Background(), 1) },
+			want:   models.ReturnStatusApproved,
+		},
+		{
+			name:    "approve approved",
+			status:  models.ReturnStatusApproved,
+			change:  func(s *ReturnService) (*models.ReturnRequest, error) { return s.ApproveReturn(context.Background(), 1) },
+			want:    models.ReturnStatusApproved,
+			wantErr: appError.ErrorTypeBadRequest,
+		},
+		{
+			name:   "reject approved",
+			status: models.ReturnStatusApproved,
+			change: func(s *ReturnService) (*models.ReturnRequest, error) {
+				return s.RejectReturn(context.Background(), 1, "not damaged")
+			},
+			want: models.ReturnStatusRejected,
+		},
+		{
+			name:   "reject refunded",
+			status: models.ReturnStatusRefunded,
+			change: func(s *ReturnService) (*models.ReturnRequest, error) {
+				return s.RejectReturn(context.Background(), 1, "not damaged")
+			},
+			want:    models.ReturnStatusRefunded,
+			wantErr: appError.ErrorTypeBadRequest,
+		},
+		{
+			name:    "receive refunded",
+			status:  models.ReturnStatusRefunded,
+			change:  func(s *ReturnService) (*models.ReturnRequest, error) { return s.ReceiveReturn(context.Background(), 1) },
+			want:    models.ReturnStatusRefunded,
+			wantErr: appError.ErrorTypeBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := deliveredOrder(time.Now())
+			order.Status = models.OrderStatusReturnRequested
+			returns := newFakeReturnRepo(models.ReturnRequest{ID: 1, OrderID: 1, UserID: 7, Status: tt.status, Items: []models.ReturnItem{{ProductID: 10, Quantity: 1}}})
+			tx := &repository.FakeTxManager{}
+			service := newTestReturnService(newFakeOrderRepo(order), returns, &fakeStockRepo{}, tx)
+
+			_, err := tt.change(service)
+			if errorType(err) != tt.wantErr {
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			// The status is checked on a locked read within the transaction
+			if returns.locks != 1 {
+				t.Errorf("return locked %d times, want once", returns.locks)
+			}
+			if tt.wantErr != "" && tx.Rollbacks != 1 {
+				t.Errorf("rollbacks = %d, want the transaction rolled back", tx.Rollbacks)
+			}
+			if status := returns.returns[1].Status; status != tt.want {
+				t.Errorf("return status = %s, want %s", status, tt.want)
+			}
+		})
+	}
+}
@@ -39,7 +39,7 @@ func (s *UserService) Create(ctx context.Context, createUserDTO dtos.CreateUserD
 		Password:  createUserDTO.Password,
 		FirstName: createUserDTO.FirstName,
 		LastName:  createUserDTO.LastName,
-		Role:      "user", // Default role
+		Role:      models.RoleUser, // Default role
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -57,6 +57,19 @@ func (s *UserService) Create(ctx context.Context, createUserDTO dtos.CreateUserD
 	return user, nil
 }
 
+// Authenticate returns the user with the email and password. Unknown emails
+// and wrong passwords get the same error, so that emails can't be probed
+func (s *UserService) Authenticate(ctx context.Context, loginDTO dtos.LoginDTO) (*models.User, error) {
+	user, err := s.repo.GetByEmail(ctx, loginDTO.Email)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return nil, appError.NewServerError("error retrieving user", err)
+	}
+	if user == nil || !user.CheckPassword(loginDTO.Password) {
+		return nil, appError.NewUnauthorizedError("invalid email or password")
+	}
+	return user, nil
+}
+
 // GetByID retrieves a user by ID
 func (s *UserService) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	user, err := s.repo.GetByID(ctx, id)
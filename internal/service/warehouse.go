@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	appError "ecom-go/pkg/errors"
+)
+
+// WarehouseService handles business logic related to warehouses
+type WarehouseService struct {
+	repo repository.WarehouseRepository
+}
+
+// NewWarehouseService creates a new warehouse service
+func NewWarehouseService(repo repository.WarehouseRepository) *WarehouseService {
+	return &WarehouseService{
+		repo: repo,
+	}
+}
+
+// CreateWarehouse creates a new warehouse
+func (s *WarehouseService) CreateWarehouse(ctx context.Context, createWarehouseDTO dtos.CreateWarehouseDTO) (*models.Warehouse, error) {
+	warehouse := &models.Warehouse{
+		Code:      createWarehouseDTO.Code,
+		Name:      createWarehouseDTO.Name,
+		Latitude:  createWarehouseDTO.Latitude,
+		Longitude: createWarehouseDTO.Longitude,
+		Active:    true,
+	}
+
+	if err := s.repo.Create(ctx, warehouse); err != nil {
+		if errors.Is(err, repository.ErrConflict) {
+			return nil, appError.NewBadRequestError("warehouse code already exists")
+		}
+		return nil, appError.NewServerError("error creating warehouse", err)
+	}
+
+	return warehouse, nil
+}
+
+// ListWarehouses retrieves all warehouses
+func (s *WarehouseService) ListWarehouses(ctx context.Context) ([]*models.Warehouse, error) {
+	warehouses, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, appError.NewServerError("error listing warehouses", err)
+	}
+	return warehouses, nil
+}
+
+// GetWarehouse retrieves a warehouse by ID
+func (s *WarehouseService) GetWarehouse(ctx context.Context, id int) (*models.Warehouse, error) {
+	warehouse, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("warehouse not found")
+		}
+		return nil, appError.NewServerError("error retrieving warehouse", err)
+	}
+	return warehouse, nil
+}
+
+// UpdateWarehouse updates a warehouse
+func (s *WarehouseService) UpdateWarehouse(ctx context.Context, id int, updateWarehouseDTO dtos.UpdateWarehouseDTO) (*models.Warehouse, error) {
+	warehouse, err := s.GetWarehouse(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if updateWarehouseDTO.Name != "" {
+		warehouse.Name = updateWarehouseDTO.Name
+	}
+	if updateWarehouseDTO.Latitude != nil {
+		warehouse.Latitude = *updateWarehouseDTO.Latitude
+	}
+	if updateWarehouseDTO.Longitude != nil {
+		warehouse.Longitude = *updateWarehouseDTO.Longitude
+	}
+	if updateWarehouseDTO.Active != nil {
+		warehouse.Active = *updateWarehouseDTO.Active
+	}
+
+	if err := s.repo.Update(ctx, warehouse); err != nil {
+		return nil, appError.NewServerError("error updating warehouse", err)
+	}
+
+	return warehouse, nil
+}
+
+// StockLevels retrieves the stock levels of a warehouse
+func (s *WarehouseService) StockLevels(ctx context.Context, id int) ([]*models.WarehouseStock, error) {
+	if _, err := s.GetWarehouse(ctx, id); err != nil {
+		return nil, err
+	}
+
+	levels, err := s.repo.StockLevels(ctx, id)
+	if err != nil {
+		return nil, appError.NewServerError("error retrieving stock levels", err)
+	}
+	return levels, nil
+}
+
+// TransferStock moves stock of a product from one warehouse to another
+func (s *WarehouseService) TransferStock(ctx context.Context, transferDTO dtos.StockTransferDTO) error {
+	for _, id := range []int{transferDTO.FromWarehouseID, transferDTO.ToWarehouseID} {
+		if _, err := s.GetWarehouse(ctx, id); err != nil {
+			return err
+		}
+	}
+
+	movement := models.StockMovement{
+		ProductID: transferDTO.ProductID,
+		Quantity:  transferDTO.Quantity,
+		Reason:    models.StockReasonTransfer,
+		ActorID:   transferDTO.ActorID,
+		Note:      transferDTO.Note,
+	}
+
+	if err := s.repo.Transfer(ctx, transferDTO.FromWarehouseID, transferDTO.ToWarehouseID, movement); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return appError.NewNotFoundError("product not found")
+		}
+		if errors.Is(err, repository.ErrInsufficientStock) {
+			return appError.NewBadRequestError("not enough unreserved stock in source warehouse")
+		}
+		return appError.NewServerError("error transferring stock", err)
+	}
+	return nil
+}
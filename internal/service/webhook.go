@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"ecom-go/internal/dtos"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	"ecom-go/internal/webhook"
+	appError "ecom-go/pkg/errors"
+)
+
+// WebhookService handles business logic related to partner webhook subscriptions
+type WebhookService struct {
+	repo repository.WebhookRepository
+}
+
+// NewWebhookService creates a new webhook service
+func NewWebhookService(repo repository.WebhookRepository) *WebhookService {
+	return &WebhookService{
+		repo: repo,
+	}
+}
+
+// CreateWebhook subscribes an endpoint to events and returns the secret its payloads are signed with
+func (s *WebhookService) CreateWebhook(ctx context.Context, createWebhookDTO dtos.CreateWebhookDTO) (*models.WebhookSubscription, string, error) {
+	if err := validateWebhookURL(createWebhookDTO.URL); err != nil {
+		return nil, "", err
+	}
+	if err := validateEventTypes(createWebhookDTO.EventTypes); err != nil {
+		return nil, "", err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", appError.NewServerError("error generating webhook secret", err)
+	}
+
+	subscription := &models.WebhookSubscription{
+		URL:         createWebhookDTO.URL,
+		Description: createWebhookDTO.Description,
+		EventTypes:  createWebhookDTO.EventTypes,
+		Secret:      secret,
+		Active:      true,
+	}
+	if err := s.repo.Create(ctx, subscription); err != nil {
+		return nil, "", appError.NewServerError("error creating webhook", err)
+	}
+
+	return subscription, secret, nil
+}
+
+// GetWebhook retrieves a webhook subscription by ID
+func (s *WebhookService) GetWebhook(ctx context.Context, id int) (*models.WebhookSubscription, error) {
+	subscription, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("webhook not found", err)
+		}
+		return nil, appError.NewServerError("error getting webhook", err)
+	}
+	return subscription, nil
+}
+
+// ListWebhooks retrieves webhook subscriptions with pagination
+func (s *WebhookService) ListWebhooks(ctx context.Context, page, pageSize int) ([]*models.WebhookSubscription, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	subscriptions, err := s.repo.List(ctx, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error listing webhooks", err)
+	}
+
+	total, err := s.repo.Count(ctx)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error counting webhooks", err)
+	}
+
+	return subscriptions, total, nil
+}
+
+// UpdateWebhook updates a webhook subscription. Enabling a disabled endpoint
+// clears its count of failed deliveries
+func (s *WebhookService) UpdateWebhook(ctx context.Context, id int, updateWebhookDTO dtos.UpdateWebhookDTO) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetWebhook(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if updateWebhookDTO.URL != "" {
+		if err := validateWebhookURL(updateWebhookDTO.URL); err != nil {
+			return nil, err
+		}
+		subscription.URL = updateWebhookDTO.URL
+	}
+	if updateWebhookDTO.Description != nil {
+		subscription.Description = *updateWebhookDTO.Description
+	}
+	if len(updateWebhookDTO.EventTypes) > 0 {
+		if err := validateEventTypes(updateWebhookDTO.EventTypes); err != nil {
+			return nil, err
+		}
+		subscription.EventTypes = updateWebhookDTO.EventTypes
+	}
+	if updateWebhookDTO.Active != nil {
+		if *updateWebhookDTO.Active && !subscription.Active {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+			subscription.DisabledReason = ""
+		}
+		subscription.Active = *updateWebhookDTO.Active
+	}
+
+	if err := s.repo.Update(ctx, subscription); err != nil {
+		return nil, appError.NewServerError("error updating webhook", err)
+	}
+
+	return subscription, nil
+}
+
+// DeleteWebhook removes a webhook subscription
+func (s *WebhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return appError.NewNotFoundError("webhook not found", err)
+		}
+		return appError.NewServerError("error deleting webhook", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves a webhook subscription's delivery log with pagination
+func (s *WebhookService) ListDeliveries(ctx context.Context, id, page, pageSize int) ([]*models.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 10
+	}
+
+	deliveries, err := s.repo.ListDeliveries(ctx, id, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error listing webhook deliveries", err)
+	}
+
+	total, err := s.repo.CountDeliveries(ctx, id)
+	if err != nil {
+		return nil, 0, appError.NewServerError("error counting webhook deliveries", err)
+	}
+
+	return deliveries, total, nil
+}
+
+// Redeliver sends the event of a logged delivery to its endpoint again
+func (s *WebhookService) Redeliver(ctx context.Context, deliveryID int) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("webhook delivery not found", err)
+		}
+		return nil, appError.NewServerError("error getting webhook delivery", err)
+	}
+	subscription, err := s.GetWebhook(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !subscription.Active {
+		return nil, appError.NewBadRequestError("webhook is disabled, enable it before redelivering")
+	}
+
+	delivery, err = s.repo.Redeliver(ctx, deliveryID, webhook.DeliveryQueue)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return nil, appError.NewNotFoundError("webhook delivery not found", err)
+		}
+		return nil, appError.NewServerError("error redelivering webhook", err)
+	}
+	return delivery, nil
+}
+
+func validateWebhookURL(rawURL string) error {
+	if err := webhook.ValidateURL(rawURL); err != nil {
+		return appError.NewValidationError("url", err.Error())
+	}
+	return nil
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !webhook.ValidEventType(eventType) {
+			return appError.NewValidationError("event_types", "unknown event type "+eventType)
+		}
+	}
+	return nil
+}
+
+// newWebhookSecret returns a random key to sign webhook payloads with
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
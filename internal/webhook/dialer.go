@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// ErrForbiddenAddress is returned when a webhook endpoint resolves to an
+// address on the internal network
+var ErrForbiddenAddress = errors.New("webhook endpoint resolves to a forbidden address")
+
+// sharedAddressSpace is the carrier-grade NAT range, which isn't routable on the internet either
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// ValidateURL checks that a webhook endpoint is an https URL with a host
+func ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return errors.New("must use https")
+	}
+	if u.Hostname() == "" {
+		return errors.New("must have a host")
+	}
+	if u.User != nil {
+		return errors.New("must not contain credentials")
+	}
+	return nil
+}
+
+// newClient returns an HTTP client that only connects to public addresses.
+// The address is checked when dialing, after the host is resolved, so that a
+// DNS name pointing at the internal network is refused too. Redirects aren't
+// followed and proxies from the environment aren't used, as both would dial
+// somewhere else than the checked address
+func newClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: checkAddress,
+	}
+	transport := &http.Transport{
+		Proxy:               nil,
+		DialContext:         dialer.DialContext,
+		TLSHandshakeTimeout: timeout,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkAddress is the dialer's Control function, refusing connections to
+// loopback, private, link-local and other non-public addresses
+func checkAddress(network, address string, _ syscall.RawConn) error {
+	if network != "tcp4" && network != "tcp6" {
+		return fmt.Errorf("%w: network %s", ErrForbiddenAddress, network)
+	}
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, address)
+	}
+	if !publicAddress(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// publicAddress reports whether the address is routable on the internet
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch {
+	case !addr.IsValid(),
+		addr.IsUnspecified(),
+		addr.IsLoopback(),
+		addr.IsPrivate(),
+		addr.IsLinkLocalUnicast(),
+		addr.IsLinkLocalMulticast(),
+		addr.IsInterfaceLocalMulticast(),
+		addr.IsMulticast(),
+		sharedAddressSpace.Contains(addr):
+		return false
+	}
+	// 0.0.0.0/8 means "this network" and is dialed as the local host
+	if addr.Is4() && addr.As4()[0] == 0 {
+		return false
+	}
+	return true
+}
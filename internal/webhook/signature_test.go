@@ -0,0 +1,58 @@
+package webhook
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	const secret = "whsec_test"
+	body := []byte(`{"order_id":42,"status":"shipped"}`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	tolerance := 5 * time.Minute
+
+	tests := []struct {
+		name    string
+		secret  string
+		header  string
+		body    []byte
+		wantErr string
+	}{
+		{name: "round trip", secret: secret, header: Sign(secret, now, body), body: body},
+		{name: "rotated secret among signatures", secret: secret, header: Sign("whsec_old", now, body) + ",v1=" + computeSignature(secret, timestamp, body), body: body},
+		{name: "tampered body", secret: secret, header: Sign(secret, now, body), body: []byte(`{"order_id":43,"status":"shipped"}`), wantErr: "signature mismatch"},
+		{name: "wrong secret", secret: "whsec_other", header: Sign(secret, now, body), body: body, wantErr: "signature mismatch"},
+		{name: "empty header", secret: secret, header: "", body: body, wantErr: "malformed signature header"},
+		{name: "no timestamp", secret: secret, header: "v1=" + computeSignature(secret, timestamp, body), body: body, wantErr: "malformed signature header"},
+		{name: "no signature", secret: secret, header: "t=" + timestamp, body: body, wantErr: "malformed signature header"},
+		{name: "timestamp not a number", secret: secret, header: "t=yesterday,v1=abc", body: body, wantErr: "malformed signature timestamp"},
+		{name: "too old", secret: secret, header: Sign(secret, now.Add(-tolerance-time.Minute), body), body: body, wantErr: "outside of tolerance"},
+		{name: "too far in the future", secret: secret, header: Sign(secret, now.Add(tolerance+time.Minute), body), body: body, wantErr: "outside of tolerance"},
+		{name: "within tolerance", secret: secret, header: Sign(secret, now.Add(-tolerance+time.Minute), body), body: body},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.secret, tt.header, tt.body, tolerance)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Verify = %v, want it verified", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Verify = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerifyWithoutTolerance(t *testing.T) {
+	body := []byte(`{}`)
+	header := Sign("whsec_test", time.Now().Add(-24*time.Hour), body)
+	if err := Verify("whsec_test", header, body, 0); err != nil {
+		t.Errorf("Verify = %v, want any timestamp accepted without a tolerance", err)
+	}
+}
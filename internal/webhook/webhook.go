@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/broker"
+	"ecom-go/internal/config"
+	"ecom-go/internal/events"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	"ecom-go/pkg/logger"
+)
+
+// Queues the worker consumes to send webhooks. Events are fanned out to one
+// delivery per subscribed endpoint, so that each endpoint is retried on its own
+const (
+	FanoutQueue   = "worker.webhooks"
+	DeliveryQueue = "worker.webhooks.deliver"
+)
+
+// Bindings are the event types partners can subscribe to
+var Bindings = []string{"order.*", "product.*", events.StockChanged}
+
+// maxDrainedBody is how much of an endpoint's response is read, and discarded,
+// so that the connection can be reused
+const maxDrainedBody = 4096
+
+// Payload is the body posted to webhook endpoints
+type Payload struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// ValidEventType reports whether partners can subscribe to the event type or pattern
+func ValidEventType(eventType string) bool {
+	if eventType == "*" {
+		return true
+	}
+	for _, binding := range Bindings {
+		if models.MatchEventType(binding, eventType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatcher sends events to the webhook endpoints subscribed to them
+type Dispatcher struct {
+	repo         repository.WebhookRepository
+	broker       broker.Broker
+	client       *http.Client
+	disableAfter int
+}
+
+// NewDispatcher creates a new webhook dispatcher
+func NewDispatcher(repo repository.WebhookRepository, b broker.Broker, cfg config.WebhooksConfig) *Dispatcher {
+	return &Dispatcher{
+		repo:         repo,
+		broker:       b,
+		client:       newClient(cfg.Timeout),
+		disableAfter: cfg.DisableAfter,
+	}
+}
+
+// Fanout queues a delivery of the event for every active endpoint subscribed to it
+func (d *Dispatcher) Fanout(ctx context.Context, msg broker.Message) error {
+	subscriptions, err := d.repo.ListActive(ctx)
+	if err != nil {
+		return fmt.Errorf("listing webhook subscriptions: %w", err)
+	}
+
+	for _, subscription := range subscriptions {
+		if !subscription.Subscribes(msg.Type) {
+			continue
+		}
+
+		delivery, err := broker.NewMessage(events.WebhookDeliveryRequested, events.WebhookDeliveryRequestedEvent{
+			SubscriptionID: subscription.ID,
+			EventID:        msg.ID,
+			EventType:      msg.Type,
+			OccurredAt:     msg.Timestamp,
+			Payload:        msg.Body,
+		})
+		if err != nil {
+			return err
+		}
+		// Fanning out again after a failure does not queue the same delivery twice
+		delivery.Key = msg.ID + ":" + strconv.Itoa(subscription.ID)
+		if err := d.broker.PublishToQueue(ctx, DeliveryQueue, delivery, 0); err != nil {
+			return fmt.Errorf("queueing webhook delivery: %w", err)
+		}
+	}
+	return nil
+}
+
+// Deliver posts an event to a webhook endpoint and logs the outcome. An error
+// is returned when the endpoint did not accept the event, for the worker to retry
+func (d *Dispatcher) Deliver(ctx context.Context, request events.WebhookDeliveryRequestedEvent) error {
+	subscription, err := d.repo.GetByID(ctx, request.SubscriptionID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			logger.Info("Dropping webhook delivery for deleted subscription", "subscription_id", request.SubscriptionID, "event_id", request.EventID)
+			return nil
+		}
+		return fmt.Errorf("getting webhook subscription: %w", err)
+	}
+	if !subscription.Active {
+		logger.Info("Dropping webhook delivery for disabled subscription", "subscription_id", subscription.ID, "event_id", request.EventID)
+		return nil
+	}
+
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        request.EventID,
+		EventType:      request.EventType,
+		OccurredAt:     request.OccurredAt,
+		Payload:        request.Payload,
+	}
+	sendErr := d.post(ctx, subscription, delivery)
+	delivery.Success = sendErr == nil
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	subscription, err = d.repo.RecordDelivery(ctx, delivery, d.disableAfter)
+	if err != nil {
+		logger.Error("Failed to log webhook delivery", "subscription_id", delivery.SubscriptionID, "event_id", delivery.EventID, "error", err)
+	} else if !subscription.Active {
+		logger.Warn("Disabled failing webhook endpoint", "subscription_id", subscription.ID, "url", subscription.URL, "failures", subscription.ConsecutiveFailures)
+		return nil
+	}
+	return sendErr
+}
+
+// post sends the signed event to the endpoint, filling in the status and
+// latency of the delivery. The response body isn't kept
+func (d *Dispatcher) post(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) error {
+	body, err := json.Marshal(Payload{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.OccurredAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("encoding webhook payload: %w", err)
+	}
+
+	if err := ValidateURL(subscription.URL); err != nil {
+		return fmt.Errorf("webhook endpoint %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ecom-go-webhooks/1.0")
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, time.Now(), body))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	delivery.DurationMS = time.Since(start).Milliseconds()
+	if err != nil {
+		return fmt.Errorf("posting webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrainedBody))
+	delivery.StatusCode = resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook endpoint responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
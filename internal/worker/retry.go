@@ -0,0 +1,71 @@
+package worker
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"ecom-go/internal/broker"
+	"ecom-go/internal/config"
+)
+
+// Message headers used to track retries
+const (
+	headerAttempt   = "x-attempt"
+	headerLastError = "x-last-error"
+)
+
+// permanentError is a failure that retrying will not fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks a handler error as not worth retrying, the message is
+// dead-lettered right away
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether the error was marked as permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+// attemptOf returns which attempt at handling the message this is, starting at 1
+func attemptOf(msg broker.Message) int {
+	attempt, err := strconv.Atoi(msg.Headers[headerAttempt])
+	if err != nil || attempt < 1 {
+		return 1
+	}
+	return attempt
+}
+
+// backoff returns the delay before retrying a message that failed the given
+// attempt. The delay doubles with every attempt up to the policy's maximum,
+// and half of it is randomized so that retries of messages that failed
+// together are spread out.
+func backoff(policy config.RetryConfig, attempt int) time.Duration {
+	delay := policy.InitialBackoff
+	for i := 1; i < attempt && delay < policy.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > policy.MaxBackoff {
+		delay = policy.MaxBackoff
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
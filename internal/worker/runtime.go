@@ -0,0 +1,269 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"ecom-go/internal/broker"
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+	"ecom-go/pkg/logger"
+)
+
+// messageKey is the context key of the message being handled
+type messageKey struct{}
+
+// MessageFromContext returns the message whose handler received the context
+func MessageFromContext(ctx context.Context) (broker.Message, bool) {
+	msg, ok := ctx.Value(messageKey{}).(broker.Message)
+	return msg, ok
+}
+
+// Handler processes a message taken from a queue
+type Handler func(ctx context.Context, msg broker.Message) error
+
+// QueueConfig describes a queue the runtime consumes and how
+type QueueConfig struct {
+	Name string
+	// Bindings are the routing key patterns routed to the queue
+	Bindings []string
+	// Prefetch overrides the worker's default prefetch limit when set
+	Prefetch int
+	// Concurrency overrides the worker's default concurrency limit when set
+	Concurrency int
+	// Retry overrides the fields of the worker's default retry policy that are set
+	Retry config.RetryConfig
+}
+
+// Runtime consumes queues from a broker and dispatches their messages to
+// handlers. Failed messages are retried with exponential backoff and
+// dead-lettered once they run out of attempts.
+type Runtime struct {
+	broker          broker.Broker
+	deadLetters     repository.DeadLetterRepository
+	prefetch        int
+	concurrency     int
+	retry           config.RetryConfig
+	shutdownTimeout time.Duration
+	consumers       []consumer
+}
+
+type consumer struct {
+	queue   QueueConfig
+	handler Handler
+}
+
+// NewRuntime creates a new worker runtime
+func NewRuntime(b broker.Broker, deadLetters repository.DeadLetterRepository, cfg config.WorkerConfig) *Runtime {
+	return &Runtime{
+		broker:          b,
+		deadLetters:     deadLetters,
+		prefetch:        cfg.Prefetch,
+		concurrency:     cfg.Concurrency,
+		retry:           cfg.Retry,
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// Handle registers a handler for the messages of a queue
+func (r *Runtime) Handle(queue QueueConfig, handler Handler) {
+	if queue.Prefetch <= 0 {
+		queue.Prefetch = r.prefetch
+	}
+	if queue.Concurrency <= 0 {
+		queue.Concurrency = r.concurrency
+	}
+	if queue.Concurrency <= 0 {
+		queue.Concurrency = 1
+	}
+	if queue.Retry.MaxAttempts <= 0 {
+		queue.Retry.MaxAttempts = r.retry.MaxAttempts
+	}
+	if queue.Retry.InitialBackoff <= 0 {
+		queue.Retry.InitialBackoff = r.retry.InitialBackoff
+	}
+	if queue.Retry.MaxBackoff <= 0 {
+		queue.Retry.MaxBackoff = r.retry.MaxBackoff
+	}
+	r.consumers = append(r.consumers, consumer{queue: queue, handler: handler})
+}
+
+// Register registers a handler receiving the JSON decoded body of the queue's messages
+func Register[T any](r *Runtime, queue QueueConfig, handler func(ctx context.Context, payload T) error) {
+	r.Handle(queue, func(ctx context.Context, msg broker.Message) error {
+		var payload T
+		if err := json.Unmarshal(msg.Body, &payload); err != nil {
+			return Permanent(fmt.Errorf("error decoding %s message: %w", msg.Type, err))
+		}
+		return handler(ctx, payload)
+	})
+}
+
+// Run consumes all registered queues until the context is canceled. Messages
+// already being handled then get the shutdown timeout to finish before the
+// context passed to their handlers is canceled as well. Run returns an error
+// when a queue stops delivering messages before that, as when the broker
+// connection is lost, after draining the others the same way
+func (r *Runtime) Run(ctx context.Context) error {
+	if len(r.consumers) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+
+	// Handlers keep running past the shutdown signal until the drain deadline
+	handlerCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	// Consuming stops on shutdown, or once any queue stops delivering
+	consumeCtx, stopConsuming := context.WithCancel(ctx)
+	defer stopConsuming()
+
+	// lost receives the first queue whose deliveries ended while still consuming
+	lost := make(chan string, 1)
+
+	var wg sync.WaitGroup
+	for _, c := range r.consumers {
+		deliveries, err := r.broker.Consume(consumeCtx, broker.QueueSpec{
+			Name:     c.queue.Name,
+			Bindings: c.queue.Bindings,
+			Prefetch: c.queue.Prefetch,
+		})
+		if err != nil {
+			stopConsuming()
+			cancelHandlers()
+			wg.Wait()
+			return fmt.Errorf("error consuming queue %s: %w", c.queue.Name, err)
+		}
+
+		logger.Info("Consuming queue", "queue", c.queue.Name, "prefetch", c.queue.Prefetch, "concurrency", c.queue.Concurrency)
+		for i := 0; i < c.queue.Concurrency; i++ {
+			wg.Add(1)
+			go func(c consumer) {
+				defer wg.Done()
+				for delivery := range deliveries {
+					r.process(handlerCtx, c, delivery)
+				}
+				if consumeCtx.Err() == nil {
+					select {
+					case lost <- c.queue.Name:
+					default:
+					}
+				}
+			}(c)
+		}
+	}
+
+	var lostErr error
+	select {
+	case <-ctx.Done():
+		logger.Info("Draining in-flight messages", "timeout", r.shutdownTimeout)
+	case queue := <-lost:
+		lostErr = fmt.Errorf("queue %s stopped delivering messages", queue)
+		logger.Error("Stopped consuming, draining in-flight messages", "queue", queue, "timeout", r.shutdownTimeout)
+		stopConsuming()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	var timeout <-chan time.Time
+	if r.shutdownTimeout > 0 {
+		timer := time.NewTimer(r.shutdownTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-done:
+		return lostErr
+	case <-timeout:
+		cancelHandlers()
+		<-done
+		return errors.Join(lostErr, errors.New("shutdown timeout exceeded while draining messages"))
+	}
+}
+
+// process runs the handler for a delivery and acknowledges it only when the
+// handler succeeds, or once the failed message has been scheduled for a retry
+// or dead-lettered
+func (r *Runtime) process(ctx context.Context, c consumer, delivery broker.Delivery) {
+	msg := delivery.Message()
+	attempt := attemptOf(msg)
+
+	handlerCtx := logger.WithTraceID(context.WithValue(ctx, messageKey{}, msg), msg.ID)
+	handlerCtx = logger.WithFields(handlerCtx, "queue", c.queue.Name, "type", msg.Type)
+	err := r.safeHandle(handlerCtx, c.handler, msg)
+	if err == nil {
+		if err := delivery.Ack(); err != nil {
+			logger.Error("Failed to acknowledge message", "queue", c.queue.Name, "message_id", msg.ID, "error", err)
+		}
+		return
+	}
+	logger.Error("Failed to handle message", "queue", c.queue.Name, "type", msg.Type, "message_id", msg.ID, "attempt", attempt, "error", err)
+
+	if attempt < c.queue.Retry.MaxAttempts && !IsPermanent(err) {
+		err = r.retryLater(ctx, c.queue, msg, attempt, err)
+	} else {
+		err = r.deadLetter(ctx, c.queue, msg, attempt, err)
+	}
+	if err != nil {
+		// Hand the message back to the broker rather than losing it
+		logger.Error("Failed to reschedule message", "queue", c.queue.Name, "message_id", msg.ID, "error", err)
+		if err := delivery.Nack(true); err != nil {
+			logger.Error("Failed to reject message", "queue", c.queue.Name, "message_id", msg.ID, "error", err)
+		}
+		return
+	}
+
+	if err := delivery.Ack(); err != nil {
+		logger.Error("Failed to acknowledge message", "queue", c.queue.Name, "message_id", msg.ID, "error", err)
+	}
+}
+
+// retryLater publishes a copy of the message back to its queue after a backoff
+func (r *Runtime) retryLater(ctx context.Context, queue QueueConfig, msg broker.Message, attempt int, cause error) error {
+	headers := make(map[string]string, len(msg.Headers)+2)
+	for k, v := range msg.Headers {
+		headers[k] = v
+	}
+	headers[headerAttempt] = strconv.Itoa(attempt + 1)
+	headers[headerLastError] = cause.Error()
+	msg.Headers = headers
+
+	delay := backoff(queue.Retry, attempt)
+	logger.Info("Retrying message", "queue", queue.Name, "message_id", msg.ID, "attempt", attempt+1, "delay", delay)
+	return r.broker.PublishToQueue(ctx, queue.Name, msg, delay)
+}
+
+// deadLetter stores a message that will not be retried along with the reason it failed
+func (r *Runtime) deadLetter(ctx context.Context, queue QueueConfig, msg broker.Message, attempt int, cause error) error {
+	logger.Warn("Dead-lettering message", "queue", queue.Name, "message_id", msg.ID, "attempts", attempt)
+	return r.deadLetters.Create(ctx, &models.DeadLetter{
+		Queue:       queue.Name,
+		MessageID:   msg.ID,
+		MessageType: msg.Type,
+		Body:        string(msg.Body),
+		Headers:     msg.Headers,
+		Attempts:    attempt,
+		Reason:      cause.Error(),
+	})
+}
+
+// safeHandle runs the handler, turning a panic into an error
+func (r *Runtime) safeHandle(ctx context.Context, handler Handler, msg broker.Message) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panicked: %v", p)
+		}
+	}()
+	return handler(ctx, msg)
+}
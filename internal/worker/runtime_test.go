@@ -0,0 +1,303 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ecom-go/internal/broker"
+	"ecom-go/internal/config"
+	"ecom-go/internal/models"
+	"ecom-go/internal/repository"
+)
+
+// fakeDeadLetters stores dead letters in memory, failing the first failures
+// attempts at storing one
+type fakeDeadLetters struct {
+	repository.DeadLetterRepository
+	mu          sync.Mutex
+	deadLetters []*models.DeadLetter
+	failures    int
+}
+
+func (r *fakeDeadLetters) Create(ctx context.Context, deadLetter *models.DeadLetter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures > 0 {
+		r.failures--
+		return errors.New("database unavailable")
+	}
+	r.deadLetters = append(r.deadLetters, deadLetter)
+	return nil
+}
+
+func (r *fakeDeadLetters) list() []*models.DeadLetter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*models.DeadLetter(nil), r.deadLetters...)
+}
+
+// attemptsRecorder records the messages a handler received
+type attemptsRecorder struct {
+	mu       sync.Mutex
+	messages []broker.Message
+}
+
+func (r *attemptsRecorder) record(msg broker.Message) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return len(r.messages)
+}
+
+func (r *attemptsRecorder) list() []broker.Message {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]broker.Message(nil), r.messages...)
+}
+
+// eventually waits up to a few seconds for done to report true
+func eventually(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// runRuntime runs a runtime over a memory broker with the handler registered
+// for queue "jobs", until the test ends
+func runRuntime(t *testing.T, deadLetters *fakeDeadLetters, handler Handler) *broker.MemoryBroker {
+	t.Helper()
+	b := broker.NewMemoryBroker()
+	runtime := NewRuntime(b, deadLetters, config.WorkerConfig{
+		Prefetch:        1,
+		Concurrency:     1,
+		Retry:           config.RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 4 * time.Millisecond},
+		ShutdownTimeout: time.Second,
+	})
+	runtime.Handle(QueueConfig{Name: "jobs"}, handler)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runtime.Run(ctx) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+		b.Close()
+	})
+	return b
+}
+
+func TestRuntimeRetries(t *testing.T) {
+	failure := errors.New("payment provider unavailable")
+	tests := []struct {
+		name string
+		// fail returns the error of the nth call of the handler
+		fail           func(n int) error
+		wantCalls      int
+		wantDeadLetter bool
+		wantReason     string
+	}{
+		{name: "success", fail: func(n int) error { return nil }, wantCalls: 1},
+		{
+			name: "retried until success",
+			fail: func(n int) error {
+				if n < 3 {
+					return failure
+				}
+				return nil
+			},
+			wantCalls: 3,
+		},
+		{
+			name:           "out of attempts",
+			fail:           func(n int) error { return failure },
+			wantCalls:      3,
+			wantDeadLetter: true,
+			wantReason:     failure.Error(),
+		},
+		{
+			name:           "permanent failure",
+			fail:           func(n int) error { return Permanent(failure) },
+			wantCalls:      1,
+			wantDeadLetter: true,
+			wantReason:     failure.Error(),
+		},
+		{
+			name: "panic",
+			fail: func(n int) error {
+				if n == 1 {
+					panic("nil map")
+				}
+				return nil
+			},
+			wantCalls: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deadLetters := &fakeDeadLetters{}
+			calls := &attemptsRecorder{}
+			b := runRuntime(t, deadLetters, func(ctx context.Context, msg broker.Message) error {
+				if got, ok := MessageFromContext(ctx); !ok || got.ID != msg.ID {
+					t.Error("message missing from the handler's context")
+				}
+				return tt.fail(calls.record(msg))
+			})
+
+			msg := broker.Message{ID: "m1", Type: "order.created", Body: []byte(`{"order_id":1}`), Headers: map[string]string{"trace": "t1"}}
+			if err := b.PublishToQueue(context.Background(), "jobs", msg, 0); err != nil {
+				t.Fatal(err)
+			}
+			eventually(t, "the message to be settled", func() bool {
+				return len(calls.list()) >= tt.wantCalls && b.Pending("jobs") == 0
+			})
+
+			received := calls.list()
+			if len(received) != tt.wantCalls {
+				t.Fatalf("handler called %d times, want %d", len(received), tt.wantCalls)
+			}
+			for i, got := range received {
+				if attempt := attemptOf(got); attempt != i+1 {
+					t.Errorf("call %d saw attempt %d", i+1, attempt)
+				}
+				if got.ID != msg.ID || got.Headers["trace"] != "t1" {
+					t.Errorf("call %d got %+v, want the original message", i+1, got)
+				}
+				if i > 0 && got.Headers[headerLastError] == "" {
+					t.Errorf("retry %d does not carry the last error", i+1)
+				}
+			}
+
+			stored := deadLetters.list()
+			if !tt.wantDeadLetter {
+				if len(stored) != 0 {
+					t.Errorf("dead-lettered %+v", stored[0])
+				}
+				return
+			}
+			if len(stored) != 1 {
+				t.Fatalf("%d dead letters, want 1", len(stored))
+			}
+			deadLetter := stored[0]
+			if deadLetter.Queue != "jobs" || deadLetter.MessageID != msg.ID || deadLetter.MessageType != msg.Type ||
+				deadLetter.Body != string(msg.Body) || deadLetter.Attempts != tt.wantCalls || deadLetter.Reason != tt.wantReason {
+				t.Errorf("dead letter = %+v", deadLetter)
+			}
+		})
+	}
+}
+
+func TestRuntimeUndecodableMessage(t *testing.T) {
+	deadLetters := &fakeDeadLetters{}
+	b := broker.NewMemoryBroker()
+	defer b.Close()
+	runtime := NewRuntime(b, deadLetters, config.WorkerConfig{Retry: config.RetryConfig{MaxAttempts: 3}})
+	calls := 0
+	Register(runtime, QueueConfig{Name: "jobs"}, func(ctx context.Context, payload struct{ OrderID int }) error {
+		calls++
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- runtime.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	if err := b.PublishToQueue(ctx, "jobs", broker.Message{ID: "m1", Type: "order.created", Body: []byte("{")}, 0); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the message to be dead-lettered", func() bool { return len(deadLetters.list()) == 1 })
+	if calls != 0 || deadLetters.list()[0].Attempts != 1 {
+		t.Errorf("undecodable message handled %d times, dead-lettered after %d attempts; want dead-lettered right away", calls, deadLetters.list()[0].Attempts)
+	}
+}
+
+func TestRuntimeDeadLetterFailure(t *testing.T) {
+	// The message is handed back to the broker while it can't be dead-lettered
+	deadLetters := &fakeDeadLetters{failures: 1}
+	calls := &attemptsRecorder{}
+	b := runRuntime(t, deadLetters, func(ctx context.Context, msg broker.Message) error {
+		calls.record(msg)
+		return Permanent(errors.New("unknown product"))
+	})
+
+	if err := b.PublishToQueue(context.Background(), "jobs", broker.Message{ID: "m1", Type: "order.created"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	eventually(t, "the message to be dead-lettered", func() bool {
+		return len(deadLetters.list()) == 1 && b.Pending("jobs") == 0
+	})
+	if received := calls.list(); len(received) != 2 || attemptOf(received[1]) != 1 {
+		t.Errorf("handled %d times, want the requeued message handled again as the same attempt", len(received))
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := config.RetryConfig{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second}
+	tests := []struct {
+		attempt int
+		want    time.Duration // Delays are randomized between half of it and it
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		for range 20 {
+			if got := backoff(policy, tt.attempt); got < tt.want/2 || got > tt.want {
+				t.Errorf("backoff after attempt %d = %s, want between %s and %s", tt.attempt, got, tt.want/2, tt.want)
+			}
+		}
+	}
+	if got := backoff(config.RetryConfig{}, 1); got != 0 {
+		t.Errorf("backoff without a policy = %s, want 0", got)
+	}
+}
+
+func TestRuntimeStopsWhenDeliveriesEnd(t *testing.T) {
+	b := broker.NewMemoryBroker()
+	runtime := NewRuntime(b, &fakeDeadLetters{}, config.WorkerConfig{Concurrency: 1, ShutdownTimeout: time.Second})
+	handled := make(chan struct{}, 1)
+	runtime.Handle(QueueConfig{Name: "jobs"}, func(ctx context.Context, msg broker.Message) error {
+		handled <- struct{}{}
+		return nil
+	})
+	runtime.Handle(QueueConfig{Name: "emails"}, func(ctx context.Context, msg broker.Message) error { return nil })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error)
+	go func() { done <- runtime.Run(ctx) }()
+
+	// A closed broker ends the deliveries, as a lost connection does
+	if err := b.PublishToQueue(ctx, "jobs", broker.Message{ID: "1"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	<-handled
+	b.Close()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "stopped delivering messages") {
+			t.Errorf("Run = %v, want an error for the queue that stopped delivering", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run still blocking after the deliveries ended")
+	}
+}
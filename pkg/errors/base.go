@@ -9,11 +9,12 @@ type ErrorType string
 
 // Error types
 const (
-	ErrorTypeServer       ErrorType = "SERVER_ERROR"
-	ErrorTypeNotFound     ErrorType = "NOT_FOUND"
-	ErrorTypeBadRequest   ErrorType = "BAD_REQUEST"
-	ErrorTypeUnauthorized ErrorType = "UNAUTHORIZED"
-	ErrorTypeForbidden    ErrorType = "FORBIDDEN"
+	ErrorTypeServer          ErrorType = "SERVER_ERROR"
+	ErrorTypeNotFound        ErrorType = "NOT_FOUND"
+	ErrorTypeBadRequest      ErrorType = "BAD_REQUEST"
+	ErrorTypeUnauthorized    ErrorType = "UNAUTHORIZED"
+	ErrorTypeForbidden       ErrorType = "FORBIDDEN"
+	ErrorTypeTooManyRequests ErrorType = "TOO_MANY_REQUESTS"
 )
 
 // ErrorItem represents a single error message
@@ -53,6 +54,11 @@ func (e *baseError) Error() string {
 	return e.message
 }
 
+// Unwrap returns the cause of the error
+func (e *baseError) Unwrap() error {
+	return e.cause
+}
+
 // Type returns the error type
 func (e *baseError) Type() ErrorType {
 	return e.errorType
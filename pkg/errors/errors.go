@@ -69,6 +69,19 @@ func NewForbiddenError(message string, cause ...error) BaseError {
 	return err
 }
 
+// NewTooManyRequestsError creates a new error for a client that exceeded its rate limit
+func NewTooManyRequestsError(message string, cause ...error) BaseError {
+	err := &baseError{
+		errorType:  ErrorTypeTooManyRequests,
+		message:    message,
+		statusCode: http.StatusTooManyRequests,
+	}
+	if len(cause) > 0 {
+		err.cause = cause[0]
+	}
+	return err
+}
+
 // ValidationError represents a validation error with field information
 func NewValidationError(field, message string) BaseError {
 	return &baseError{
@@ -0,0 +1,73 @@
+package logger
+
+import "context"
+
+// traceIDKey is the context key of the trace ID
+type traceIDKey struct{}
+
+// fieldsKey is the context key of the fields added to the logs of a request or job
+type fieldsKey struct{}
+
+// Logger writes logs carrying the fields of the request or job they are about
+type Logger struct {
+	fields []interface{}
+}
+
+// WithTraceID returns a context carrying the ID that ties together the logs of
+// a request or job
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID of the context, or "" if it has none
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// WithFields returns a context whose logger adds the key-value pairs to every log
+func WithFields(ctx context.Context, args ...interface{}) context.Context {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	return context.WithValue(ctx, fieldsKey{}, concat(fields, args))
+}
+
+// FromContext returns the logger of a request or job, adding its trace ID and
+// the fields of WithFields to every log
+func FromContext(ctx context.Context) *Logger {
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	if traceID := TraceIDFromContext(ctx); traceID != "" {
+		return &Logger{fields: concat([]interface{}{"trace_id", traceID}, fields)}
+	}
+	return &Logger{fields: fields}
+}
+
+// With returns a logger adding the key-value pairs to every log, along with those of l
+func (l *Logger) With(args ...interface{}) *Logger {
+	return &Logger{fields: concat(l.fields, args)}
+}
+
+// Debug logs a debug message
+func (l *Logger) Debug(message string, args ...interface{}) {
+	write(logger.Load().Debug(), message, l.fields, args)
+}
+
+// Info logs an info message
+func (l *Logger) Info(message string, args ...interface{}) {
+	write(logger.Load().Info(), message, l.fields, args)
+}
+
+// Warn logs a warning message
+func (l *Logger) Warn(message string, args ...interface{}) {
+	write(logger.Load().Warn(), message, l.fields, args)
+}
+
+// Error logs an error message
+func (l *Logger) Error(message string, args ...interface{}) {
+	write(logger.Load().Error(), message, l.fields, args)
+}
+
+// concat returns a new slice of the key-value pairs of a followed by those of b
+func concat(a, b []interface{}) []interface{} {
+	fields := make([]interface{}, 0, len(a)+len(b))
+	return append(append(fields, a...), b...)
+}
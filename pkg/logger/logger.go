@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -11,42 +13,104 @@ import (
 )
 
 var (
-	// Default logger instance
-	logger zerolog.Logger
+	// Default logger instance, replaced as a whole when the logger is reconfigured
+	logger atomic.Pointer[zerolog.Logger]
+
+	// mu guards the settings the logger is built from
+	mu       sync.Mutex
+	output   io.Writer = os.Stdout
+	format             = "console"
+	sampling Sampling
 )
 
+// Options configure the logger
+type Options struct {
+	// Format is "console" for human-readable logs, or "json" for one JSON
+	// object per line as log pipelines parse
+	Format string
+	// Level is the lowest level logged: "debug", "info", "warn" or "error"
+	Level string
+	// Sampling limits the debug logs written
+	Sampling Sampling
+}
+
+// Sampling limits the debug logs written, the other levels are never sampled.
+// The zero value writes every debug log
+type Sampling struct {
+	// Burst is how many debug logs are written per Period before sampling them, 0 for none
+	Burst  uint32
+	Period time.Duration
+	// Every is the share of the debug logs past the burst that is written, one
+	// in Every. Without a burst 0 writes them all, with one 0 writes none
+	Every uint32
+}
+
 // init initializes the logger
 func init() {
-	// Set up console writer
-	output := zerolog.ConsoleWriter{
-		Out:        os.Stdout,
-		TimeFormat: time.RFC3339,
+	build()
+
+	// Default log level
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+}
+
+// Configure sets the format, level and sampling of the logger
+func Configure(opts Options) {
+	mu.Lock()
+	format = opts.Format
+	sampling = opts.Sampling
+	build()
+	mu.Unlock()
+
+	SetLevel(opts.Level)
+	Info("Logger initialized", "format", opts.Format, "log_level", opts.Level)
+}
+
+// SetOutput sets the logger output
+func SetOutput(w io.Writer) {
+	mu.Lock()
+	defer mu.Unlock()
+	output = w
+	build()
+}
+
+// build replaces the logger with one of the current settings, the caller holds mu
+func build() {
+	writer := output
+	if format != "json" {
+		writer = zerolog.ConsoleWriter{
+			Out:        output,
+			TimeFormat: time.RFC3339,
+		}
 	}
 
-	// Configure logger
-	logger = zerolog.New(output).
+	// Configure logger, the caller is the code calling this package rather than the package itself
+	l := zerolog.New(writer).
 		With().
 		Timestamp().
-		Caller().
+		CallerWithSkipFrameCount(zerolog.CallerSkipFrameCount + 2).
 		Logger()
+	if sampler := sampling.sampler(); sampler != nil {
+		l = l.Sample(zerolog.LevelSampler{DebugSampler: sampler})
+	}
+	logger.Store(&l)
 
 	// Set global logger
-	log.Logger = logger
-
-	// Default log level
-	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-
-	Info("Logger initialized")
+	log.Logger = l
 }
 
-// SetOutput sets the logger output
-func SetOutput(w io.Writer) {
-	consoleWriter := zerolog.ConsoleWriter{
-		Out:        w,
-		TimeFormat: time.RFC3339,
+// sampler returns the sampler of the debug logs, nil when they are all written
+func (s Sampling) sampler() zerolog.Sampler {
+	var next zerolog.Sampler
+	if s.Every > 0 {
+		next = &zerolog.BasicSampler{N: s.Every}
+	}
+	if s.Burst > 0 {
+		return &zerolog.BurstSampler{Burst: s.Burst, Period: s.Period, NextSampler: next}
 	}
-	logger = logger.Output(consoleWriter)
-	log.Logger = logger
+	if s.Every > 1 {
+		return next
+	}
+	return nil
 }
 
 // SetLevel sets the logger level
@@ -65,37 +129,44 @@ func SetLevel(level string) {
 	}
 }
 
+// DebugEnabled reports whether debug logs are written
+func DebugEnabled() bool {
+	return zerolog.GlobalLevel() <= zerolog.DebugLevel
+}
+
 // Debug logs a debug message
 func Debug(message string, args ...interface{}) {
-	event := logger.Debug()
-	appendArgs(event, args...)
-	event.Msg(message)
+	write(logger.Load().Debug(), message, nil, args)
 }
 
 // Info logs an info message
 func Info(message string, args ...interface{}) {
-	event := logger.Info()
-	appendArgs(event, args...)
-	event.Msg(message)
+	write(logger.Load().Info(), message, nil, args)
 }
 
 // Warn logs a warning message
 func Warn(message string, args ...interface{}) {
-	event := logger.Warn()
-	appendArgs(event, args...)
-	event.Msg(message)
+	write(logger.Load().Warn(), message, nil, args)
 }
 
 // Error logs an error message
 func Error(message string, args ...interface{}) {
-	event := logger.Error()
-	appendArgs(event, args...)
-	event.Msg(message)
+	write(logger.Load().Error(), message, nil, args)
 }
 
 // Fatal logs a fatal message and exits
 func Fatal(message string, args ...interface{}) {
-	event := logger.Fatal()
+	write(logger.Load().Fatal(), message, nil, args)
+}
+
+// write adds the fields and key-value pairs to the log event and writes it.
+// It must be called directly by the logging functions, for the caller to be reported
+func write(event *zerolog.Event, message string, fields, args []interface{}) {
+	if event == nil {
+		// The level is disabled or the event was sampled out
+		return
+	}
+	appendArgs(event, fields...)
 	appendArgs(event, args...)
 	event.Msg(message)
 }
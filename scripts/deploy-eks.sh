@@ -53,6 +53,10 @@ aws eks update-kubeconfig --region $REGION --name $CLUSTER_NAME
 echo "=== Creating namespace if not exists ==="
 kubectl get namespace $NAMESPACE >/dev/null 2>&1 || kubectl create namespace $NAMESPACE
 
+# Create the secret signing access tokens once, recreating it would sign every user out
+kubectl get secret auth-secret -n $NAMESPACE >/dev/null 2>&1 || \
+  kubectl create secret generic auth-secret -n $NAMESPACE --from-literal=secret="$(openssl rand -hex 32)"
+
 # Base64 encode DB credentials
 DB_USER_BASE64=$(echo -n $DB_USER | base64)
 DB_PASSWORD_BASE64=$(echo -n $DB_PASSWORD | base64)
@@ -34,6 +34,10 @@ kubectl config use-context minikube
 # Create namespace if it doesn't exist
 kubectl get namespace $NAMESPACE >/dev/null 2>&1 || kubectl create namespace $NAMESPACE
 
+# Create the secret signing access tokens once, recreating it would sign every user out
+kubectl get secret auth-secret -n $NAMESPACE >/dev/null 2>&1 || \
+  kubectl create secret generic auth-secret -n $NAMESPACE --from-literal=secret="$(openssl rand -hex 32)"
+
 # Build Docker images
 echo "Building API Docker image..."
 docker build -t $APP_NAME-api:latest -f ./docker/api/Dockerfile .